@@ -0,0 +1,412 @@
+package p256k1
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+// BIP-322 message tag
+var bip322MessageTag = []byte("BIP0322-signed-message")
+
+// BIP-341 signature hash tag
+var tapSighashTag = []byte("TapSighash")
+
+// Human-readable parts of segwit addresses for each Bitcoin network
+const (
+	Bech32Mainnet = "bc"
+	Bech32Testnet = "tb"
+	Bech32Regtest = "bcrt"
+)
+
+// Sighash types accepted in BIP-322 simple signatures
+const (
+	sighashDefault = 0x00
+	sighashAll     = 0x01
+)
+
+// BIP322MessageHash computes the BIP-322 message hash, the tagged hash
+// "BIP0322-signed-message" of the message
+func BIP322MessageHash(message string) [32]byte {
+	return TaggedHash(bip322MessageTag, []byte(message))
+}
+
+// BIP322Verify verifies a BIP-322 "simple" message signature for a P2WPKH
+// or P2TR (key path) address. signature is the serialized witness stack, i.e.
+// the base64-decoded simple signature. The address may be for mainnet,
+// testnet or regtest; use BIP322VerifyNetwork to require a particular one.
+//
+// An error is returned if the address is malformed or not for a Bitcoin
+// network, or if the signature is malformed or uses an unsupported script
+// type or sighash type. Otherwise the result reports whether the signature
+// is valid.
+func BIP322Verify(address, message string, signature []byte) (bool, error) {
+	hrp, err := bitcoinAddressHRP(address)
+	if err != nil {
+		return false, err
+	}
+	return BIP322VerifyNetwork(address, message, signature, hrp)
+}
+
+// BIP322VerifyNetwork is BIP322Verify for an address on the network with
+// human-readable part hrp, such as Bech32Mainnet. An address for any other
+// network is an error.
+func BIP322VerifyNetwork(address, message string, signature []byte, hrp string) (bool, error) {
+	version, program, err := decodeSegwitAddress(address, hrp)
+	if err != nil {
+		return false, err
+	}
+
+	witness, err := parseWitnessStack(signature)
+	if err != nil {
+		return false, err
+	}
+
+	var scriptPubKey []byte
+	switch {
+	case version == 0 && len(program) == 20:
+		scriptPubKey = append([]byte{0x00, 0x14}, program...)
+	case version == 1 && len(program) == 32:
+		scriptPubKey = append([]byte{0x51, 0x20}, program...)
+	default:
+		return false, errors.New("unsupported address type")
+	}
+
+	msgHash := BIP322MessageHash(message)
+	toSpend := bip322ToSpendTxid(scriptPubKey, msgHash)
+
+	if version == 0 {
+		return bip322VerifyP2WPKH(program, toSpend, witness)
+	}
+	return bip322VerifyP2TR(program, scriptPubKey, toSpend, witness)
+}
+
+// bip322VerifyP2WPKH checks a [signature, pubkey] witness against the
+// BIP-143 signature hash of the to_sign transaction
+func bip322VerifyP2WPKH(program []byte, toSpend [32]byte, witness [][]byte) (bool, error) {
+	if len(witness) != 2 {
+		return false, errors.New("P2WPKH witness must have 2 items")
+	}
+	sigBytes, pubBytes := witness[0], witness[1]
+
+	if len(sigBytes) == 0 || sigBytes[len(sigBytes)-1] != sighashAll {
+		return false, errors.New("unsupported sighash type")
+	}
+	var sig ECDSASignature
+	if err := ECDSASignatureParseDER(&sig, sigBytes[:len(sigBytes)-1]); err != nil {
+		return false, err
+	}
+
+	if len(pubBytes) != 33 {
+		return false, errors.New("P2WPKH requires a compressed public key")
+	}
+	keyHash := Hash160(pubBytes)
+	for i := range keyHash {
+		if keyHash[i] != program[i] {
+			return false, nil
+		}
+	}
+	var pubkey PublicKey
+	if err := ECPubkeyParse(&pubkey, pubBytes); err != nil {
+		return false, err
+	}
+
+	// BIP-143 preimage for input 0 of to_sign, spending a zero-value output
+	var preimage []byte
+	var outpoint [36]byte
+	copy(outpoint[:32], toSpend[:])
+
+	hashPrevouts := doubleSHA256(outpoint[:])
+	hashSequence := doubleSHA256(make([]byte, 4))
+	hashOutputs := doubleSHA256(bip322ToSignOutputs())
+
+	preimage = append(preimage, 0, 0, 0, 0) // nVersion
+	preimage = append(preimage, hashPrevouts[:]...)
+	preimage = append(preimage, hashSequence[:]...)
+	preimage = append(preimage, outpoint[:]...)
+	preimage = append(preimage, 0x19, 0x76, 0xa9, 0x14) // scriptCode
+	preimage = append(preimage, program...)
+	preimage = append(preimage, 0x88, 0xac)
+	preimage = append(preimage, make([]byte, 8)...) // amount
+	preimage = append(preimage, 0, 0, 0, 0)         // nSequence
+	preimage = append(preimage, hashOutputs[:]...)
+	preimage = append(preimage, 0, 0, 0, 0)          // nLockTime
+	preimage = append(preimage, sighashAll, 0, 0, 0) // sighash type
+
+	sighash := doubleSHA256(preimage)
+	return ECDSAVerify(&sig, sighash[:], &pubkey), nil
+}
+
+// bip322VerifyP2TR checks a key path witness against the BIP-341 signature
+// hash of the to_sign transaction
+func bip322VerifyP2TR(program, scriptPubKey []byte, toSpend [32]byte, witness [][]byte) (bool, error) {
+	if len(witness) != 1 {
+		return false, errors.New("P2TR key path witness must have 1 item")
+	}
+	sig := witness[0]
+
+	hashType := byte(sighashDefault)
+	switch len(sig) {
+	case 64:
+	case 65:
+		hashType = sig[64]
+		if hashType != sighashAll {
+			return false, errors.New("unsupported sighash type")
+		}
+		sig = sig[:64]
+	default:
+		return false, errors.New("invalid taproot signature length")
+	}
+
+	xonly, err := XOnlyPubkeyParse(program)
+	if err != nil {
+		return false, err
+	}
+
+	var outpoint [36]byte
+	copy(outpoint[:32], toSpend[:])
+	shaPrevouts := sha256.Sum256(outpoint[:])
+	shaAmounts := sha256.Sum256(make([]byte, 8))
+	shaScriptPubKeys := sha256.Sum256(append([]byte{byte(len(scriptPubKey))}, scriptPubKey...))
+	shaSequences := sha256.Sum256(make([]byte, 4))
+	shaOutputs := sha256.Sum256(bip322ToSignOutputs())
+
+	var msg []byte
+	msg = append(msg, 0x00)     // epoch
+	msg = append(msg, hashType) // hash_type
+	msg = append(msg, 0, 0, 0, 0)
+	msg = append(msg, 0, 0, 0, 0)
+	msg = append(msg, shaPrevouts[:]...)
+	msg = append(msg, shaAmounts[:]...)
+	msg = append(msg, shaScriptPubKeys[:]...)
+	msg = append(msg, shaSequences[:]...)
+	msg = append(msg, shaOutputs[:]...)
+	msg = append(msg, 0x00)       // spend_type: key path, no annex
+	msg = append(msg, 0, 0, 0, 0) // input_index
+
+	sighash := TaggedHash(tapSighashTag, msg)
+	return SchnorrVerify(sig, sighash[:], xonly), nil
+}
+
+// bip322ToSpendTxid computes the txid of the virtual to_spend transaction
+// committing to the message hash and the address's scriptPubKey
+func bip322ToSpendTxid(scriptPubKey []byte, msgHash [32]byte) [32]byte {
+	var tx []byte
+	tx = append(tx, 0, 0, 0, 0) // nVersion
+	tx = append(tx, 0x01)       // one input
+	tx = append(tx, make([]byte, 32)...)
+	tx = append(tx, 0xff, 0xff, 0xff, 0xff)
+	tx = append(tx, 0x22, 0x00, 0x20) // scriptSig: OP_0 PUSH32[msgHash]
+	tx = append(tx, msgHash[:]...)
+	tx = append(tx, 0, 0, 0, 0)         // nSequence
+	tx = append(tx, 0x01)               // one output
+	tx = append(tx, make([]byte, 8)...) // value
+	tx = append(tx, byte(len(scriptPubKey)))
+	tx = append(tx, scriptPubKey...)
+	tx = append(tx, 0, 0, 0, 0) // nLockTime
+
+	return doubleSHA256(tx)
+}
+
+// bip322ToSignOutputs returns the serialized single OP_RETURN output of the
+// virtual to_sign transaction
+func bip322ToSignOutputs() []byte {
+	out := make([]byte, 8, 10)
+	return append(out, 0x01, 0x6a)
+}
+
+// parseWitnessStack parses a serialized witness stack: a compact-size item
+// count followed by compact-size length-prefixed items
+func parseWitnessStack(b []byte) ([][]byte, error) {
+	count, b, err := readCompactSize(b)
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(len(b)) {
+		return nil, errors.New("invalid witness item count")
+	}
+
+	items := make([][]byte, 0, count)
+	for i := uint64(0); i < count; i++ {
+		var n uint64
+		n, b, err = readCompactSize(b)
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(b)) {
+			return nil, errors.New("witness item exceeds input")
+		}
+		items = append(items, b[:n])
+		b = b[n:]
+	}
+	if len(b) != 0 {
+		return nil, errors.New("trailing data after witness stack")
+	}
+
+	return items, nil
+}
+
+// readCompactSize reads a Bitcoin compact-size integer
+func readCompactSize(b []byte) (uint64, []byte, error) {
+	if len(b) == 0 {
+		return 0, nil, errors.New("unexpected end of input")
+	}
+
+	switch b[0] {
+	case 0xfd:
+		if len(b) < 3 {
+			return 0, nil, errors.New("unexpected end of input")
+		}
+		return uint64(binary.LittleEndian.Uint16(b[1:])), b[3:], nil
+	case 0xfe:
+		if len(b) < 5 {
+			return 0, nil, errors.New("unexpected end of input")
+		}
+		return uint64(binary.LittleEndian.Uint32(b[1:])), b[5:], nil
+	case 0xff:
+		if len(b) < 9 {
+			return 0, nil, errors.New("unexpected end of input")
+		}
+		return binary.LittleEndian.Uint64(b[1:]), b[9:], nil
+	default:
+		return uint64(b[0]), b[1:], nil
+	}
+}
+
+// Bech32 character set and checksum constants (BIP-173, BIP-350)
+const (
+	bech32Charset     = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	bech32Const       = 1
+	bech32mConst      = 0x2bc830a3
+	bech32MaxLength   = 90
+	bech32ChecksumLen = 6
+)
+
+// bech32Polymod computes the bech32 checksum polynomial
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 != 0 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+// bech32Decode decodes a bech32 or bech32m string into its human-readable
+// part, 5-bit data (without checksum) and checksum constant
+func bech32Decode(s string) (string, []byte, uint32, error) {
+	if len(s) > bech32MaxLength {
+		return "", nil, 0, errors.New("bech32 string too long")
+	}
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, 0, errors.New("bech32 string has mixed case")
+	}
+	s = strings.ToLower(s)
+
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || sep+bech32ChecksumLen+1 > len(s) {
+		return "", nil, 0, errors.New("invalid bech32 separator position")
+	}
+	hrp := s[:sep]
+
+	values := make([]byte, 0, 2*len(hrp)+1+len(s)-sep-1)
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, 0, errors.New("invalid bech32 human-readable part")
+		}
+		values = append(values, hrp[i]>>5)
+	}
+	values = append(values, 0)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]&31)
+	}
+
+	dataStart := len(values)
+	for i := sep + 1; i < len(s); i++ {
+		d := strings.IndexByte(bech32Charset, s[i])
+		if d < 0 {
+			return "", nil, 0, errors.New("invalid bech32 character")
+		}
+		values = append(values, byte(d))
+	}
+
+	return hrp, values[dataStart : len(values)-bech32ChecksumLen], bech32Polymod(values), nil
+}
+
+// convertBits regroups 5-bit values into bytes, rejecting non-zero padding
+func convertBits(data []byte) ([]byte, error) {
+	var acc uint32
+	var nbits uint
+	out := make([]byte, 0, len(data)*5/8)
+	for _, v := range data {
+		acc = acc<<5 | uint32(v)
+		nbits += 5
+		if nbits >= 8 {
+			nbits -= 8
+			out = append(out, byte(acc>>nbits))
+		}
+	}
+	if nbits >= 5 || acc&((1<<nbits)-1) != 0 {
+		return nil, errors.New("invalid bech32 padding")
+	}
+	return out, nil
+}
+
+// bitcoinAddressHRP returns the human-readable part of a segwit address,
+// which must be that of mainnet, testnet or regtest
+func bitcoinAddressHRP(addr string) (string, error) {
+	sep := strings.LastIndexByte(addr, '1')
+	if sep < 1 {
+		return "", errors.New("invalid bech32 separator position")
+	}
+	switch hrp := strings.ToLower(addr[:sep]); hrp {
+	case Bech32Mainnet, Bech32Testnet, Bech32Regtest:
+		return hrp, nil
+	default:
+		return "", errors.New("segwit address is not for a Bitcoin network")
+	}
+}
+
+// decodeSegwitAddress decodes a segwit address into its witness version and
+// program, enforcing bech32 for version 0 and bech32m for later versions.
+// The address's human-readable part must be hrp.
+func decodeSegwitAddress(addr string, hrp string) (int, []byte, error) {
+	addrHRP, data, checksum, err := bech32Decode(addr)
+	if err != nil {
+		return 0, nil, err
+	}
+	if addrHRP != strings.ToLower(hrp) {
+		return 0, nil, errors.New("segwit address is for another network")
+	}
+	if len(data) < 1 {
+		return 0, nil, errors.New("empty segwit address data")
+	}
+
+	version := int(data[0])
+	if version > 16 {
+		return 0, nil, errors.New("invalid witness version")
+	}
+	if (version == 0 && checksum != bech32Const) || (version != 0 && checksum != bech32mConst) {
+		return 0, nil, errors.New("invalid bech32 checksum")
+	}
+
+	program, err := convertBits(data[1:])
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(program) < 2 || len(program) > 40 {
+		return 0, nil, errors.New("invalid witness program length")
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return 0, nil, errors.New("invalid witness v0 program length")
+	}
+
+	return version, program, nil
+}
@@ -0,0 +1,169 @@
+package p256k1
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+)
+
+func TestBIP322MessageHash(t *testing.T) {
+	vectors := []struct {
+		message, hash string
+	}{
+		{"", "c90c269c4f8fcbe6880f72a721ddfbf1914268a794cbb21cfafee13770ae19f1"},
+		{"Hello World", "f0eb03b1a75ac6d9847f55c624a99169b5dccba2a31f5b23bea77ba270de0a7a"},
+	}
+
+	for _, v := range vectors {
+		hash := BIP322MessageHash(v.message)
+		if hex.EncodeToString(hash[:]) != v.hash {
+			t.Errorf("message hash of %q = %x, want %s", v.message, hash, v.hash)
+		}
+	}
+}
+
+func TestBIP322Verify(t *testing.T) {
+	// Reference vectors from BIP-322
+	const p2wpkh = "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l"
+	const p2tr = "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3"
+
+	vectors := []struct {
+		address, message, signature string
+	}{
+		{p2wpkh, "", "AkcwRAIgM2gBAQqvZX15ZiysmKmQpDrG83avLIT492QBzLnQIxYCIBaTpOaD20qRlEylyxFSeEA2ba9YOixpX8z46TSDtS40ASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI="},
+		{p2wpkh, "Hello World", "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI="},
+		{p2wpkh, "Hello World", "AkgwRQIhAOzyynlqt93lOKJr+wmmxIens//zPzl9tqIOua93wO6MAiBi5n5EyAcPScOjf1lAqIUIQtr3zKNeavYabHyR8eGhowEhAsfxIAMZZEKUPYWI4BruhAQjzFT8FSFSajuFwrDL1Yhy"},
+		{p2tr, "Hello World", "AUHd69PrJQEv+oKTfZ8l+WROBHuy9HKrbFCJu7U1iK2iiEy1vMU5EfMtjc+VSHM7aU0SDbak5IUZRVno2P5mjSafAQ=="},
+	}
+
+	for i, v := range vectors {
+		sig, err := base64.StdEncoding.DecodeString(v.signature)
+		if err != nil {
+			t.Fatalf("vector %d: failed to decode signature: %v", i, err)
+		}
+
+		valid, err := BIP322Verify(v.address, v.message, sig)
+		if err != nil {
+			t.Fatalf("vector %d: verification error: %v", i, err)
+		}
+		if !valid {
+			t.Errorf("vector %d: signature should be valid", i)
+		}
+
+		// The same signature must not verify a different message
+		valid, err = BIP322Verify(v.address, v.message+"!", sig)
+		if err != nil {
+			t.Fatalf("vector %d: verification error: %v", i, err)
+		}
+		if valid {
+			t.Errorf("vector %d: signature should be invalid for a different message", i)
+		}
+	}
+
+	// Signatures are bound to the address type
+	sig, _ := base64.StdEncoding.DecodeString(vectors[1].signature)
+	if _, err := BIP322Verify(p2tr, "Hello World", sig); err == nil {
+		t.Error("expected error for P2WPKH witness against a P2TR address")
+	}
+
+	// Malformed inputs are errors
+	if _, err := BIP322Verify("bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0m", "", sig); err == nil {
+		t.Error("expected error for bad address checksum")
+	}
+	if _, err := BIP322Verify(p2wpkh, "", sig[:len(sig)-1]); err == nil {
+		t.Error("expected error for truncated witness")
+	}
+
+	// Re-encoding r as a 33-byte integer with a non-zero leading byte is
+	// not a second valid encoding of the same signature
+	sig, _ = base64.StdEncoding.DecodeString(vectors[0].signature)
+	witness, err := parseWitnessStack(sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, pub := witness[0], witness[1]
+	if der[0] != 0x30 || der[3] != 0x20 {
+		t.Fatalf("unexpected DER layout %x", der)
+	}
+	malleated := append([]byte{0x30, der[1] + 1, 0x02, 0x21, 0x01}, der[4:]...)
+	stack := append([]byte{0x02, byte(len(malleated))}, malleated...)
+	stack = append(append(stack, byte(len(pub))), pub...)
+	if valid, err := BIP322Verify(p2wpkh, "", stack); err == nil || valid {
+		t.Errorf("33-byte r with a non-zero leading byte accepted: valid=%v err=%v", valid, err)
+	}
+
+	// The network comes from the address; the signature commits only to
+	// the witness program, so it is valid on every network
+	sig, _ = base64.StdEncoding.DecodeString(vectors[0].signature)
+	networks := []struct{ hrp, address string }{
+		{Bech32Mainnet, p2wpkh},
+		{Bech32Testnet, "tb1q9vza2e8x573nczrlzms0wvx3gsqjx7vaxwd45v"},
+		{Bech32Regtest, "bcrt1q9vza2e8x573nczrlzms0wvx3gsqjx7vay85cr9"},
+	}
+	for _, n := range networks {
+		if valid, err := BIP322Verify(n.address, "", sig); err != nil || !valid {
+			t.Errorf("%s: valid=%v err=%v", n.address, valid, err)
+		}
+		for _, m := range networks {
+			valid, err := BIP322VerifyNetwork(n.address, "", sig, m.hrp)
+			if m.hrp == n.hrp && (err != nil || !valid) {
+				t.Errorf("%s on %s: valid=%v err=%v", n.address, m.hrp, valid, err)
+			}
+			if m.hrp != n.hrp && err == nil {
+				t.Errorf("expected error for %s on %s", n.address, m.hrp)
+			}
+		}
+	}
+	if _, err := BIP322Verify("ltc1q9vza2e8x573nczrlzms0wvx3gsqjx7vag5vzh0", "", sig); err == nil {
+		t.Error("expected error for an address on a non-Bitcoin network")
+	}
+}
+
+func TestDecodeSegwitAddress(t *testing.T) {
+	// BIP-173 and BIP-350 valid addresses
+	vectors := []struct {
+		address, hrp string
+		version      int
+		program      string
+	}{
+		{"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", Bech32Mainnet, 0, "751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", Bech32Testnet, 0, "1863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262"},
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", Bech32Mainnet, 1, "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"},
+	}
+
+	for _, v := range vectors {
+		version, program, err := decodeSegwitAddress(v.address, v.hrp)
+		if err != nil {
+			t.Fatalf("%s: failed to decode: %v", v.address, err)
+		}
+		if version != v.version || hex.EncodeToString(program) != v.program {
+			t.Errorf("%s: got version %d program %x", v.address, version, program)
+		}
+	}
+
+	invalid := []string{
+		// v1 address with a bech32 (not bech32m) checksum
+		"bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7k7grplx",
+		// v0 address with a bech32m checksum
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh",
+		// mixed case
+		"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sL5k7",
+	}
+	for _, addr := range invalid {
+		if _, _, err := decodeSegwitAddress(addr, addr[:2]); err == nil {
+			t.Errorf("%s: expected decode error", addr)
+		}
+	}
+
+	// Valid addresses are rejected for any other network
+	for _, v := range vectors {
+		for _, hrp := range []string{Bech32Mainnet, Bech32Testnet, Bech32Regtest} {
+			if hrp == v.hrp {
+				continue
+			}
+			if _, _, err := decodeSegwitAddress(v.address, hrp); err == nil {
+				t.Errorf("%s: expected error for network %s", v.address, hrp)
+			}
+		}
+	}
+}
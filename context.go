@@ -3,6 +3,8 @@ package p256k1
 import (
 	"crypto/rand"
 	"errors"
+	"fmt"
+	"unsafe"
 )
 
 // Context flags
@@ -10,16 +12,31 @@ const (
 	ContextSign   = 1 << 0
 	ContextVerify = 1 << 1
 	ContextNone   = 0
+
+	// ContextSignVerify makes signing with the context verify each signature
+	// before returning it, so a fault during signing (e.g. a glitch or bit
+	// flip) yields an error instead of a bad signature that may leak the key
+	ContextSignVerify = 1 << 2
+
+	// ContextDeclassify makes signing with the context declassify values
+	// that are safe to reveal, for constant-time auditing under valgrind
+	// with the p256k1_ctime build tag; see ctime.go. It has no effect in
+	// other builds.
+	ContextDeclassify = 1 << 3
 )
 
 // Context represents a secp256k1 context
 type Context struct {
 	flags       uint
 	ecmultGenCtx *EcmultGenContext
+	// Generator multiplication blinding: k*G is computed as
+	// (k + blind)*G + blindPoint where blindPoint = -blind*G
+	blindSeed  [32]byte
+	blind      Scalar
+	blindPoint GroupElementJacobian
 	// In a real implementation, this would also contain:
 	// - ecmult context for verification
 	// - callback functions
-	// - randomization state
 }
 
 // CallbackFunction represents an error callback
@@ -40,6 +57,8 @@ func ContextCreate(flags uint) *Context {
 	ctx := &Context{
 		flags: flags,
 	}
+	// Blinding starts disabled; the zero-valued point is not infinity
+	ctx.blindPoint.setInfinity()
 	
 	// Initialize generator context if needed for signing
 	if flags&ContextSign != 0 {
@@ -69,12 +88,13 @@ func ContextDestroy(ctx *Context) {
 	// Zero out the context
 	ctx.flags = 0
 	ctx.ecmultGenCtx = nil
+	ctx.SetBlindingSeed([32]byte{})
 }
 
 // ContextRandomize randomizes the context to provide protection against side-channel attacks
 func ContextRandomize(ctx *Context, seed32 []byte) error {
 	if ctx == nil {
-		return errors.New("context cannot be nil")
+		return fmt.Errorf("%w: context cannot be nil", ErrContextNotBuilt)
 	}
 	
 	var seedBytes [32]byte
@@ -91,15 +111,50 @@ func ContextRandomize(ctx *Context, seed32 []byte) error {
 		}
 	}
 	
-	// In a real implementation, this would:
-	// 1. Randomize the precomputed tables
-	// 2. Add blinding to prevent side-channel attacks
-	// 3. Update the context state
-	
-	// For now, we just validate the input
+	ctx.SetBlindingSeed(seedBytes)
+	memclear(unsafe.Pointer(&seedBytes[0]), 32)
 	return nil
 }
 
+// BlindingSeed returns the seed the context's generator blinding was derived
+// from. An all-zero seed means blinding is disabled.
+func (ctx *Context) BlindingSeed() [32]byte {
+	return ctx.blindSeed
+}
+
+// SetBlindingSeed pins the generator blinding to the value derived from seed,
+// so tests can reproduce a blinded context exactly. An all-zero seed disables
+// blinding. The blinding never affects results, only how they are computed.
+func (ctx *Context) SetBlindingSeed(seed [32]byte) {
+	ctx.blindSeed = seed
+	ctx.blind.clear()
+	ctx.blindPoint.setInfinity()
+	if seed == ([32]byte{}) {
+		return
+	}
+
+	var blindBytes [32]byte
+	rng := NewRFC6979HMACSHA256(seed[:])
+	rng.Generate(blindBytes[:])
+	rng.Finalize()
+	rng.Clear()
+	ctx.blind.setB32(blindBytes[:])
+	memclear(unsafe.Pointer(&blindBytes[0]), 32)
+
+	// blindPoint = -blind*G
+	EcmultGen(&ctx.blindPoint, &ctx.blind)
+	ctx.blindPoint.negate(&ctx.blindPoint)
+}
+
+// ecmultGen computes r = n*G using the context's table and blinding
+func (ctx *Context) ecmultGen(r *GroupElementJacobian, n *Scalar) {
+	var k Scalar
+	k.add(n, &ctx.blind)
+	ctx.ecmultGenCtx.ecmultGen(r, &k)
+	r.addVar(r, &ctx.blindPoint)
+	k.clear()
+}
+
 // Global static context (read-only, for verification only)
 var ContextStatic = &Context{
 	flags:        ContextVerify,
@@ -131,6 +186,27 @@ func (ctx *Context) canSign() bool {
 	return ctx != nil && (ctx.flags&ContextSign) != 0 && ctx.ecmultGenCtx != nil
 }
 
+// signVerify returns true if signatures made with the context must be
+// verified before they are returned
+func (ctx *Context) signVerify() bool {
+	return ctx != nil && (ctx.flags&ContextSignVerify) != 0
+}
+
+// declassify marks n bytes at p as public if the context was created with
+// ContextDeclassify
+func (ctx *Context) declassify(p unsafe.Pointer, n uintptr) {
+	if ctx != nil && (ctx.flags&ContextDeclassify) != 0 {
+		ctimeDeclassify(p, n)
+	}
+}
+
+// ctxECDSASign and ctxSchnorrSign are the signers behind the WithContext
+// functions. Tests swap them for faulty ones to exercise ContextSignVerify.
+var (
+	ctxECDSASign   = ecdsaSign
+	ctxSchnorrSign = schnorrSign
+)
+
 // canVerify returns true if the context can be used for verification
 func (ctx *Context) canVerify() bool {
 	return ctx != nil && (ctx.flags&ContextVerify) != 0
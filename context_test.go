@@ -2,6 +2,7 @@ package p256k1
 
 import (
 	"crypto/rand"
+	"errors"
 	"testing"
 )
 
@@ -96,6 +97,218 @@ func TestContextRandomize(t *testing.T) {
 	}
 }
 
+func TestContextBlindingSeed(t *testing.T) {
+	ctx1 := ContextCreate(ContextSign)
+	defer ContextDestroy(ctx1)
+	ctx2 := ContextCreate(ContextSign)
+	defer ContextDestroy(ctx2)
+
+	if ctx1.BlindingSeed() != ([32]byte{}) {
+		t.Error("new context should have blinding disabled")
+	}
+
+	var seed1, seed2 [32]byte
+	seed1[0] = 1
+	seed2[0] = 2
+	ctx1.SetBlindingSeed(seed1)
+	if err := ContextRandomize(ctx2, seed2[:]); err != nil {
+		t.Fatalf("ContextRandomize failed: %v", err)
+	}
+	if ctx1.BlindingSeed() != seed1 || ctx2.BlindingSeed() != seed2 {
+		t.Fatal("blinding seed not round-tripped")
+	}
+	if ctx1.blind.equal(&ctx2.blind) {
+		t.Fatal("different seeds should give different blinding")
+	}
+
+	seckey := make([]byte, 32)
+	msg := make([]byte, 32)
+	for i := 0; i < 16; i++ {
+		if _, err := rand.Read(seckey); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := rand.Read(msg); err != nil {
+			t.Fatal(err)
+		}
+		if !ECSeckeyVerify(seckey) {
+			continue
+		}
+
+		// The RFC6979 signature must not depend on the blinding value
+		var want, sig1, sig2 ECDSASignature
+		if err := ECDSASign(&want, msg, seckey); err != nil {
+			t.Fatalf("ECDSASign failed: %v", err)
+		}
+		if err := ECDSASignWithContext(ctx1, &sig1, msg, seckey); err != nil {
+			t.Fatalf("ECDSASignWithContext failed: %v", err)
+		}
+		if err := ECDSASignWithContext(ctx2, &sig2, msg, seckey); err != nil {
+			t.Fatalf("ECDSASignWithContext failed: %v", err)
+		}
+		if !sig1.r.equal(&want.r) || !sig1.s.equal(&want.s) ||
+			!sig2.r.equal(&want.r) || !sig2.s.equal(&want.s) {
+			t.Fatal("blinded signatures differ from unblinded signature")
+		}
+	}
+
+	// Pinning the same seed again reproduces the same blinding
+	ctx2.SetBlindingSeed(seed1)
+	if !ctx1.blind.equal(&ctx2.blind) {
+		t.Error("same seed should give the same blinding")
+	}
+
+	// Signing requires a signing context
+	verifyCtx := ContextCreate(ContextVerify)
+	defer ContextDestroy(verifyCtx)
+	var sig ECDSASignature
+	if err := ECDSASignWithContext(verifyCtx, &sig, msg, seckey); err == nil {
+		t.Error("expected error signing with a verify-only context")
+	}
+}
+
+func TestContextUnblindedSign(t *testing.T) {
+	// A fresh context has blinding disabled and must sign exactly like the
+	// context-free functions
+	ctx := ContextCreate(ContextSign)
+	defer ContextDestroy(ctx)
+	if !ctx.blindPoint.isInfinity() {
+		t.Fatal("fresh context should have an infinite blinding point")
+	}
+
+	seckey := make([]byte, 32)
+	msg := make([]byte, 32)
+	for i := range seckey {
+		seckey[i] = byte(i + 1)
+		msg[i] = byte(0xa5 ^ i)
+	}
+
+	var want, sig ECDSASignature
+	if err := ECDSASign(&want, msg, seckey); err != nil {
+		t.Fatalf("ECDSASign failed: %v", err)
+	}
+	if err := ECDSASignWithContext(ctx, &sig, msg, seckey); err != nil {
+		t.Fatalf("ECDSASignWithContext failed: %v", err)
+	}
+	if !sig.r.equal(&want.r) || !sig.s.equal(&want.s) {
+		t.Error("ECDSA signature differs from ECDSASign")
+	}
+	var pubkey PublicKey
+	if err := ECPubkeyCreate(&pubkey, seckey); err != nil {
+		t.Fatal(err)
+	}
+	if !ECDSAVerify(&sig, msg, &pubkey) {
+		t.Error("ECDSA signature from an unrandomized context does not verify")
+	}
+
+	keypair, err := KeyPairCreate(seckey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xonly, err := keypair.XOnlyPubkey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want64, sig64 [64]byte
+	if err := SchnorrSign(want64[:], msg, keypair, nil); err != nil {
+		t.Fatalf("SchnorrSign failed: %v", err)
+	}
+	if err := SchnorrSignWithContext(ctx, sig64[:], msg, keypair, nil); err != nil {
+		t.Fatalf("SchnorrSignWithContext failed: %v", err)
+	}
+	if sig64 != want64 {
+		t.Error("Schnorr signature differs from SchnorrSign")
+	}
+	if !SchnorrVerify(sig64[:], msg, xonly) {
+		t.Error("Schnorr signature from an unrandomized context does not verify")
+	}
+}
+
+func TestContextSignVerify(t *testing.T) {
+	ctx := ContextCreate(ContextSign | ContextSignVerify)
+	defer ContextDestroy(ctx)
+	if err := ContextRandomize(ctx, nil); err != nil {
+		t.Fatalf("ContextRandomize failed: %v", err)
+	}
+
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	defer kp.Clear()
+	msg := make([]byte, 32)
+	if _, err := rand.Read(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a fault the self-check passes and signatures are unchanged
+	var sig, want ECDSASignature
+	if err := ECDSASignWithContext(ctx, &sig, msg, kp.Seckey()); err != nil {
+		t.Fatalf("ECDSASignWithContext failed: %v", err)
+	}
+	if err := ECDSASign(&want, msg, kp.Seckey()); err != nil {
+		t.Fatalf("ECDSASign failed: %v", err)
+	}
+	if !sig.r.equal(&want.r) || !sig.s.equal(&want.s) {
+		t.Error("self-checked ECDSA signature differs from ECDSASign")
+	}
+
+	var sig64, want64 [64]byte
+	if err := SchnorrSignWithContext(ctx, sig64[:], msg, kp, nil); err != nil {
+		t.Fatalf("SchnorrSignWithContext failed: %v", err)
+	}
+	if err := SchnorrSign(want64[:], msg, kp, nil); err != nil {
+		t.Fatalf("SchnorrSign failed: %v", err)
+	}
+	if sig64 != want64 {
+		t.Error("self-checked Schnorr signature differs from SchnorrSign")
+	}
+
+	// A corrupted signature is caught and not returned
+	ctxECDSASign = func(ctx *Context, sig *ECDSASignature, msghash32 []byte, seckey []byte, ndata []byte, recid *int) error {
+		if err := ecdsaSign(ctx, sig, msghash32, seckey, ndata, recid); err != nil {
+			return err
+		}
+		var one Scalar
+		one.setInt(1)
+		sig.s.add(&sig.s, &one)
+		return nil
+	}
+	ctxSchnorrSign = func(ctx *Context, sig64 []byte, msg32 []byte, keypair *KeyPair, auxRand32 []byte) error {
+		if err := schnorrSign(ctx, sig64, msg32, keypair, auxRand32); err != nil {
+			return err
+		}
+		sig64[40] ^= 0x01
+		return nil
+	}
+	defer func() {
+		ctxECDSASign = ecdsaSign
+		ctxSchnorrSign = schnorrSign
+	}()
+
+	if err := ECDSASignWithContext(ctx, &sig, msg, kp.Seckey()); !errors.Is(err, ErrSignatureSelfCheck) {
+		t.Errorf("expected ErrSignatureSelfCheck from ECDSA, got %v", err)
+	}
+	if !sig.r.isZero() || !sig.s.isZero() {
+		t.Error("failed ECDSA signature should be cleared")
+	}
+	if err := SchnorrSignWithContext(ctx, sig64[:], msg, kp, nil); !errors.Is(err, ErrSignatureSelfCheck) {
+		t.Errorf("expected ErrSignatureSelfCheck from Schnorr, got %v", err)
+	}
+	if sig64 != ([64]byte{}) {
+		t.Error("failed Schnorr signature should be cleared")
+	}
+
+	// Without the flag the faulty signature is returned unchecked
+	plain := ContextCreate(ContextSign)
+	defer ContextDestroy(plain)
+	if err := ECDSASignWithContext(plain, &sig, msg, kp.Seckey()); err != nil {
+		t.Errorf("signing without ContextSignVerify should not self-check: %v", err)
+	}
+	if err := SchnorrSignWithContext(plain, sig64[:], msg, kp, nil); err != nil {
+		t.Errorf("signing without ContextSignVerify should not self-check: %v", err)
+	}
+}
+
 func TestContextStatic(t *testing.T) {
 	// Test that static context exists and has correct properties
 	if ContextStatic == nil {
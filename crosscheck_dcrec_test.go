@@ -0,0 +1,136 @@
+//go:build p256k1_dcrec
+
+// Cross-checks against github.com/decred/dcrd/dcrec/secp256k1, which is only
+// imported under the p256k1_dcrec build tag so the package itself does not
+// depend on it. To run:
+//
+//	go get github.com/decred/dcrd/dcrec/secp256k1/v4
+//	go test -tags p256k1_dcrec -run CrossCheck .
+//
+// dcrec's schnorr package implements EC-Schnorr-DCRv0 rather than BIP-340,
+// so Schnorr signatures are not compared here.
+
+package p256k1
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	dcrecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// crossCheckIterations returns how many random inputs to try
+func crossCheckIterations() int {
+	if testing.Short() {
+		return 200
+	}
+	return 5000
+}
+
+// crossCheckKey returns a random valid secret key and its dcrec counterpart
+func crossCheckKey(t *testing.T) ([]byte, *secp256k1.PrivateKey) {
+	seckey := make([]byte, 32)
+	for {
+		if _, err := rand.Read(seckey); err != nil {
+			t.Fatal(err)
+		}
+		if ECSeckeyVerify(seckey) {
+			return seckey, secp256k1.PrivKeyFromBytes(seckey)
+		}
+	}
+}
+
+func TestCrossCheckDcrecPubkey(t *testing.T) {
+	for i := 0; i < crossCheckIterations(); i++ {
+		seckey, priv := crossCheckKey(t)
+
+		var pubkey PublicKey
+		if err := ECPubkeyCreate(&pubkey, seckey); err != nil {
+			t.Fatalf("ECPubkeyCreate failed: %v", err)
+		}
+
+		var compressed [33]byte
+		var uncompressed [65]byte
+		ECPubkeySerialize(compressed[:], &pubkey, ECCompressed)
+		ECPubkeySerialize(uncompressed[:], &pubkey, ECUncompressed)
+		if !bytes.Equal(compressed[:], priv.PubKey().SerializeCompressed()) {
+			t.Fatalf("compressed pubkey mismatch for seckey %x", seckey)
+		}
+		if !bytes.Equal(uncompressed[:], priv.PubKey().SerializeUncompressed()) {
+			t.Fatalf("uncompressed pubkey mismatch for seckey %x", seckey)
+		}
+	}
+}
+
+func TestCrossCheckDcrecECDSA(t *testing.T) {
+	msg := make([]byte, 32)
+	for i := 0; i < crossCheckIterations(); i++ {
+		seckey, priv := crossCheckKey(t)
+		if _, err := rand.Read(msg); err != nil {
+			t.Fatal(err)
+		}
+
+		var pubkey PublicKey
+		if err := ECPubkeyCreate(&pubkey, seckey); err != nil {
+			t.Fatalf("ECPubkeyCreate failed: %v", err)
+		}
+
+		// Both use RFC6979 nonces and low-S, so signatures must be identical
+		var sig ECDSASignature
+		if err := ECDSASign(&sig, msg, seckey); err != nil {
+			t.Fatalf("ECDSASign failed: %v", err)
+		}
+		var der [72]byte
+		n := ECDSASignatureSerializeDER(der[:], &sig)
+		theirs := dcrecdsa.Sign(priv, msg)
+		if !bytes.Equal(der[:n], theirs.Serialize()) {
+			t.Fatalf("signature mismatch for seckey %x msg %x:\n ours   %x\n theirs %x", seckey, msg, der[:n], theirs.Serialize())
+		}
+
+		// Each side verifies the other's signature
+		parsed, err := dcrecdsa.ParseDERSignature(der[:n])
+		if err != nil {
+			t.Fatalf("dcrec failed to parse our signature: %v", err)
+		}
+		if !parsed.Verify(msg, priv.PubKey()) {
+			t.Fatalf("dcrec rejected our signature for seckey %x msg %x", seckey, msg)
+		}
+		var ours ECDSASignature
+		if err := ECDSASignatureParseDER(&ours, theirs.Serialize()); err != nil {
+			t.Fatalf("failed to parse dcrec signature: %v", err)
+		}
+		if !ECDSAVerify(&ours, msg, &pubkey) {
+			t.Fatalf("rejected dcrec signature for seckey %x msg %x", seckey, msg)
+		}
+
+		// And both reject it for a different message
+		msg[0] ^= 1
+		if ECDSAVerify(&ours, msg, &pubkey) != parsed.Verify(msg, priv.PubKey()) {
+			t.Fatalf("verification disagrees for a modified message, seckey %x msg %x", seckey, msg)
+		}
+	}
+}
+
+func TestCrossCheckDcrecECDH(t *testing.T) {
+	for i := 0; i < crossCheckIterations(); i++ {
+		seckey, _ := crossCheckKey(t)
+		otherSeckey, otherPriv := crossCheckKey(t)
+
+		var otherPubkey PublicKey
+		if err := ECPubkeyCreate(&otherPubkey, otherSeckey); err != nil {
+			t.Fatalf("ECPubkeyCreate failed: %v", err)
+		}
+
+		// dcrec's shared secret is the bare x-coordinate
+		var ours [32]byte
+		if err := ECDHXOnly(ours[:], &otherPubkey, seckey); err != nil {
+			t.Fatalf("ECDHXOnly failed: %v", err)
+		}
+		theirs := secp256k1.GenerateSharedSecret(secp256k1.PrivKeyFromBytes(seckey), otherPriv.PubKey())
+		if !bytes.Equal(ours[:], theirs) {
+			t.Fatalf("shared secret mismatch for seckeys %x, %x", seckey, otherSeckey)
+		}
+	}
+}
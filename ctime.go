@@ -0,0 +1,48 @@
+//go:build p256k1_ctime && amd64
+
+package p256k1
+
+import "unsafe"
+
+// Constant-time auditing hooks, compiled in only with the p256k1_ctime build
+// tag. As in libsecp256k1's ctime tests, secret inputs are marked as
+// undefined memory for valgrind's memcheck, so any branch or memory index
+// that depends on them is reported as a use of an uninitialised value.
+// Values that are safe to reveal are declassified (marked defined) first.
+//
+//	go test -c -tags p256k1_ctime -o p256k1.test
+//	valgrind ./p256k1.test -test.run CTime
+//
+// Outside valgrind the client requests are no-ops.
+
+// Memcheck client request codes, from valgrind/memcheck.h
+const (
+	vgUserreqRunningOnValgrind = 0x1001
+	vgUserreqMakeMemUndefined  = 0x4d430001
+	vgUserreqMakeMemDefined    = 0x4d430002
+)
+
+// valgrindRequest issues a valgrind client request and returns its result,
+// or 0 when not running under valgrind
+//
+//go:noescape
+func valgrindRequest(args *[6]uint64) uint64
+
+// ctimeClassify marks n bytes at p as secret
+func ctimeClassify(p unsafe.Pointer, n uintptr) {
+	args := [6]uint64{vgUserreqMakeMemUndefined, uint64(uintptr(p)), uint64(n)}
+	valgrindRequest(&args)
+}
+
+// ctimeDeclassify marks n bytes at p as public
+func ctimeDeclassify(p unsafe.Pointer, n uintptr) {
+	args := [6]uint64{vgUserreqMakeMemDefined, uint64(uintptr(p)), uint64(n)}
+	valgrindRequest(&args)
+}
+
+// ctimeRunningOnValgrind reports whether the process is running under
+// valgrind, so tests can tell whether the hooks have any effect
+func ctimeRunningOnValgrind() bool {
+	args := [6]uint64{vgUserreqRunningOnValgrind}
+	return valgrindRequest(&args) != 0
+}
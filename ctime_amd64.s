@@ -0,0 +1,20 @@
+//go:build p256k1_ctime
+
+#include "textflag.h"
+
+// func valgrindRequest(args *[6]uint64) uint64
+//
+// The valgrind client request preamble for amd64: the rotations of DI sum to
+// 128 bits and so leave it unchanged, and valgrind recognises the sequence
+// followed by XCHGQ BX, BX. AX points at the arguments and DX holds the
+// default result, which valgrind replaces.
+TEXT ·valgrindRequest(SB), NOSPLIT, $0-16
+	MOVQ args+0(FP), AX
+	XORQ DX, DX
+	ROLQ $3, DI
+	ROLQ $13, DI
+	ROLQ $61, DI
+	ROLQ $51, DI
+	XCHGQ BX, BX
+	MOVQ DX, ret+8(FP)
+	RET
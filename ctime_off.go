@@ -0,0 +1,11 @@
+//go:build !p256k1_ctime || !amd64
+
+package p256k1
+
+import "unsafe"
+
+// No-op constant-time auditing hooks; see ctime.go
+
+func ctimeClassify(p unsafe.Pointer, n uintptr)   {}
+func ctimeDeclassify(p unsafe.Pointer, n uintptr) {}
+func ctimeRunningOnValgrind() bool                { return false }
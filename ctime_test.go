@@ -0,0 +1,66 @@
+//go:build p256k1_ctime
+
+package p256k1
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// TestCTimeSign signs with a classified secret key and declassifies the
+// outputs before checking them. Under valgrind, memcheck reports any branch
+// or lookup on secret data in between; without valgrind it just checks that
+// the hooks are harmless.
+func TestCTimeSign(t *testing.T) {
+	if ctimeRunningOnValgrind() {
+		t.Log("running under valgrind")
+	}
+
+	ctx := ContextCreate(ContextSign | ContextDeclassify)
+	defer ContextDestroy(ctx)
+
+	seckey := make([]byte, 32)
+	msg := make([]byte, 32)
+	for i := range seckey {
+		seckey[i] = byte(i + 1)
+		msg[i] = byte(0xa5 ^ i)
+	}
+	var pubkey PublicKey
+	if err := ECPubkeyCreate(&pubkey, seckey); err != nil {
+		t.Fatal(err)
+	}
+	keypair, err := KeyPairCreate(seckey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xonly, err := keypair.XOnlyPubkey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctimeClassify(unsafe.Pointer(&seckey[0]), 32)
+	var sig ECDSASignature
+	err = ECDSASignWithContext(ctx, &sig, msg, seckey)
+	ctimeDeclassify(unsafe.Pointer(&err), unsafe.Sizeof(err))
+	ctimeDeclassify(unsafe.Pointer(&sig), unsafe.Sizeof(sig))
+	ctimeDeclassify(unsafe.Pointer(&seckey[0]), 32)
+	if err != nil {
+		t.Fatalf("ECDSASignWithContext failed: %v", err)
+	}
+	if !ECDSAVerify(&sig, msg, &pubkey) {
+		t.Error("ECDSA signature made with classified key does not verify")
+	}
+
+	ctimeClassify(unsafe.Pointer(&keypair.seckey[0]), 32)
+	var sig64 [64]byte
+	err = SchnorrSignWithContext(ctx, sig64[:], msg, keypair, nil)
+	ctimeDeclassify(unsafe.Pointer(&err), unsafe.Sizeof(err))
+	ctimeDeclassify(unsafe.Pointer(&sig64[0]), 64)
+	ctimeDeclassify(unsafe.Pointer(&keypair.seckey[0]), 32)
+	if err != nil {
+		t.Fatalf("SchnorrSignWithContext failed: %v", err)
+	}
+	if !SchnorrVerify(sig64[:], msg, xonly) {
+		t.Error("Schnorr signature made with classified key does not verify")
+	}
+}
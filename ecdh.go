@@ -1,8 +1,11 @@
 package p256k1
 
 import (
+	"crypto/subtle"
 	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"unsafe"
 )
 
@@ -12,44 +15,94 @@ const (
 	windowG = 14 // Window size for generator (G) - larger for better performance
 )
 
+// ecmultConstGroupSize is the window width in bits used by EcmultConst.
+// Each window costs groupSize doublings, a scan of the whole 2^groupSize
+// entry table and one addition, so wider windows trade a bigger table and
+// longer scans for fewer additions. Any value from 4 to 8 gives identical
+// results. Since the scan is linear in the table size it dominates quickly:
+// on amd64 4 bits is fastest, 5 about 15% slower and 8 over three times
+// slower (see BenchmarkEcmultConstGroupSize).
+const ecmultConstGroupSize = 4
+
 // EcmultConst computes r = q * a using constant-time multiplication
-// Uses simple binary method
+// Uses fixed windows of ecmultConstGroupSize bits: every window performs the
+// same doublings, a full-table lookup and a constant-time addition whose
+// result is kept or discarded with cmov, so the sequence of operations and
+// memory accesses does not depend on q
 func EcmultConst(r *GroupElementJacobian, a *GroupElementAffine, q *Scalar) {
-	if a.isInfinity() {
-		r.setInfinity()
-		return
+	ecmultConstWindow(r, a, q, ecmultConstGroupSize)
+}
+
+// ecmultConstWindow is EcmultConst with the window width as a parameter.
+// It panics if groupSize is not in [4, 8].
+func ecmultConstWindow(r *GroupElementJacobian, a *GroupElementAffine, q *Scalar, groupSize int) {
+	if groupSize < 4 || groupSize > 8 {
+		panic("group size must be between 4 and 8")
 	}
-	
-	if q.isZero() {
+	if a.isInfinity() {
 		r.setInfinity()
 		return
 	}
-	
-	// Convert affine point to Jacobian
-	var aJac GroupElementJacobian
-	aJac.setGE(a)
-	
-	// Use simple binary method for constant-time behavior
+
+	// table[i] = i*a. addGEConst cannot add infinity, so table[0] holds a
+	// as a placeholder and the sum for a zero digit is discarded.
+	var tableBuf [1 << 8]GroupElementAffine
+	table := tableBuf[:1<<groupSize]
+	ecmultConstTable(table, a)
+	ecmultConstWithTable(r, table, q, groupSize)
+}
+
+// ecmultConstWithTable computes r = q*a in constant time from a table of
+// 2^groupSize multiples of a filled by ecmultConstTable
+func ecmultConstWithTable(r *GroupElementJacobian, table []GroupElementAffine, q *Scalar, groupSize int) {
 	r.setInfinity()
-	
-	var base GroupElementJacobian
-	base = aJac
-	
-	// Process bits from MSB to LSB
-	for i := 0; i < 256; i++ {
-		if i > 0 {
-			r.double(r)
+	var sum GroupElementJacobian
+	var entry GroupElementAffine
+	for offset := (255 / groupSize) * groupSize; offset >= 0; offset -= groupSize {
+		width := groupSize
+		if offset+width > 256 {
+			width = 256 - offset
 		}
-		
-		// Get bit i (from MSB)
-		bit := q.getBits(uint(255-i), 1)
-		if bit != 0 {
-			if r.isInfinity() {
-				*r = base
-			} else {
-				r.addVar(r, &base)
-			}
+		for j := 0; j < width; j++ {
+			r.double(r)
 		}
+
+		digit := int(q.getBitsClamped(uint(offset), uint(groupSize)))
+		GeCmovSelect(&entry, table, digit)
+		sum.addGEConst(r, &entry)
+		r.cmov(&sum, subtle.ConstantTimeEq(int32(digit), 0)^1)
+	}
+
+	sum.clear()
+	entry.clear()
+}
+
+// ecmultConstTable fills table with i*a for i >= 1, and table[0] = a, using
+// a single batched inversion to convert to affine
+func ecmultConstTable(table []GroupElementAffine, a *GroupElementAffine) {
+	n := len(table)
+	jac := make([]GroupElementJacobian, n)
+	zs := make([]FieldElement, n)
+	zInv := make([]FieldElement, n)
+	jac[0].setGE(a)
+	jac[1] = jac[0]
+	for i := 2; i < n; i++ {
+		jac[i].addGE(&jac[i-1], a)
+	}
+	for i := range jac {
+		zs[i] = jac[i].z
+	}
+	batchInverse(zInv, zs)
+
+	for i := range table {
+		var zi2, zi3 FieldElement
+		zi2.sqr(&zInv[i])
+		zi3.mul(&zi2, &zInv[i])
+		table[i].x.mul(&jac[i].x, &zi2)
+		table[i].y.mul(&jac[i].y, &zi3)
+		table[i].x.normalize()
+		table[i].y.normalize()
+		table[i].infinity = false
 	}
 }
 
@@ -132,7 +185,53 @@ func ecmultWindowedVar(r *GroupElementJacobian, a *GroupElementAffine, q *Scalar
 	}
 }
 
-// Ecmult computes r = q * a using optimized windowed multiplication
+// ScalarMultAlgorithm selects the implementation behind Ecmult
+type ScalarMultAlgorithm int32
+
+const (
+	// ScalarMultWindowed uses a 6-bit fixed window (variable-time). This is
+	// the default.
+	ScalarMultWindowed ScalarMultAlgorithm = iota
+	// ScalarMultConst uses EcmultConst (constant-time, slower)
+	ScalarMultConst
+	// ScalarMultStrauss uses the wNAF multiplication of EcmultStraussGLV
+	// (variable-time)
+	ScalarMultStrauss
+)
+
+// String returns the name of the algorithm
+func (algo ScalarMultAlgorithm) String() string {
+	switch algo {
+	case ScalarMultWindowed:
+		return "windowed"
+	case ScalarMultConst:
+		return "const"
+	case ScalarMultStrauss:
+		return "strauss"
+	default:
+		return fmt.Sprintf("ScalarMultAlgorithm(%d)", int32(algo))
+	}
+}
+
+// scalarMultAlgorithm holds the ScalarMultAlgorithm used by Ecmult
+var scalarMultAlgorithm atomic.Int32
+
+// SetScalarMultAlgorithm selects the algorithm backing Ecmult and returns
+// the previous selection. All algorithms compute the same result; this is
+// for benchmarking them against each other and as a fallback if one is
+// suspected of a bug. It is safe to call concurrently with Ecmult. It panics
+// if algo is not a known algorithm.
+func SetScalarMultAlgorithm(algo ScalarMultAlgorithm) ScalarMultAlgorithm {
+	switch algo {
+	case ScalarMultWindowed, ScalarMultConst, ScalarMultStrauss:
+	default:
+		panic("unknown scalar multiplication algorithm " + algo.String())
+	}
+	return ScalarMultAlgorithm(scalarMultAlgorithm.Swap(int32(algo)))
+}
+
+// Ecmult computes r = q * a using the algorithm selected by
+// SetScalarMultAlgorithm, by default optimized windowed multiplication.
 // This provides good performance for verification and ECDH operations
 func Ecmult(r *GroupElementJacobian, a *GroupElementJacobian, q *Scalar) {
 	if a.isInfinity() {
@@ -149,8 +248,14 @@ func Ecmult(r *GroupElementJacobian, a *GroupElementJacobian, q *Scalar) {
 	var aAff GroupElementAffine
 	aAff.setGEJ(a)
 
-	// Use optimized windowed multiplication
-	ecmultWindowedVar(r, &aAff, q)
+	switch ScalarMultAlgorithm(scalarMultAlgorithm.Load()) {
+	case ScalarMultConst:
+		EcmultConst(r, &aAff, q)
+	case ScalarMultStrauss:
+		ecmultStraussGLV(r, &aAff, q)
+	default:
+		ecmultWindowedVar(r, &aAff, q)
+	}
 }
 
 // ecmultStraussGLV computes r = q * a using Strauss algorithm with GLV endomorphism
@@ -167,9 +272,13 @@ func ecmultStraussGLV(r *GroupElementJacobian, a *GroupElementAffine, q *Scalar)
 	}
 
 	// For now, use simplified Strauss algorithm without GLV endomorphism
-	// Convert base point to Jacobian
+	// Convert base point to Jacobian. wNAF encodes -q if its top bit is set,
+	// so use -a in that case.
 	var aJac GroupElementJacobian
 	aJac.setGE(a)
+	if q.getBits(255, 1) == 1 {
+		aJac.negate(&aJac)
+	}
 
 	// Compute odd multiples for the scalar
 	var preA [1 << (windowA - 1)]GroupElementJacobian
@@ -201,13 +310,78 @@ func ecmultStraussGLV(r *GroupElementJacobian, a *GroupElementAffine, q *Scalar)
 					panic("wNAF index out of bounds (negative)")
 				}
 				pt = preA[(-n-1)/2]
-				pt.y.negate(&pt.y, 1)
+				pt.y.negate(&pt.y, pt.y.magnitude)
 			}
 			r.addVar(r, &pt)
 		}
 	}
 }
 
+// ecmultGenAndPoint computes r = ng*G + na*a in a single doubling chain
+// (variable-time), the s*G + e*P shape of signature verification. The a term
+// uses a wNAF of na with a table of odd multiples; the G term adds one
+// precomputed b*G from the generator table for each byte b of ng at the bit
+// where that byte starts, so the shared doublings scale it into place.
+func ecmultGenAndPoint(r *GroupElementJacobian, ng *Scalar, na *Scalar, a *GroupElementAffine) {
+	if a.isInfinity() || na.isZero() {
+		EcmultGen(r, ng)
+		return
+	}
+
+	// wNAF encodes -na if its top bit is set, so use -a in that case
+	var base GroupElementAffine
+	base = *a
+	if na.getBits(255, 1) == 1 {
+		base.negate(&base)
+	}
+	var wnaf [257]int
+	bits := na.wNAF(wnaf[:], windowA)
+
+	// pre[i] = (2*i+1) * base for every odd digit the wNAF can produce
+	var pre [1 << (windowA - 2)]GroupElementJacobian
+	var twoA GroupElementJacobian
+	pre[0].setGE(&base)
+	twoA.double(&pre[0])
+	for i := 1; i < len(pre); i++ {
+		pre[i].addVar(&pre[i-1], &twoA)
+	}
+
+	var ngBytes [32]byte
+	ng.getB32(ngBytes[:])
+	gen := getGlobalGenContext()
+
+	top := 255
+	if bits-1 > top {
+		top = bits - 1
+	}
+	r.setInfinity()
+	var pt GroupElementJacobian
+	var gx, gy FieldElement
+	var gpt GroupElementAffine
+	for i := top; i >= 0; i-- {
+		if !r.isInfinity() {
+			r.double(r)
+		}
+
+		if n := wnaf[i]; n > 0 {
+			r.addVar(r, &pre[(n-1)/2])
+		} else if n < 0 {
+			pt.negate(&pre[(-n-1)/2])
+			r.addVar(r, &pt)
+		}
+
+		if i%8 == 0 {
+			// Byte i/8 of ng, counting from the least significant
+			if b := ngBytes[31-i/8]; b != 0 {
+				gx.setB32(gen.bytePoints[31][b][0][:])
+				gy.setB32(gen.bytePoints[31][b][1][:])
+				gpt.setXY(&gx, &gy)
+				r.addGE(r, &gpt)
+			}
+		}
+	}
+}
+
 // buildOddMultiples builds a table of odd multiples of a point
 // pre[i] = (2*i+1) * a for i = 0 to (1<<(w-1))-1
 func buildOddMultiples(pre *[1 << (windowA - 1)]GroupElementJacobian, a *GroupElementJacobian, w uint) {
@@ -221,9 +395,9 @@ func buildOddMultiples(pre *[1 << (windowA - 1)]GroupElementJacobian, a *GroupEl
 		var twoA GroupElementJacobian
 		twoA.double(a)
 
-		// Build odd multiples: pre[i] = pre[i-2] + 2*a for i >= 2, i even
-		for i := 2; i < tableSize; i += 2 {
-			pre[i].addVar(&pre[i-2], &twoA)
+		// Build odd multiples: pre[i] = pre[i-1] + 2*a
+		for i := 1; i < tableSize; i++ {
+			pre[i].addVar(&pre[i-1], &twoA)
 		}
 	}
 }
@@ -233,6 +407,37 @@ func EcmultStraussGLV(r *GroupElementJacobian, a *GroupElementAffine, q *Scalar)
 	ecmultStraussGLV(r, a, q)
 }
 
+// PointMulCache caches the multiples table of a fixed point so that
+// repeated multiplications of the same point by different scalars (e.g. ECDH
+// with a long-lived key) only pay for the table once. The table is built
+// lazily on first use and the cache is safe for concurrent use.
+type PointMulCache struct {
+	point GroupElementAffine
+	once  sync.Once
+	table [1 << ecmultConstGroupSize]GroupElementAffine // as filled by ecmultConstTable
+}
+
+// NewPointMulCache creates a multiplication cache for the point a
+func NewPointMulCache(a *GroupElementAffine) *PointMulCache {
+	return &PointMulCache{point: *a}
+}
+
+// build computes the multiples table of the point
+func (c *PointMulCache) build() {
+	ecmultConstTable(c.table[:], &c.point)
+}
+
+// CachedMul computes r = k * point using the cached table. Like EcmultConst
+// it runs in constant time with respect to k, so k may be secret.
+func CachedMul(r *GroupElementJacobian, cache *PointMulCache, k *Scalar) {
+	if cache.point.isInfinity() {
+		r.setInfinity()
+		return
+	}
+	cache.once.Do(cache.build)
+	ecmultConstWithTable(r, cache.table[:], k, ecmultConstGroupSize)
+}
+
 // ECDHHashFunction is a function type for hashing ECDH shared secrets
 type ECDHHashFunction func(output []byte, x32 []byte, y32 []byte) bool
 
@@ -255,9 +460,35 @@ func ecdhHashFunctionSHA256(output []byte, x32 []byte, y32 []byte) bool {
 	return true
 }
 
+// ecdhHashFunctionSHA256Full hashes the full shared point as
+// SHA256(0x04 || x || y), for protocols whose shared secret is the
+// uncompressed point
+func ecdhHashFunctionSHA256Full(output []byte, x32 []byte, y32 []byte) bool {
+	if len(output) != 32 || len(x32) != 32 || len(y32) != 32 {
+		return false
+	}
+
+	sha := NewSHA256()
+	sha.Write([]byte{0x04})
+	sha.Write(x32)
+	sha.Write(y32)
+	sha.Finalize(output)
+	sha.Clear()
+
+	return true
+}
+
 // ECDH computes an EC Diffie-Hellman shared secret
 // Following the C reference implementation secp256k1_ecdh
 func ECDH(output []byte, pubkey *PublicKey, seckey []byte, hashfp ECDHHashFunction) error {
+	return ECDHFull(output, pubkey, seckey, false, hashfp)
+}
+
+// ECDHFull computes an EC Diffie-Hellman shared secret, choosing whether the
+// default hash covers the compressed point (SHA256(0x02|parity || x), as ECDH
+// does) or the full point (SHA256(0x04 || x || y)) when includeY is set. A
+// non-nil hashfp is always given both coordinates and overrides the default.
+func ECDHFull(output []byte, pubkey *PublicKey, seckey []byte, includeY bool, hashfp ECDHHashFunction) error {
 	if len(output) != 32 {
 		return errors.New("output must be 32 bytes")
 	}
@@ -270,31 +501,38 @@ func ECDH(output []byte, pubkey *PublicKey, seckey []byte, hashfp ECDHHashFuncti
 	
 	// Use default hash function if none provided
 	if hashfp == nil {
-		hashfp = ecdhHashFunctionSHA256
+		if includeY {
+			hashfp = ecdhHashFunctionSHA256Full
+		} else {
+			hashfp = ecdhHashFunctionSHA256
+		}
 	}
 	
 	// Load public key
 	var pt GroupElementAffine
 	pt.fromBytes(pubkey.data[:])
 	if pt.isInfinity() {
-		return errors.New("invalid public key")
+		return ErrInvalidPubkey
 	}
 	
 	// Parse scalar
 	var s Scalar
 	if !s.setB32Seckey(seckey) {
-		return errors.New("invalid secret key")
+		return ErrInvalidSeckey
 	}
 	
 	// Handle zero scalar
 	if s.isZero() {
-		return errors.New("secret key cannot be zero")
+		return ErrInvalidSeckey
 	}
 
-	// Compute res = s * pt using optimized windowed multiplication (variable-time)
-	// ECDH doesn't require constant-time since the secret key is already known
+	// Compute res = s * pt in constant time: s is the caller's secret key
 	var res GroupElementJacobian
-	ecmultWindowedVar(&res, &pt, &s)
+	EcmultConst(&res, &pt, &s)
+	if res.isInfinity() {
+		s.clear()
+		return ErrInvalidPubkey
+	}
 	
 	// Convert to affine
 	var resAff GroupElementAffine
@@ -427,23 +665,26 @@ func ECDHXOnly(output []byte, pubkey *PublicKey, seckey []byte) error {
 	var pt GroupElementAffine
 	pt.fromBytes(pubkey.data[:])
 	if pt.isInfinity() {
-		return errors.New("invalid public key")
+		return ErrInvalidPubkey
 	}
 	
 	// Parse scalar
 	var s Scalar
 	if !s.setB32Seckey(seckey) {
-		return errors.New("invalid secret key")
+		return ErrInvalidSeckey
 	}
 	
 	if s.isZero() {
-		return errors.New("secret key cannot be zero")
+		return ErrInvalidSeckey
 	}
 	
-	// Compute res = s * pt using optimized windowed multiplication (variable-time)
-	// ECDH doesn't require constant-time since the secret key is already known
+	// Compute res = s * pt in constant time: s is the caller's secret key
 	var res GroupElementJacobian
-	ecmultWindowedVar(&res, &pt, &s)
+	EcmultConst(&res, &pt, &s)
+	if res.isInfinity() {
+		s.clear()
+		return ErrInvalidPubkey
+	}
 	
 	// Convert to affine
 	var resAff GroupElementAffine
@@ -462,3 +703,46 @@ func ECDHXOnly(output []byte, pubkey *PublicKey, seckey []byte) error {
 }
 
 
+// NostrSharedSecret computes the shared secret used by Nostr encryption
+// (NIP-04, and as the input to the NIP-44 conversation key): the unhashed X
+// coordinate of mySeckey times the x-only public key theirPubXonly, lifted
+// to even Y as in BIP-340, written to out32. The multiplication is by the
+// peer's point rather than the generator, so ctx may be nil. It returns false
+// if either key is invalid.
+func NostrSharedSecret(ctx *Context, out32 []byte, theirPubXonly []byte, mySeckey []byte) bool {
+	return nostrSharedSecret(out32, theirPubXonly, mySeckey) == nil
+}
+
+// nostrSharedSecret is NostrSharedSecret reporting why it failed
+func nostrSharedSecret(output []byte, theirPubkey []byte, seckey []byte) error {
+	if len(theirPubkey) != 32 {
+		return errors.New("x-only pubkey must be 32 bytes")
+	}
+
+	var compressed [33]byte
+	compressed[0] = 0x02
+	copy(compressed[1:], theirPubkey)
+	var pubkey PublicKey
+	if err := ECPubkeyParse(&pubkey, compressed[:]); err != nil {
+		return err
+	}
+	return ECDHXOnly(output, &pubkey, seckey)
+}
+
+// NIP44ConversationKey derives the NIP-44 v2 conversation key between an
+// x-only public key and a secret key: HKDF-Extract with salt "nip44-v2" of
+// the NostrSharedSecret. The key is the same in both directions.
+func NIP44ConversationKey(theirPubkey []byte, seckey []byte) ([32]byte, error) {
+	var key, shared [32]byte
+	if err := nostrSharedSecret(shared[:], theirPubkey, seckey); err != nil {
+		return key, err
+	}
+
+	// HKDF-Extract: PRK = HMAC-SHA256(salt, IKM)
+	hmac := NewHMACSHA256([]byte("nip44-v2"))
+	hmac.Write(shared[:])
+	hmac.Finalize(key[:])
+	hmac.Clear()
+	memclear(unsafe.Pointer(&shared[0]), 32)
+	return key, nil
+}
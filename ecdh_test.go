@@ -1,7 +1,13 @@
 package p256k1
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"testing"
+	"unsafe"
 )
 
 func TestEcmultConst(t *testing.T) {
@@ -106,6 +112,102 @@ func TestECDH(t *testing.T) {
 	}
 }
 
+func TestECDHMatchesVarTime(t *testing.T) {
+	// ECDH multiplies in constant time; the result must agree with the
+	// variable-time multiplication on the same inputs
+	for i := 0; i < 32; i++ {
+		seckey, _, err := ECKeyPairGenerate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, pubkey, err := ECKeyPairGenerate()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var s Scalar
+		s.setB32Seckey(seckey)
+		var pt GroupElementAffine
+		pubkeyLoad(&pt, pubkey)
+		var res GroupElementJacobian
+		ecmultWindowedVar(&res, &pt, &s)
+		var resAff GroupElementAffine
+		resAff.setGEJ(&res)
+		resAff.x.normalize()
+		resAff.y.normalize()
+		var x, y [32]byte
+		resAff.x.getB32(x[:])
+		resAff.y.getB32(y[:])
+
+		var want, got [32]byte
+		ecdhHashFunctionSHA256(want[:], x[:], y[:])
+		if err := ECDH(got[:], pubkey, seckey, nil); err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("ECDH = %x, want %x", got, want)
+		}
+		if err := ECDHXOnly(got[:], pubkey, seckey); err != nil || got != x {
+			t.Fatalf("ECDHXOnly = %x (%v), want %x", got, err, x)
+		}
+	}
+}
+
+func TestECDHFull(t *testing.T) {
+	seckey1, pubkey1, err := ECKeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate key pair 1: %v", err)
+	}
+	seckey2, pubkey2, err := ECKeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate key pair 2: %v", err)
+	}
+
+	// Expected secrets from the shared point seckey1 * pubkey2
+	shared := *pubkey2
+	if err := ECPubkeyTweakMul(&shared, seckey1); err != nil {
+		t.Fatalf("failed to compute shared point: %v", err)
+	}
+	var compressed [33]byte
+	var uncompressed [65]byte
+	ECPubkeySerialize(compressed[:], &shared, ECCompressed)
+	ECPubkeySerialize(uncompressed[:], &shared, ECUncompressed)
+	wantX := sha256.Sum256(compressed[:])
+	wantXY := sha256.Sum256(uncompressed[:])
+
+	for _, tc := range []struct {
+		name     string
+		includeY bool
+		want     [32]byte
+	}{
+		{"compressed", false, wantX},
+		{"full", true, wantXY},
+	} {
+		var out1, out2 [32]byte
+		if err := ECDHFull(out1[:], pubkey2, seckey1, tc.includeY, nil); err != nil {
+			t.Fatalf("%s: ECDHFull failed for Alice: %v", tc.name, err)
+		}
+		if err := ECDHFull(out2[:], pubkey1, seckey2, tc.includeY, nil); err != nil {
+			t.Fatalf("%s: ECDHFull failed for Bob: %v", tc.name, err)
+		}
+		if out1 != out2 {
+			t.Errorf("%s: shared secrets differ", tc.name)
+		}
+		if out1 != tc.want {
+			t.Errorf("%s: shared secret = %x, want %x", tc.name, out1, tc.want)
+		}
+	}
+
+	// The compressed mode is what ECDH computes
+	var out [32]byte
+	if err := ECDH(out[:], pubkey2, seckey1, nil); err != nil {
+		t.Fatalf("ECDH failed: %v", err)
+	}
+	if out != wantX {
+		t.Error("ECDH does not match ECDHFull compressed mode")
+	}
+}
+
 func TestECDHZeroKey(t *testing.T) {
 	// Test that zero key is rejected
 	_, pubkey, err := ECKeyPairGenerate()
@@ -281,3 +383,537 @@ func TestECDHXOnly(t *testing.T) {
 		}
 	}
 }
+
+func TestNostrSharedSecret(t *testing.T) {
+	for i := 0; i < 16; i++ {
+		seckey1, pubkey1, err := ECKeyPairGenerate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		seckey2, pubkey2, err := ECKeyPairGenerate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		xonly1, _, err := XOnlyPubkeyFromPubkey(pubkey1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		xonly2, _, err := XOnlyPubkeyFromPubkey(pubkey2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		x1, x2 := xonly1.Serialize(), xonly2.Serialize()
+
+		// Both parties derive the same secret from the other's x-only key,
+		// whatever the parity of the full keys
+		var s1, s2, want [32]byte
+		if !NostrSharedSecret(nil, s1[:], x2[:], seckey1) {
+			t.Fatal("NostrSharedSecret failed")
+		}
+		if !NostrSharedSecret(nil, s2[:], x1[:], seckey2) {
+			t.Fatal("NostrSharedSecret failed")
+		}
+		if err := ECDHXOnly(want[:], pubkey2, seckey1); err != nil {
+			t.Fatal(err)
+		}
+		if s1 != s2 || s1 != want {
+			t.Fatalf("shared secrets differ: %x, %x, want %x", s1, s2, want)
+		}
+	}
+
+	// 1 * x(2G) = x(2G)
+	one := make([]byte, 32)
+	one[31] = 1
+	x2G, _ := hex.DecodeString("c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5")
+	var out [32]byte
+	ctx := ContextCreate(ContextSign)
+	defer ContextDestroy(ctx)
+	if ok := NostrSharedSecret(ctx, out[:], x2G, one); !ok || hex.EncodeToString(out[:]) != hex.EncodeToString(x2G) {
+		t.Errorf("NostrSharedSecret(1, 2G) = %x, %v", out, ok)
+	}
+
+	// x = 5 is not on the curve
+	notOnCurve := make([]byte, 32)
+	notOnCurve[31] = 5
+	if NostrSharedSecret(nil, out[:], notOnCurve, one) {
+		t.Error("x not on the curve should be rejected")
+	}
+	if NostrSharedSecret(nil, out[:], x2G, make([]byte, 32)) {
+		t.Error("zero seckey should be rejected")
+	}
+	if NostrSharedSecret(nil, out[:], x2G[:31], one) {
+		t.Error("short pubkey should be rejected")
+	}
+}
+
+func TestNIP44ConversationKey(t *testing.T) {
+	// From the NIP-44 get_conversation_key test vectors
+	vectors := []struct{ sec1, pub2, key string }{
+		{
+			"315e59ff51cb9209768cf7da80791ddcaae56ac9775eb25b6dee1234bc5d2268",
+			"c2f9d9948dc8c7c38321e4b85c8558872eafa0641cd269db76848a6073e69133",
+			"3dfef0ce2a4d80a25e7a328accf73448ef67096f65f79588e358d9a0eb9013f1",
+		},
+		{
+			"a1e37752c9fdc1273be53f68c5f74be7c8905728e8de75800b94262f9497c86e",
+			"03bb7947065dde12ba991ea045132581d0954f042c84e06d8c00066e23c1a800",
+			"4d14f36e81b8452128da64fe6f1eae873baae2f444b02c950b90e43553f2178b",
+		},
+		{
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			"79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+			"3b4610cb7189beb9cc29eb3716ecc6102f1247e8f3101a03a1787d8908aeb54e",
+		},
+	}
+	for _, v := range vectors {
+		sec, _ := hex.DecodeString(v.sec1)
+		pub, _ := hex.DecodeString(v.pub2)
+		key, err := NIP44ConversationKey(pub, sec)
+		if err != nil {
+			t.Fatalf("NIP44ConversationKey(%s, %s): %v", v.pub2, v.sec1, err)
+		}
+		if got := hex.EncodeToString(key[:]); got != v.key {
+			t.Errorf("NIP44ConversationKey(%s, %s) = %s, want %s", v.pub2, v.sec1, got, v.key)
+		}
+	}
+
+	// Symmetric between the two parties
+	seckey1, pubkey1, err := ECKeyPairGenerate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	seckey2, pubkey2, err := ECKeyPairGenerate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	xonly1, _, _ := XOnlyPubkeyFromPubkey(pubkey1)
+	xonly2, _, _ := XOnlyPubkeyFromPubkey(pubkey2)
+	x1, x2 := xonly1.Serialize(), xonly2.Serialize()
+	k1, err1 := NIP44ConversationKey(x2[:], seckey1)
+	k2, err2 := NIP44ConversationKey(x1[:], seckey2)
+	if err1 != nil || err2 != nil || k1 != k2 {
+		t.Errorf("conversation keys differ: %x (%v), %x (%v)", k1, err1, k2, err2)
+	}
+
+	if _, err := NIP44ConversationKey(x2[:], make([]byte, 32)); !errors.Is(err, ErrInvalidSeckey) {
+		t.Errorf("zero seckey: got %v, want ErrInvalidSeckey", err)
+	}
+}
+
+func TestCachedMul(t *testing.T) {
+	// Use a random point so the cache is not built for the generator
+	var seed Scalar
+	var seedBytes [32]byte
+	if _, err := rand.Read(seedBytes[:]); err != nil {
+		t.Fatal(err)
+	}
+	seed.setB32(seedBytes[:])
+	var pj GroupElementJacobian
+	EcmultGen(&pj, &seed)
+	var point GroupElementAffine
+	point.setGEJ(&pj)
+
+	cache := NewPointMulCache(&point)
+
+	scalars := make([]Scalar, 0, 20)
+	var s Scalar
+	s.setInt(1)
+	scalars = append(scalars, s)
+	s.setInt(31)
+	scalars = append(scalars, s)
+	s.setInt(1)
+	s.negate(&s)
+	scalars = append(scalars, s)
+	for i := 0; i < 16; i++ {
+		var b [32]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			t.Fatal(err)
+		}
+		s.setB32(b[:])
+		scalars = append(scalars, s)
+	}
+
+	for i := range scalars {
+		var result, expected GroupElementJacobian
+		CachedMul(&result, cache, &scalars[i])
+		Ecmult(&expected, &pj, &scalars[i])
+
+		var resultAff, expectedAff GroupElementAffine
+		resultAff.setGEJ(&result)
+		expectedAff.setGEJ(&expected)
+		resultAff.x.normalize()
+		resultAff.y.normalize()
+		expectedAff.x.normalize()
+		expectedAff.y.normalize()
+
+		if !resultAff.x.equal(&expectedAff.x) || !resultAff.y.equal(&expectedAff.y) {
+			t.Errorf("cached multiplication %d does not match Ecmult", i)
+		}
+	}
+
+	var zero Scalar
+	var result GroupElementJacobian
+	CachedMul(&result, cache, &zero)
+	if !result.isInfinity() {
+		t.Error("0*P should be infinity")
+	}
+}
+
+func BenchmarkCachedMul(b *testing.B) {
+	var k Scalar
+	var kb [32]byte
+	if _, err := rand.Read(kb[:]); err != nil {
+		b.Fatal(err)
+	}
+	k.setB32(kb[:])
+	cache := NewPointMulCache(&Generator)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var r GroupElementJacobian
+		CachedMul(&r, cache, &k)
+	}
+}
+
+func BenchmarkUncachedMul(b *testing.B) {
+	var k Scalar
+	var kb [32]byte
+	if _, err := rand.Read(kb[:]); err != nil {
+		b.Fatal(err)
+	}
+	k.setB32(kb[:])
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var r GroupElementJacobian
+		EcmultConst(&r, &Generator, &k)
+	}
+}
+
+func TestEcmultConstVsGen(t *testing.T) {
+	scalars := make([]Scalar, 0, 20)
+	var s Scalar
+	s.setInt(1)
+	s.negate(&s) // n-1
+	scalars = append(scalars, s)
+	s.setB32([]byte{
+		0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1,
+	})
+	scalars = append(scalars, s)
+	for i := 0; i < 16; i++ {
+		var b [32]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			t.Fatal(err)
+		}
+		s.setB32(b[:])
+		scalars = append(scalars, s)
+	}
+
+	for i := range scalars {
+		var result, expected GroupElementJacobian
+		EcmultConst(&result, &Generator, &scalars[i])
+		EcmultGen(&expected, &scalars[i])
+
+		var resultAff, expectedAff GroupElementAffine
+		resultAff.setGEJ(&result)
+		expectedAff.setGEJ(&expected)
+		if !resultAff.equal(&expectedAff) {
+			t.Errorf("EcmultConst does not match EcmultGen for scalar %d", i)
+		}
+	}
+}
+
+func TestEcmultConstGroupSizes(t *testing.T) {
+	// A point other than G, so table construction is exercised generically
+	var seven Scalar
+	seven.setInt(7)
+	var pj GroupElementJacobian
+	EcmultGen(&pj, &seven)
+	var p GroupElementAffine
+	p.setGEJ(&pj)
+
+	scalars := []Scalar{
+		{},
+		{d: [4]uint64{1, 0, 0, 0}},
+		{d: [4]uint64{scalarN0 - 1, scalarN1, scalarN2, scalarN3}},
+	}
+	for i := 0; i < 8; i++ {
+		var b [32]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			t.Fatal(err)
+		}
+		var s Scalar
+		s.setB32(b[:])
+		scalars = append(scalars, s)
+	}
+
+	var pjac GroupElementJacobian
+	pjac.setGE(&p)
+	for i := range scalars {
+		var expected GroupElementJacobian
+		Ecmult(&expected, &pjac, &scalars[i])
+		for groupSize := 4; groupSize <= 8; groupSize++ {
+			var result GroupElementJacobian
+			ecmultConstWindow(&result, &p, &scalars[i], groupSize)
+			if !jacobianEqual(&result, &expected) {
+				t.Errorf("group size %d: wrong result for scalar %d", groupSize, i)
+			}
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for group size 9")
+		}
+	}()
+	var r GroupElementJacobian
+	ecmultConstWindow(&r, &p, &scalars[1], 9)
+}
+
+func BenchmarkEcmultConstGroupSize(b *testing.B) {
+	var k Scalar
+	k.setB32([]byte{
+		0x4c, 0x8a, 0x3e, 0x91, 0x27, 0xd5, 0x60, 0x1b, 0xf3, 0x09, 0x6e, 0xa2, 0x58, 0xc4, 0x1d, 0x7f,
+		0x35, 0xe8, 0x92, 0x0b, 0x6a, 0xcf, 0x14, 0x73, 0xbd, 0x21, 0x5e, 0x86, 0xf0, 0x39, 0xa7, 0x42,
+	})
+
+	for groupSize := 4; groupSize <= 8; groupSize++ {
+		b.Run(fmt.Sprintf("w%d", groupSize), func(b *testing.B) {
+			// Affine table size, the memory cost of the window
+			b.ReportMetric(float64((1<<groupSize)*int(unsafe.Sizeof(GroupElementAffine{}))), "table-bytes")
+			var r GroupElementJacobian
+			for i := 0; i < b.N; i++ {
+				ecmultConstWindow(&r, &Generator, &k, groupSize)
+			}
+		})
+	}
+}
+
+func TestEcmultGenAndPoint(t *testing.T) {
+	var p GroupElementAffine
+	var pj GroupElementJacobian
+	var k Scalar
+	k.setInt(7)
+	EcmultGen(&pj, &k)
+	p.setGEJ(&pj)
+
+	var zero, one, nMinus1 Scalar
+	one.setInt(1)
+	nMinus1.negate(&one)
+	var topBit Scalar
+	topBit.setB32([]byte{
+		0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1,
+	})
+	scalars := []Scalar{zero, one, nMinus1, topBit}
+	for i := 0; i < 8; i++ {
+		var b [32]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			t.Fatal(err)
+		}
+		var s Scalar
+		s.setB32(b[:])
+		scalars = append(scalars, s)
+	}
+
+	var inf GroupElementAffine
+	inf.setInfinity()
+	for _, a := range []*GroupElementAffine{&p, &Generator, &inf} {
+		for i := range scalars {
+			for j := range scalars {
+				ng, na := &scalars[i], &scalars[j]
+
+				var got, gTerm, aTerm GroupElementJacobian
+				ecmultGenAndPoint(&got, ng, na, a)
+				EcmultGen(&gTerm, ng)
+				EcmultConst(&aTerm, a, na)
+				var want GroupElementJacobian
+				want.addVar(&gTerm, &aTerm)
+
+				var gotAff, wantAff GroupElementAffine
+				gotAff.setGEJ(&got)
+				wantAff.setGEJ(&want)
+				if !gotAff.equal(&wantAff) {
+					t.Fatalf("ecmultGenAndPoint mismatch for ng %d, na %d", i, j)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkEcmultGenAndPoint(b *testing.B) {
+	var ng, na Scalar
+	ng.setB32([]byte{
+		0x4c, 0x8a, 0x3e, 0x91, 0x27, 0xd5, 0x60, 0x1b, 0xf3, 0x09, 0x6e, 0xa2, 0x58, 0xc4, 0x1d, 0x7f,
+		0x35, 0xe8, 0x92, 0x0b, 0x6a, 0xcf, 0x14, 0x73, 0xbd, 0x21, 0x5e, 0x86, 0xf0, 0x39, 0xa7, 0x42,
+	})
+	na.setB32([]byte{
+		0x1b, 0x60, 0xd5, 0x27, 0x91, 0x3e, 0x8a, 0x4c, 0x7f, 0x1d, 0xc4, 0x58, 0xa2, 0x6e, 0x09, 0xf3,
+		0x42, 0xa7, 0x39, 0xf0, 0x86, 0x5e, 0x21, 0xbd, 0x73, 0x14, 0xcf, 0x6a, 0x0b, 0x92, 0xe8, 0x35,
+	})
+	var p GroupElementAffine
+	var pj GroupElementJacobian
+	EcmultGen(&pj, &na)
+	p.setGEJ(&pj)
+	getGlobalGenContext()
+
+	b.Run("interleaved", func(b *testing.B) {
+		var r GroupElementJacobian
+		for i := 0; i < b.N; i++ {
+			ecmultGenAndPoint(&r, &ng, &na, &p)
+		}
+	})
+	b.Run("separate", func(b *testing.B) {
+		var r, t GroupElementJacobian
+		for i := 0; i < b.N; i++ {
+			EcmultGen(&r, &ng)
+			ecmultWindowedVar(&t, &p, &na)
+			r.addVar(&r, &t)
+		}
+	})
+}
+
+func TestBuildOddMultiples(t *testing.T) {
+	var k Scalar
+	k.setInt(12345)
+	var a GroupElementJacobian
+	EcmultGen(&a, &k)
+
+	// Every entry is filled, with pre[i] = (2i+1)*a
+	var pre [1 << (windowA - 1)]GroupElementJacobian
+	buildOddMultiples(&pre, &a, windowA)
+	var p GroupElementAffine
+	p.setGEJ(&a)
+	for i := range pre {
+		var m Scalar
+		var want GroupElementJacobian
+		m.setInt(uint(2*i + 1))
+		EcmultConst(&want, &p, &m)
+		if !jacobianEqual(&pre[i], &want) {
+			t.Errorf("pre[%d] != %d*a", i, 2*i+1)
+		}
+	}
+}
+
+func TestEcmultStraussGLV(t *testing.T) {
+	var k Scalar
+	k.setInt(7)
+	var pj GroupElementJacobian
+	EcmultGen(&pj, &k)
+	var p GroupElementAffine
+	p.setGEJ(&pj)
+
+	// Scalars with the top bit set are encoded by wNAF as their negation
+	var one, nMinus1 Scalar
+	one.setInt(1)
+	nMinus1.negate(&one)
+	scalars := []Scalar{one, nMinus1}
+	for i := 0; i < 16; i++ {
+		var b [32]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			t.Fatal(err)
+		}
+		var s Scalar
+		s.setB32(b[:])
+		scalars = append(scalars, s)
+	}
+
+	for i := range scalars {
+		var got, want GroupElementJacobian
+		EcmultStraussGLV(&got, &p, &scalars[i])
+		EcmultConst(&want, &p, &scalars[i])
+		if !jacobianEqual(&got, &want) {
+			t.Errorf("scalar %d (top bit %d): wrong result", i, scalars[i].getBits(255, 1))
+		}
+	}
+}
+
+var scalarMultAlgorithms = []ScalarMultAlgorithm{ScalarMultWindowed, ScalarMultConst, ScalarMultStrauss}
+
+func TestSetScalarMultAlgorithm(t *testing.T) {
+	defer SetScalarMultAlgorithm(ScalarMultWindowed)
+
+	var seven Scalar
+	seven.setInt(7)
+	var pj GroupElementJacobian
+	EcmultGen(&pj, &seven)
+
+	var one, nMinus1 Scalar
+	one.setInt(1)
+	nMinus1.negate(&one)
+	scalars := []Scalar{{}, one, nMinus1}
+	for i := 0; i < 8; i++ {
+		var b [32]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			t.Fatal(err)
+		}
+		var s Scalar
+		s.setB32(b[:])
+		scalars = append(scalars, s)
+	}
+
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	xonly, err := kp.XOnlyPubkey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := sha256.Sum256([]byte("scalar mult algorithm"))
+	var sig [64]byte
+	if err := SchnorrSign(sig[:], msg[:], kp, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var p GroupElementAffine
+	p.setGEJ(&pj)
+	for _, algo := range scalarMultAlgorithms {
+		SetScalarMultAlgorithm(algo)
+		for i := range scalars {
+			var want, got GroupElementJacobian
+			EcmultConst(&want, &p, &scalars[i])
+			Ecmult(&got, &pj, &scalars[i])
+			if !jacobianEqual(&got, &want) {
+				t.Errorf("%v: wrong result for scalar %d", algo, i)
+			}
+		}
+		if !SchnorrVerify(sig[:], msg[:], xonly) {
+			t.Errorf("%v: valid signature rejected", algo)
+		}
+	}
+
+	if prev := SetScalarMultAlgorithm(ScalarMultWindowed); prev != ScalarMultStrauss {
+		t.Errorf("previous algorithm %v, want %v", prev, ScalarMultStrauss)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for an unknown algorithm")
+		}
+	}()
+	SetScalarMultAlgorithm(ScalarMultAlgorithm(99))
+}
+
+func BenchmarkScalarMultAlgorithm(b *testing.B) {
+	defer SetScalarMultAlgorithm(ScalarMultWindowed)
+
+	var k Scalar
+	k.setB32([]byte{
+		0x4c, 0x8a, 0x3e, 0x91, 0x27, 0xd5, 0x60, 0x1b, 0xf3, 0x09, 0x6e, 0xa2, 0x58, 0xc4, 0x1d, 0x7f,
+		0x35, 0xe8, 0x92, 0x0b, 0x6a, 0xcf, 0x14, 0x73, 0xbd, 0x21, 0x5e, 0x86, 0xf0, 0x39, 0xa7, 0x42,
+	})
+	var g GroupElementJacobian
+	g.setGE(&Generator)
+
+	for _, algo := range scalarMultAlgorithms {
+		b.Run(algo.String(), func(b *testing.B) {
+			SetScalarMultAlgorithm(algo)
+			var r GroupElementJacobian
+			for i := 0; i < b.N; i++ {
+				Ecmult(&r, &g, &k)
+			}
+		})
+	}
+}
@@ -1,7 +1,11 @@
 package p256k1
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
 	"errors"
+	"fmt"
+	"math/bits"
 	"unsafe"
 )
 
@@ -12,6 +16,86 @@ type ECDSASignature struct {
 
 // ECDSASign creates an ECDSA signature for a message hash using a private key
 func ECDSASign(sig *ECDSASignature, msghash32 []byte, seckey []byte) error {
+	return ecdsaSign(nil, sig, msghash32, seckey, nil, nil)
+}
+
+// ECDSASignWithContext is ECDSASign using the signing context's generator
+// table and blinding. The signature is identical to ECDSASign's. If the
+// context was created with ContextSignVerify the signature is verified before
+// returning, and ErrSignatureSelfCheck is returned if it does not verify.
+func ECDSASignWithContext(ctx *Context, sig *ECDSASignature, msghash32 []byte, seckey []byte) error {
+	if !ctx.canSign() {
+		return fmt.Errorf("%w: context cannot be used for signing", ErrContextNotBuilt)
+	}
+	if err := ctxECDSASign(ctx, sig, msghash32, seckey, nil, nil); err != nil {
+		return err
+	}
+	if !ctx.signVerify() {
+		return nil
+	}
+
+	var pubkey PublicKey
+	if err := ECPubkeyCreate(&pubkey, seckey); err != nil {
+		return err
+	}
+	if !ECDSAVerify(sig, msghash32, &pubkey) {
+		*sig = ECDSASignature{}
+		return ErrSignatureSelfCheck
+	}
+	return nil
+}
+
+// ECDSASignGrind creates an ECDSA signature whose DER encoding is at most
+// maxDERLen bytes long. It retries signing with an incrementing counter mixed
+// into the RFC6979 nonce derivation (as Bitcoin Core does for low-R grinding)
+// until the encoded signature fits. The first attempt uses no extra data, so
+// if it already fits the result is identical to ECDSASign. If ctx can sign,
+// its blinded generator multiplication is used. It returns false if the
+// inputs are invalid, maxDERLen is below 8 or every counter is exhausted.
+//
+// A bound of 70 grinds for a low R value and takes about two attempts.
+// Shorter encodings are reachable, as r or s may have leading zero bytes,
+// but each byte below 70 multiplies the expected number of attempts by
+// about 256. No DER signature is shorter than 8 bytes.
+func ECDSASignGrind(ctx *Context, sig *ECDSASignature, msghash32 []byte, seckey []byte, maxDERLen int) bool {
+	if sig == nil || maxDERLen < 8 {
+		return false
+	}
+	if !ctx.canSign() {
+		ctx = nil
+	}
+
+	var ndata [32]byte
+	for counter := uint32(0); ; counter++ {
+		var extra []byte
+		if counter != 0 {
+			// Little-endian counter, matching Bitcoin Core's extra entropy
+			ndata[0] = byte(counter)
+			ndata[1] = byte(counter >> 8)
+			ndata[2] = byte(counter >> 16)
+			ndata[3] = byte(counter >> 24)
+			extra = ndata[:]
+		}
+
+		if err := ecdsaSign(ctx, sig, msghash32, seckey, extra, nil); err != nil {
+			return false
+		}
+
+		if PredictDERLength(&sig.r, &sig.s) <= maxDERLen {
+			return true
+		}
+
+		if counter == ^uint32(0) {
+			*sig = ECDSASignature{}
+			return false
+		}
+	}
+}
+
+// ecdsaSign creates an ECDSA signature, mixing the optional 32-byte ndata
+// into the RFC6979 nonce derivation. A nil ctx uses the global generator table.
+// If recid is non-nil it receives the recovery id.
+func ecdsaSign(ctx *Context, sig *ECDSASignature, msghash32 []byte, seckey []byte, ndata []byte, recid *int) error {
 	if len(msghash32) != 32 {
 		return errors.New("message hash must be 32 bytes")
 	}
@@ -19,33 +103,44 @@ func ECDSASign(sig *ECDSASignature, msghash32 []byte, seckey []byte) error {
 		return errors.New("private key must be 32 bytes")
 	}
 	
-	// Parse secret key
+	// Parse secret key; whether it is valid is not secret
 	var sec Scalar
-	if !sec.setB32Seckey(seckey) {
-		return errors.New("invalid private key")
+	valid := sec.setB32Seckey(seckey)
+	ctx.declassify(unsafe.Pointer(&valid), unsafe.Sizeof(valid))
+	if !valid {
+		return ErrInvalidSeckey
 	}
 	
 	// Parse message hash
 	var msg Scalar
 	msg.setB32(msghash32)
 	
+	if ndata != nil && len(ndata) != 32 {
+		return errors.New("extra nonce data must be 32 bytes")
+	}
+	
 	// Generate nonce using RFC6979
-	nonceKey := make([]byte, 64)
+	nonceKey := make([]byte, 64+len(ndata))
 	copy(nonceKey[:32], msghash32)
-	copy(nonceKey[32:], seckey)
+	copy(nonceKey[32:64], seckey)
+	copy(nonceKey[64:], ndata)
 	
 	rng := NewRFC6979HMACSHA256(nonceKey)
-	memclear(unsafe.Pointer(&nonceKey[0]), 64)
+	memclear(unsafe.Pointer(&nonceKey[0]), uintptr(len(nonceKey)))
 	
 	var nonceBytes [32]byte
 	rng.Generate(nonceBytes[:])
 	
 	// Parse nonce
 	var nonce Scalar
-	if !nonce.setB32Seckey(nonceBytes[:]) {
+	valid = nonce.setB32Seckey(nonceBytes[:])
+	ctx.declassify(unsafe.Pointer(&valid), unsafe.Sizeof(valid))
+	if !valid {
 		// Retry with new nonce
 		rng.Generate(nonceBytes[:])
-		if !nonce.setB32Seckey(nonceBytes[:]) {
+		valid = nonce.setB32Seckey(nonceBytes[:])
+		ctx.declassify(unsafe.Pointer(&valid), unsafe.Sizeof(valid))
+		if !valid {
 			rng.Finalize()
 			rng.Clear()
 			return errors.New("nonce generation failed")
@@ -55,9 +150,70 @@ func ECDSASign(sig *ECDSASignature, msghash32 []byte, seckey []byte) error {
 	rng.Finalize()
 	rng.Clear()
 	
+	err := ecdsaSignWithScalars(ctx, sig, &sec, &msg, &nonce, recid)
+	
+	// Clear sensitive data
+	sec.clear()
+	msg.clear()
+	nonce.clear()
+	
+	return err
+}
+
+// ECDSASignWithNonce creates an ECDSA signature using the caller-provided
+// nonce32 as k instead of deriving it with RFC6979. It fails if k is zero or
+// not below the group order.
+//
+// This exists only to reproduce external test vectors and for interop
+// testing. It is NOT safe for production use: reusing or leaking anything
+// about k reveals the private key. Use ECDSASign instead.
+func ECDSASignWithNonce(sig *ECDSASignature, msghash32 []byte, seckey []byte, nonce32 []byte) error {
+	if len(msghash32) != 32 {
+		return errors.New("message hash must be 32 bytes")
+	}
+	if len(seckey) != 32 {
+		return errors.New("private key must be 32 bytes")
+	}
+	if len(nonce32) != 32 {
+		return errors.New("nonce must be 32 bytes")
+	}
+	
+	var sec Scalar
+	if !sec.setB32Seckey(seckey) {
+		return ErrInvalidSeckey
+	}
+	
+	var nonce Scalar
+	if !nonce.setB32Seckey(nonce32) {
+		sec.clear()
+		return errors.New("invalid nonce")
+	}
+	
+	var msg Scalar
+	msg.setB32(msghash32)
+	
+	err := ecdsaSignWithScalars(nil, sig, &sec, &msg, &nonce, nil)
+	
+	sec.clear()
+	msg.clear()
+	nonce.clear()
+	
+	return err
+}
+
+// ecdsaSignWithScalars computes r = X(nonce*G) mod n and
+// s = nonce^-1 * (msg + r*sec) mod n, normalized to low-S. If ctx is non-nil
+// R is computed with the context's blinded generator multiplication. If recid
+// is non-nil it receives the recovery id: bit 0 is the parity of R's Y and
+// bit 1 is set if X(R) was reduced modulo n.
+func ecdsaSignWithScalars(ctx *Context, sig *ECDSASignature, sec, msg, nonce *Scalar, recid *int) error {
 	// Compute R = nonce * G
 	var rp GroupElementJacobian
-	EcmultGen(&rp, &nonce)
+	if ctx != nil {
+		ctx.ecmultGen(&rp, nonce)
+	} else {
+		EcmultGen(&rp, nonce)
+	}
 	
 	// Convert to affine
 	var r GroupElementAffine
@@ -65,37 +221,41 @@ func ECDSASign(sig *ECDSASignature, msghash32 []byte, seckey []byte) error {
 	r.x.normalize()
 	r.y.normalize()
 	
-	// Extract r = X(R) mod n
+	// Extract r = X(R) mod n; R is part of the signature, so it is public
 	var rBytes [32]byte
 	r.x.getB32(rBytes[:])
+	ctx.declassify(unsafe.Pointer(&rBytes[0]), 32)
+	ctx.declassify(unsafe.Pointer(&r.y), unsafe.Sizeof(r.y))
 	
-	sig.r.setB32(rBytes[:])
+	overflow := sig.r.setB32(rBytes[:])
 	if sig.r.isZero() {
 		return errors.New("signature r is zero")
 	}
 	
 	// Compute s = nonce^-1 * (msg + r * sec) mod n
 	var n Scalar
-	n.mul(&sig.r, &sec)
-	n.add(&n, &msg)
+	n.mul(&sig.r, sec)
+	n.add(&n, msg)
 	
 	var nonceInv Scalar
-	nonceInv.inverse(&nonce)
+	nonceInv.inv(nonce)
 	sig.s.mul(&nonceInv, &n)
 	
-	// Normalize to low-S
-	if sig.s.isHigh() {
+	// Normalize to low-S; negating s corresponds to negating R
+	ctx.declassify(unsafe.Pointer(&sig.s), unsafe.Sizeof(sig.s))
+	high := sig.s.isHigh()
+	if high {
 		sig.s.condNegate(1)
 	}
+	if recid != nil {
+		*recid = (boolToInt(r.y.isOdd()) ^ boolToInt(high)) | boolToInt(overflow)<<1
+	}
 	
 	if sig.s.isZero() {
 		return errors.New("signature s is zero")
 	}
 	
 	// Clear sensitive data
-	sec.clear()
-	msg.clear()
-	nonce.clear()
 	n.clear()
 	nonceInv.clear()
 	rp.clear()
@@ -104,13 +264,28 @@ func ECDSASign(sig *ECDSASignature, msghash32 []byte, seckey []byte) error {
 	return nil
 }
 
+// ECDSAVerifyStrictDigest is ECDSAVerify, except that a digest that is not
+// below the group order is rejected instead of being reduced modulo n, as
+// some strict protocols require
+func ECDSAVerifyStrictDigest(sig *ECDSASignature, msghash32 []byte, pubkey *PublicKey) bool {
+	if len(msghash32) != 32 {
+		return false
+	}
+	var m Scalar
+	if m.setB32(msghash32) {
+		return false
+	}
+	return ECDSAVerify(sig, msghash32, pubkey)
+}
+
 // ECDSAVerify verifies an ECDSA signature against a message hash and public key
 func ECDSAVerify(sig *ECDSASignature, msghash32 []byte, pubkey *PublicKey) bool {
 	if len(msghash32) != 32 {
 		return false
 	}
 	
-	// Check signature components are non-zero
+	// r and s must be in [1, n-1]; scalars are always below n, so only zero
+	// needs rejecting here
 	if sig.r.isZero() || sig.s.isZero() {
 		return false
 	}
@@ -128,7 +303,7 @@ func ECDSAVerify(sig *ECDSASignature, msghash32 []byte, pubkey *PublicKey) bool
 	
 	// Compute s^-1 mod n
 	var sInv Scalar
-	sInv.inverse(&sig.s)
+	sInv.invVar(&sig.s)
 	
 	// Compute u1 = msg * s^-1 mod n
 	var u1 Scalar
@@ -192,6 +367,161 @@ func ECDSAVerify(sig *ECDSASignature, msghash32 []byte, pubkey *PublicKey) bool
 	return sig.r.equal(&computedR)
 }
 
+// ECDSAVerifyWithMalleabilityInfo verifies sig like ECDSAVerify, which
+// accepts both s and n-s, and also reports whether s is in the canonical
+// low-S form. Policy code can then accept a high-S signature but flag it
+// rather than reject it outright. Both results are false if ctx was not
+// created with ContextVerify.
+func ECDSAVerifyWithMalleabilityInfo(ctx *Context, sig *ECDSASignature, msghash32 []byte, pubkey *PublicKey) (valid bool, lowS bool) {
+	if !ctx.canVerify() {
+		return false, false
+	}
+	if !ECDSAVerify(sig, msghash32, pubkey) {
+		return false, false
+	}
+	return true, !sig.s.isHigh()
+}
+
+// ECDSAVerifyMessage verifies an ECDSA signature over message hashed with
+// Bitcoin's double SHA-256, SHA256(SHA256(message))
+func ECDSAVerifyMessage(sig *ECDSASignature, message []byte, pubkey *PublicKey) bool {
+	msghash := doubleSHA256(message)
+	return ECDSAVerify(sig, msghash[:], pubkey)
+}
+
+// ECDSAVerifyMessageSHA256 verifies an ECDSA signature over message hashed
+// with a single SHA-256
+func ECDSAVerifyMessageSHA256(sig *ECDSASignature, message []byte, pubkey *PublicKey) bool {
+	msghash := sha256.Sum256(message)
+	return ECDSAVerify(sig, msghash[:], pubkey)
+}
+
+// ECDSASignatureSerializeDER serializes an ECDSA signature in strict DER
+// format. The output buffer must hold at least 72 bytes. Returns the number
+// of bytes written, or 0 if the buffer is too small.
+func ECDSASignatureSerializeDER(output []byte, sig *ECDSASignature) int {
+	var rBytes, sBytes [33]byte
+	sig.r.getB32(rBytes[1:])
+	sig.s.getB32(sBytes[1:])
+
+	// Strip leading zeros, keeping one if the next byte has its high bit set
+	// so the INTEGER stays positive
+	r := derMinimalInteger(rBytes[:])
+	s := derMinimalInteger(sBytes[:])
+
+	total := 6 + len(r) + len(s)
+	if len(output) < total {
+		return 0
+	}
+
+	output[0] = 0x30
+	output[1] = byte(4 + len(r) + len(s))
+	output[2] = 0x02
+	output[3] = byte(len(r))
+	copy(output[4:], r)
+	output[4+len(r)] = 0x02
+	output[5+len(r)] = byte(len(s))
+	copy(output[6+len(r):], s)
+
+	return total
+}
+
+// PredictDERLength returns the length ECDSASignatureSerializeDER produces for
+// a signature with the given r and s, without encoding it, e.g. to estimate
+// transaction sizes before signing. Each INTEGER takes the minimal number of
+// bytes for its value, plus a zero byte if the top bit is set.
+func PredictDERLength(r, s *Scalar) int {
+	return 6 + derIntegerLength(r) + derIntegerLength(s)
+}
+
+// derIntegerLength returns the length of a's minimal DER INTEGER content
+func derIntegerLength(a *Scalar) int {
+	bitLen := 0
+	for i := 3; i >= 0; i-- {
+		if a.d[i] != 0 {
+			bitLen = 64*i + bits.Len64(a.d[i])
+			break
+		}
+	}
+	// One byte per started octet, plus a zero byte when the top bit of the
+	// top byte is set; zero encodes as a single byte
+	return bitLen/8 + 1
+}
+
+// ECDSASignatureParseDER parses a strict DER-encoded ECDSA signature: a
+// SEQUENCE of two minimally-encoded, non-negative INTEGERs r and s, each
+// below the group order, with no trailing data
+func ECDSASignatureParseDER(sig *ECDSASignature, input []byte) error {
+	if len(input) < 8 || len(input) > 72 {
+		return fmt.Errorf("%w: invalid DER signature length", ErrParse)
+	}
+	if input[0] != 0x30 || int(input[1]) != len(input)-2 {
+		return fmt.Errorf("%w: invalid DER sequence", ErrParse)
+	}
+
+	rest := input[2:]
+	var r, s Scalar
+	var err error
+	if rest, err = derParseInteger(&r, rest); err != nil {
+		return err
+	}
+	if rest, err = derParseInteger(&s, rest); err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return fmt.Errorf("%w: trailing data after DER signature", ErrParse)
+	}
+
+	sig.r = r
+	sig.s = s
+	return nil
+}
+
+// derParseInteger parses one DER INTEGER into r, rejecting negative,
+// non-minimal and out-of-range values, and returns the remaining input
+func derParseInteger(r *Scalar, input []byte) ([]byte, error) {
+	if len(input) < 2 || input[0] != 0x02 {
+		return nil, fmt.Errorf("%w: invalid DER integer", ErrParse)
+	}
+	n := int(input[1])
+	if n == 0 || n > 33 || len(input) < 2+n {
+		return nil, fmt.Errorf("%w: invalid DER integer length", ErrParse)
+	}
+	v := input[2 : 2+n]
+
+	if v[0]&0x80 != 0 {
+		return nil, fmt.Errorf("%w: negative DER integer", ErrParse)
+	}
+	if n > 1 && v[0] == 0 && v[1]&0x80 == 0 {
+		return nil, fmt.Errorf("%w: non-minimal DER integer", ErrParse)
+	}
+	if n == 33 {
+		// Only allowed as a 0x00 pad before a high bit; any other leading
+		// byte makes the value at least 2^256
+		if v[0] != 0 {
+			return nil, fmt.Errorf("%w: DER integer exceeds group order", ErrParse)
+		}
+		v = v[1:]
+	}
+
+	var b [32]byte
+	copy(b[32-len(v):], v)
+	if r.setB32(b[:]) {
+		return nil, fmt.Errorf("%w: DER integer exceeds group order", ErrParse)
+	}
+
+	return input[2+n:], nil
+}
+
+// derMinimalInteger returns the minimal DER INTEGER content for a 33-byte
+// big-endian buffer whose first byte is zero
+func derMinimalInteger(b []byte) []byte {
+	for len(b) > 1 && b[0] == 0 && b[1]&0x80 == 0 {
+		b = b[1:]
+	}
+	return b
+}
+
 // ECDSASignatureCompact represents a compact 64-byte signature (r || s)
 type ECDSASignatureCompact [64]byte
 
@@ -203,18 +533,35 @@ func (sig *ECDSASignature) ToCompact() *ECDSASignatureCompact {
 	return &compact
 }
 
-// FromCompact converts a compact signature to ECDSA signature format
+// FromCompact converts a compact signature to ECDSA signature format. Both r
+// and s must be in [1, n-1]; values >= n are rejected rather than reduced, as
+// reducing would let r+n or s+n verify as a second encoding of r or s.
 func (sig *ECDSASignature) FromCompact(compact *ECDSASignatureCompact) error {
-	sig.r.setB32(compact[:32])
-	sig.s.setB32(compact[32:64])
+	overflowR := sig.r.setB32(compact[:32])
+	overflowS := sig.s.setB32(compact[32:64])
 	
+	if overflowR || overflowS {
+		return fmt.Errorf("%w: r or s is not below the group order", ErrInvalidSignature)
+	}
 	if sig.r.isZero() || sig.s.isZero() {
-		return errors.New("invalid signature: r or s is zero")
+		return fmt.Errorf("%w: r or s is zero", ErrInvalidSignature)
 	}
 	
 	return nil
 }
 
+// EqualConst reports whether sig and b are the same signature, in time that
+// does not depend on where they differ. Use it when either side is a secret
+// expected value.
+func (sig *ECDSASignature) EqualConst(b *ECDSASignature) bool {
+	var x, y [64]byte
+	sig.r.getB32(x[:32])
+	sig.s.getB32(x[32:])
+	b.r.getB32(y[:32])
+	b.s.getB32(y[32:])
+	return subtle.ConstantTimeCompare(x[:], y[:]) == 1
+}
+
 // VerifyCompact verifies a compact signature
 func ECDSAVerifyCompact(compact *ECDSASignatureCompact, msghash32 []byte, pubkey *PublicKey) bool {
 	var sig ECDSASignature
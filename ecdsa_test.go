@@ -1,7 +1,12 @@
 package p256k1
 
 import (
+	"bytes"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
 	"testing"
 )
 
@@ -102,3 +107,644 @@ func TestECDSASignCompact(t *testing.T) {
 	}
 }
 
+
+func TestECDSASignGrind(t *testing.T) {
+	seckey, err := ECSeckeyGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate secret key: %v", err)
+	}
+
+	var pubkey PublicKey
+	if err := ECPubkeyCreate(&pubkey, seckey); err != nil {
+		t.Fatalf("failed to create public key: %v", err)
+	}
+
+	msghash := make([]byte, 32)
+	var der [72]byte
+	for i := 0; i < 16; i++ {
+		if _, err := rand.Read(msghash); err != nil {
+			t.Fatal(err)
+		}
+
+		var sig ECDSASignature
+		if !ECDSASignGrind(nil, &sig, msghash, seckey, 70) {
+			t.Fatal("failed to sign")
+		}
+
+		if n := ECDSASignatureSerializeDER(der[:], &sig); n == 0 || n > 70 {
+			t.Errorf("DER length %d exceeds bound 70", n)
+		}
+
+		if !ECDSAVerify(&sig, msghash, &pubkey) {
+			t.Error("ground signature verification failed")
+		}
+	}
+
+	// A bound that the first attempt always meets must match ECDSASign
+	var sig1, sig2 ECDSASignature
+	if err := ECDSASign(&sig1, msghash, seckey); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if !ECDSASignGrind(nil, &sig2, msghash, seckey, 72) {
+		t.Fatal("failed to sign")
+	}
+	if !sig1.r.equal(&sig2.r) || !sig1.s.equal(&sig2.s) {
+		t.Error("grinding with a loose bound should match ECDSASign")
+	}
+
+	// 69 bytes needs r or s below 2^248, about one attempt in 128
+	var sig ECDSASignature
+	if !ECDSASignGrind(nil, &sig, msghash, seckey, 69) {
+		t.Fatal("failed to sign with a 69-byte bound")
+	}
+	if n := ECDSASignatureSerializeDER(der[:], &sig); n == 0 || n > 69 {
+		t.Errorf("DER length %d exceeds bound 69", n)
+	}
+	if !ECDSAVerify(&sig, msghash, &pubkey) {
+		t.Error("ground signature verification failed")
+	}
+
+	// A signing context gives the same signature
+	ctx := ContextCreate(ContextSign)
+	defer ContextDestroy(ctx)
+	var sig3 ECDSASignature
+	if !ECDSASignGrind(ctx, &sig3, msghash, seckey, 69) {
+		t.Fatal("failed to sign with a context")
+	}
+	if !sig.r.equal(&sig3.r) || !sig.s.equal(&sig3.s) {
+		t.Error("grinding with a context should match grinding without")
+	}
+
+	if ECDSASignGrind(nil, &sig, msghash, seckey, 7) {
+		t.Error("expected failure for a bound below the shortest DER signature")
+	}
+	if ECDSASignGrind(nil, &sig, msghash, make([]byte, 32), 72) {
+		t.Error("expected failure for a zero secret key")
+	}
+}
+
+func TestECDSASignWithNonce(t *testing.T) {
+	// (d, k, m) -> (r, s) with s normalized to low-S
+	vectors := []struct {
+		d, k, m, r, s string
+	}{
+		{
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			"ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb",
+			"79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+			"4455e791c3f879775062944968aae755ef740fec9301d71053a09a53f6b01f12",
+		},
+		{
+			"0000000000000000000000000000000000000000000000000000000000000002",
+			"0000000000000000000000000000000000000000000000000000000000000003",
+			"3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d",
+			"f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+			"4529014cf3b1b587135a05da38a138d168a3d962d4b78057cd5518f00b1771bc",
+		},
+		{
+			"c9afa9d845ba75166b5c215767b1d6934e50c3db36e89b127b8a622b120f6721",
+			"0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			"af2bdbe1aa9b6ec1e2ade1d694f41fc71a831d0268e9891562113d8a62add1bf",
+			"4646ae5047316b4230d0086c8acec687f00b1cd9d1dc634f6cb358ac0a9a8fff",
+			"2e356dbf60db892f70dec7017bd2b73ecd3b0333f66d6f411ca0ccd7393c2a9f",
+		},
+		{
+			"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364140",
+			"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd036413f",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			"c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5",
+			"1cfdc035df09414967dd5fc8b51fc1932f1ba74d912cb1ca0a132a69b9e2d12e",
+		},
+	}
+
+	decode := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatalf("failed to decode hex: %v", err)
+		}
+		return b
+	}
+
+	for i, v := range vectors {
+		var sig ECDSASignature
+		if err := ECDSASignWithNonce(&sig, decode(v.m), decode(v.d), decode(v.k)); err != nil {
+			t.Fatalf("vector %d: failed to sign: %v", i, err)
+		}
+
+		compact := sig.ToCompact()
+		if hex.EncodeToString(compact[:32]) != v.r {
+			t.Errorf("vector %d: r = %x, want %s", i, compact[:32], v.r)
+		}
+		if hex.EncodeToString(compact[32:]) != v.s {
+			t.Errorf("vector %d: s = %x, want %s", i, compact[32:], v.s)
+		}
+
+		var pubkey PublicKey
+		if err := ECPubkeyCreate(&pubkey, decode(v.d)); err != nil {
+			t.Fatalf("vector %d: failed to create public key: %v", i, err)
+		}
+		if !ECDSAVerify(&sig, decode(v.m), &pubkey) {
+			t.Errorf("vector %d: signature verification failed", i)
+		}
+	}
+
+	// Invalid nonces must be rejected
+	seckey := decode(vectors[0].d)
+	msg := decode(vectors[0].m)
+	var sig ECDSASignature
+	if err := ECDSASignWithNonce(&sig, msg, seckey, make([]byte, 32)); err == nil {
+		t.Error("expected error for zero nonce")
+	}
+	if err := ECDSASignWithNonce(&sig, msg, seckey, decode("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141")); err == nil {
+		t.Error("expected error for nonce equal to n")
+	}
+}
+
+func TestShortInputsDoNotPanic(t *testing.T) {
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	xonly, err := kp.XOnlyPubkey()
+	if err != nil {
+		t.Fatalf("failed to get x-only pubkey: %v", err)
+	}
+
+	var sig ECDSASignature
+	var buf [64]byte
+	for _, n := range []int{0, 1, 31, 33} {
+		short := make([]byte, n)
+
+		if ECDSASign(&sig, short, kp.Seckey()) == nil {
+			t.Errorf("ECDSASign accepted %d-byte message", n)
+		}
+		if ECDSASign(&sig, buf[:32], short) == nil {
+			t.Errorf("ECDSASign accepted %d-byte key", n)
+		}
+		if ECDSASignWithNonce(&sig, buf[:32], kp.Seckey(), short) == nil {
+			t.Errorf("ECDSASignWithNonce accepted %d-byte nonce", n)
+		}
+		if ECDSAVerify(&sig, short, kp.Pubkey()) {
+			t.Errorf("ECDSAVerify accepted %d-byte message", n)
+		}
+		if ECDSASignatureParseDER(&sig, short) == nil {
+			t.Errorf("ECDSASignatureParseDER accepted %d-byte input", n)
+		}
+		if ECSeckeyTweakAdd(short, buf[:32]) == nil || ECPubkeyTweakMul(kp.Pubkey(), short) == nil {
+			t.Errorf("tweak accepted %d-byte input", n)
+		}
+		if ECDH(buf[:32], kp.Pubkey(), short, nil) == nil {
+			t.Errorf("ECDH accepted %d-byte key", n)
+		}
+		if SchnorrSign(buf[:], short, kp, nil) == nil {
+			t.Errorf("SchnorrSign accepted %d-byte message", n)
+		}
+		if SchnorrSign(buf[:], buf[:32], kp, short) == nil {
+			t.Errorf("SchnorrSign accepted %d-byte aux randomness", n)
+		}
+		if SchnorrVerify(short, buf[:32], xonly) || SchnorrVerify(buf[:], short, xonly) {
+			t.Errorf("SchnorrVerify accepted %d-byte input", n)
+		}
+		if _, ok := SchnorrExtractR(short); ok {
+			t.Errorf("SchnorrExtractR accepted %d-byte signature", n)
+		}
+		if _, err := HashToScalar(short); err == nil {
+			t.Errorf("HashToScalar accepted %d-byte input", n)
+		}
+	}
+}
+
+// addBigEndian32 returns the 32-byte big-endian encoding of a + hexValue, or
+// false if the sum does not fit in 256 bits
+func addBigEndian32(a []byte, hexValue string) ([32]byte, bool) {
+	var out [32]byte
+	v, _ := new(big.Int).SetString(hexValue, 16)
+	sum := new(big.Int).Add(new(big.Int).SetBytes(a), v)
+	if sum.BitLen() > 256 {
+		return out, false
+	}
+	sum.FillBytes(out[:])
+	return out, true
+}
+
+const (
+	testGroupOrderHex = "fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141"
+	testFieldPrimeHex = "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f"
+)
+
+func TestECDSASignatureRange(t *testing.T) {
+	// With k = 1 and msg = 0, the key d = r^-1 makes (r, 1) a valid
+	// signature, so s+n fits in 32 bytes and can be tried as an encoding
+	msg := make([]byte, 32)
+	var r, d Scalar
+	gx := Generator.x
+	gx.normalize()
+	var gxBytes [32]byte
+	gx.getB32(gxBytes[:])
+	r.setB32(gxBytes[:])
+	d.inverse(&r)
+	seckey := d.Bytes()
+	var pubkey PublicKey
+	if err := ECPubkeyCreate(&pubkey, seckey[:]); err != nil {
+		t.Fatalf("failed to create pubkey: %v", err)
+	}
+
+	var compact ECDSASignatureCompact
+	copy(compact[:32], gxBytes[:])
+	compact[63] = 1
+	if !ECDSAVerifyCompact(&compact, msg, &pubkey) {
+		t.Fatal("constructed signature failed to verify")
+	}
+
+	// s+n encodes the same residue as s and must not verify
+	shifted := compact
+	sPlusN, ok := addBigEndian32(compact[32:], testGroupOrderHex)
+	if !ok {
+		t.Fatal("1+n should fit in 256 bits")
+	}
+	copy(shifted[32:], sPlusN[:])
+	if ECDSAVerifyCompact(&shifted, msg, &pubkey) {
+		t.Error("signature with s+n verified")
+	}
+
+	order, _ := hex.DecodeString(testGroupOrderHex)
+	orderMinus1, _ := hex.DecodeString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364140")
+	zero := make([]byte, 32)
+	one, _ := hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000001")
+
+	cases := []struct {
+		name  string
+		r, s  []byte
+		valid bool
+	}{
+		{"r=0", zero, one, false},
+		{"s=0", one, zero, false},
+		{"r=n", order, one, false},
+		{"s=n", one, order, false},
+		{"r=s=1", one, one, true},
+		{"r=n-1", orderMinus1, one, true},
+		{"s=n-1", one, orderMinus1, true},
+	}
+	for _, tc := range cases {
+		var c ECDSASignatureCompact
+		copy(c[:32], tc.r)
+		copy(c[32:], tc.s)
+		var sig ECDSASignature
+		err := sig.FromCompact(&c)
+		if tc.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("%s: expected error", tc.name)
+		}
+	}
+}
+
+func TestECDSAVerifyMessage(t *testing.T) {
+	seckey, pubkey, err := ECKeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	message := []byte("Hello World")
+
+	single := sha256.Sum256(message)
+	double := sha256.Sum256(single[:])
+
+	var sigDouble, sigSingle ECDSASignature
+	if err := ECDSASign(&sigDouble, double[:], seckey); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if err := ECDSASign(&sigSingle, single[:], seckey); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	if !ECDSAVerifyMessage(&sigDouble, message, pubkey) {
+		t.Error("double SHA-256 message signature failed to verify")
+	}
+	if !ECDSAVerifyMessageSHA256(&sigSingle, message, pubkey) {
+		t.Error("single SHA-256 message signature failed to verify")
+	}
+
+	// Each variant must agree with manual hashing and reject the other
+	if ECDSAVerifyMessage(&sigSingle, message, pubkey) != ECDSAVerify(&sigSingle, double[:], pubkey) {
+		t.Error("ECDSAVerifyMessage disagrees with ECDSAVerify on the double hash")
+	}
+	if ECDSAVerifyMessage(&sigSingle, message, pubkey) {
+		t.Error("single-hash signature verified as a double-hash message")
+	}
+	if ECDSAVerifyMessageSHA256(&sigDouble, message, pubkey) {
+		t.Error("double-hash signature verified as a single-hash message")
+	}
+	if ECDSAVerifyMessage(&sigDouble, []byte("Hello World!"), pubkey) {
+		t.Error("signature verified for a different message")
+	}
+}
+
+func TestECDSASignatureEqualConst(t *testing.T) {
+	seckey, _, err := ECKeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	msg := make([]byte, 32)
+
+	var a, b ECDSASignature
+	if err := ECDSASign(&a, msg, seckey); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if err := ECDSASign(&b, msg, seckey); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if !a.EqualConst(&b) || !b.EqualConst(&a) {
+		t.Error("identical signatures should compare equal")
+	}
+
+	// Differing only in r or only in s
+	c := b
+	c.r.setInt(1)
+	if a.EqualConst(&c) {
+		t.Error("signatures with different r should compare unequal")
+	}
+	c = b
+	c.s.setInt(1)
+	if a.EqualConst(&c) {
+		t.Error("signatures with different s should compare unequal")
+	}
+
+	msg[0] = 1
+	if err := ECDSASign(&b, msg, seckey); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if a.EqualConst(&b) {
+		t.Error("signatures over different messages should compare unequal")
+	}
+}
+
+func TestECDSAVerifyStrictDigest(t *testing.T) {
+	seckey, pubkey, err := ECKeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+
+	order, _ := hex.DecodeString(testGroupOrderHex)
+	orderMinus1, _ := hex.DecodeString(testGroupOrderHex)
+	orderMinus1[31]--
+
+	// n - 1 is a valid digest for both variants
+	var sig ECDSASignature
+	if err := ECDSASign(&sig, orderMinus1, seckey); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if !ECDSAVerify(&sig, orderMinus1, pubkey) || !ECDSAVerifyStrictDigest(&sig, orderMinus1, pubkey) {
+		t.Error("digest n-1 should verify")
+	}
+
+	// n reduces to zero, which ECDSAVerify accepts and the strict variant
+	// rejects
+	if err := ECDSASign(&sig, order, seckey); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if !ECDSAVerify(&sig, order, pubkey) {
+		t.Error("ECDSAVerify should reduce digest n")
+	}
+	if ECDSAVerifyStrictDigest(&sig, order, pubkey) {
+		t.Error("ECDSAVerifyStrictDigest should reject digest n")
+	}
+	if ECDSAVerifyStrictDigest(&sig, order[:31], pubkey) {
+		t.Error("short digest should be rejected")
+	}
+}
+
+func TestECDSAVerifyWithMalleabilityInfo(t *testing.T) {
+	ctx := ContextCreate(ContextVerify)
+	defer ContextDestroy(ctx)
+
+	seckey, pubkey, err := ECKeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	msg := make([]byte, 32)
+	if _, err := rand.Read(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	var sig ECDSASignature
+	if err := ECDSASign(&sig, msg, seckey); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if valid, lowS := ECDSAVerifyWithMalleabilityInfo(ctx, &sig, msg, pubkey); !valid || !lowS {
+		t.Errorf("signed signature: valid=%v lowS=%v, want true, true", valid, lowS)
+	}
+
+	// The high-S twin is still valid but not canonical
+	high := sig
+	high.s.negate(&high.s)
+	if valid, lowS := ECDSAVerifyWithMalleabilityInfo(ctx, &high, msg, pubkey); !valid || lowS {
+		t.Errorf("high-S signature: valid=%v lowS=%v, want true, false", valid, lowS)
+	}
+
+	msg[0] ^= 1
+	if valid, lowS := ECDSAVerifyWithMalleabilityInfo(ctx, &high, msg, pubkey); valid || lowS {
+		t.Errorf("wrong message: valid=%v lowS=%v, want false, false", valid, lowS)
+	}
+	msg[0] ^= 1
+
+	signCtx := ContextCreate(ContextSign)
+	defer ContextDestroy(signCtx)
+	if valid, _ := ECDSAVerifyWithMalleabilityInfo(signCtx, &sig, msg, pubkey); valid {
+		t.Error("expected failure with a context that cannot verify")
+	}
+}
+
+func TestPredictDERLength(t *testing.T) {
+	var zero, one, nMinus1, x7f, x80, xff Scalar
+	one.setInt(1)
+	nMinus1.negate(&one)
+	x7f.setInt(0x7f)
+	x80.setInt(0x80)
+	xff.setInt(0x100)
+	scalars := []Scalar{zero, one, nMinus1, x7f, x80, xff}
+	for i := 0; i < 200; i++ {
+		var b [32]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			t.Fatal(err)
+		}
+		// Vary the magnitude so short and padded encodings both occur
+		for j := 0; j < i%32; j++ {
+			b[j] = 0
+		}
+		var s Scalar
+		s.setB32(b[:])
+		scalars = append(scalars, s)
+	}
+
+	var der [72]byte
+	for i := range scalars {
+		for j := range scalars {
+			sig := ECDSASignature{r: scalars[i], s: scalars[j]}
+			want := ECDSASignatureSerializeDER(der[:], &sig)
+			if got := PredictDERLength(&sig.r, &sig.s); got != want {
+				t.Fatalf("r %x s %x: predicted %d, serialized %d", sig.r.d, sig.s.d, got, want)
+			}
+		}
+	}
+}
+
+func TestECDSASignatureParseDER(t *testing.T) {
+	seckey, err := ECSeckeyGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate secret key: %v", err)
+	}
+
+	// Signatures, plus r and s values that hit every padding case
+	var sigs []ECDSASignature
+	msghash := make([]byte, 32)
+	for i := 0; i < 32; i++ {
+		if _, err := rand.Read(msghash); err != nil {
+			t.Fatal(err)
+		}
+		var sig ECDSASignature
+		if err := ECDSASign(&sig, msghash, seckey); err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		sigs = append(sigs, sig)
+	}
+	var zero, one, high, nMinus1 Scalar
+	one.setInt(1)
+	high.setInt(0x80)
+	nMinus1.negate(&one)
+	sigs = append(sigs,
+		ECDSASignature{r: one, s: nMinus1},
+		ECDSASignature{r: nMinus1, s: high},
+		ECDSASignature{r: zero, s: one},
+	)
+
+	var der [72]byte
+	for i := range sigs {
+		n := ECDSASignatureSerializeDER(der[:], &sigs[i])
+		var parsed ECDSASignature
+		if err := ECDSASignatureParseDER(&parsed, der[:n]); err != nil {
+			t.Fatalf("signature %d: failed to parse %x: %v", i, der[:n], err)
+		}
+		if !parsed.r.equal(&sigs[i].r) || !parsed.s.equal(&sigs[i].s) {
+			t.Errorf("signature %d: round trip mismatch", i)
+		}
+	}
+
+	order, _ := hex.DecodeString(testGroupOrderHex)
+	// In-range values, so a parser that dropped the extra leading byte
+	// would accept the 33-byte encodings below
+	r32 := "7c4d36070c1e1176b2960a1b0dd2319d547cf8eb0bdc9d2d256b3ee9daae347b"
+	s32 := "3c86963b3ff646a65ae42996e9664c747cc7e5e6f71c27109c692c1b56bbdceb"
+	invalid := []struct {
+		name string
+		der  string
+	}{
+		{"empty", ""},
+		{"wrong sequence tag", "3106020101020101"},
+		{"sequence length too long", "3007020101020101"},
+		{"sequence length too short", "3005020101020101"},
+		{"long-form sequence length", "308106020101020101"},
+		{"wrong r tag", "3006030101020101"},
+		{"wrong s tag", "3006020101030101"},
+		{"zero-length r", "30050200020101"},
+		{"zero-length s", "30050201010200"},
+		{"r length past end", "3006020201020101"},
+		{"s length past end", "3006020101020201"},
+		{"negative r", "3006020181020101"},
+		{"negative s", "3006020101020181"},
+		{"padded r", "300702020001020101"},
+		{"padded s", "300702010102020001"},
+		{"trailing data", "300602010102010100"},
+		{"trailing data in sequence", "30080201010201010000"},
+		{"missing s", "3003020101"},
+		{"r equal to order", "3026022100" + hex.EncodeToString(order) + "020101"},
+		{"r over 33 bytes", "30270222000000" + hex.EncodeToString(order)[2:] + "020101"},
+		{"33-byte r with non-zero leading byte", "3045022101" + r32 + "0220" + s32},
+		{"33-byte s with non-zero leading byte", "30450220" + r32 + "022101" + s32},
+	}
+	for _, c := range invalid {
+		b, err := hex.DecodeString(c.der)
+		if err != nil {
+			t.Fatalf("%s: bad test vector: %v", c.name, err)
+		}
+		var sig ECDSASignature
+		if err := ECDSASignatureParseDER(&sig, b); !errors.Is(err, ErrParse) {
+			t.Errorf("%s: expected ErrParse, got %v", c.name, err)
+		}
+	}
+}
+
+func TestSignatureSerialization(t *testing.T) {
+	scalar := func(h string) Scalar {
+		b, _ := hex.DecodeString(h)
+		var buf [32]byte
+		copy(buf[32-len(b):], b)
+		var s Scalar
+		s.setB32(buf[:])
+		return s
+	}
+	nMinus1 := "fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364140"
+
+	cases := []struct {
+		r, s string
+		want string
+	}{
+		{"01", "01", "3006020101020101"},
+		{"80", "7f", "30070202008002017f"},
+		{"00", "0100", "300702010002020100"},
+		{nMinus1, "01", "3026022100" + nMinus1 + "020101"},
+		{nMinus1, nMinus1, "3046022100" + nMinus1 + "022100" + nMinus1},
+	}
+	var der [72]byte
+	for _, c := range cases {
+		sig := ECDSASignature{r: scalar(c.r), s: scalar(c.s)}
+		n := ECDSASignatureSerializeDER(der[:], &sig)
+		if got := hex.EncodeToString(der[:n]); got != c.want {
+			t.Errorf("r=%s s=%s: got %s, want %s", c.r, c.s, got, c.want)
+		}
+	}
+
+	// Real signatures vary in length with the leading bytes of r and s, and
+	// always parse back to the same values
+	seckey, err := ECSeckeyGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate secret key: %v", err)
+	}
+	lengths := map[int]bool{}
+	msghash := make([]byte, 32)
+	for i := 0; i < 256; i++ {
+		if _, err := rand.Read(msghash); err != nil {
+			t.Fatal(err)
+		}
+		var sig ECDSASignature
+		if err := ECDSASign(&sig, msghash, seckey); err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		n := ECDSASignatureSerializeDER(der[:], &sig)
+		if n < 8 || n > 72 || der[0] != 0x30 || int(der[1]) != n-2 {
+			t.Fatalf("malformed DER %x", der[:n])
+		}
+		lengths[n] = true
+
+		var parsed ECDSASignature
+		if err := ECDSASignatureParseDER(&parsed, der[:n]); err != nil {
+			t.Fatalf("failed to parse %x: %v", der[:n], err)
+		}
+		if !parsed.r.equal(&sig.r) || !parsed.s.equal(&sig.s) {
+			t.Fatal("DER round trip mismatch")
+		}
+		if compact := sig.ToCompact(); bytes.Equal(der[:n], compact[:]) {
+			t.Fatal("DER output equals the compact encoding")
+		}
+	}
+	if len(lengths) < 2 {
+		t.Errorf("expected variable-length output, only saw %v", lengths)
+	}
+
+	// A buffer too small for the encoding is rejected
+	sig := ECDSASignature{r: scalar(nMinus1), s: scalar(nMinus1)}
+	if n := ECDSASignatureSerializeDER(der[:71], &sig); n != 0 {
+		t.Errorf("wrote %d bytes into a 71-byte buffer", n)
+	}
+}
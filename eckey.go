@@ -3,6 +3,9 @@ package p256k1
 import (
 	"crypto/rand"
 	"errors"
+	"fmt"
+	"io"
+	"unsafe"
 )
 
 // ECSeckeyVerify verifies that a 32-byte array is a valid secret key
@@ -34,13 +37,24 @@ func ECSeckeyNegate(seckey []byte) bool {
 // ECSeckeyGenerate generates a new random secret key
 func ECSeckeyGenerate() ([]byte, error) {
 	seckey := make([]byte, 32)
+	if err := seckeyGenerate(seckey, rand.Reader); err != nil {
+		return nil, err
+	}
+	return seckey, nil
+}
+
+// seckeyGenerate fills the 32-byte seckey with a uniformly random valid
+// secret key read from rng. Candidates that are zero or >= n are rejected and
+// redrawn; an error is returned only if rng fails.
+func seckeyGenerate(seckey []byte, rng io.Reader) error {
 	for {
-		if _, err := rand.Read(seckey); err != nil {
-			return nil, err
+		if _, err := io.ReadFull(rng, seckey); err != nil {
+			memclear(unsafe.Pointer(&seckey[0]), 32)
+			return err
 		}
-		
+
 		if ECSeckeyVerify(seckey) {
-			return seckey, nil
+			return nil
 		}
 	}
 }
@@ -60,7 +74,9 @@ func ECKeyPairGenerate() (seckey []byte, pubkey *PublicKey, err error) {
 	return seckey, pubkey, nil
 }
 
-// ECSeckeyTweakAdd adds a tweak to a secret key: seckey = seckey + tweak mod n
+// ECSeckeyTweakAdd adds a tweak to a secret key: seckey = seckey + tweak mod n.
+// As in libsecp256k1 a zero tweak is allowed and a tweak not below the group
+// order is rejected.
 func ECSeckeyTweakAdd(seckey []byte, tweak []byte) error {
 	if len(seckey) != 32 {
 		return errors.New("secret key must be 32 bytes")
@@ -71,9 +87,9 @@ func ECSeckeyTweakAdd(seckey []byte, tweak []byte) error {
 	
 	var sec, tw Scalar
 	if !sec.setB32Seckey(seckey) {
-		return errors.New("invalid secret key")
+		return ErrInvalidSeckey
 	}
-	if !tw.setB32Seckey(tweak) {
+	if tw.setB32(tweak) {
 		return errors.New("invalid tweak")
 	}
 	
@@ -82,7 +98,7 @@ func ECSeckeyTweakAdd(seckey []byte, tweak []byte) error {
 	
 	// Check if result is valid
 	if sec.isZero() {
-		return errors.New("resulting secret key is zero")
+		return fmt.Errorf("%w: resulting secret key is zero", ErrInvalidSeckey)
 	}
 	
 	// Get result
@@ -101,7 +117,7 @@ func ECSeckeyTweakMul(seckey []byte, tweak []byte) error {
 	
 	var sec, tw Scalar
 	if !sec.setB32Seckey(seckey) {
-		return errors.New("invalid secret key")
+		return ErrInvalidSeckey
 	}
 	if !tw.setB32Seckey(tweak) {
 		return errors.New("invalid tweak")
@@ -112,7 +128,7 @@ func ECSeckeyTweakMul(seckey []byte, tweak []byte) error {
 	
 	// Check if result is valid
 	if sec.isZero() {
-		return errors.New("resulting secret key is zero")
+		return fmt.Errorf("%w: resulting secret key is zero", ErrInvalidSeckey)
 	}
 	
 	// Get result
@@ -120,14 +136,18 @@ func ECSeckeyTweakMul(seckey []byte, tweak []byte) error {
 	return nil
 }
 
-// ECPubkeyTweakAdd adds a tweak to a public key: pubkey = pubkey + tweak*G
+// ECPubkeyTweakAdd adds a tweak to a public key: pubkey = pubkey + tweak*G.
+// It matches ECSeckeyTweakAdd followed by ECPubkeyCreate, and is what BIP32
+// non-hardened derivation needs. As in libsecp256k1 a zero tweak leaves the
+// key unchanged, a tweak not below the group order is rejected, and so is a
+// result at infinity; pubkey is only written on success.
 func ECPubkeyTweakAdd(pubkey *PublicKey, tweak []byte) error {
 	if len(tweak) != 32 {
 		return errors.New("tweak must be 32 bytes")
 	}
 	
 	var tw Scalar
-	if !tw.setB32Seckey(tweak) {
+	if tw.setB32(tweak) {
 		return errors.New("invalid tweak")
 	}
 	
@@ -135,7 +155,7 @@ func ECPubkeyTweakAdd(pubkey *PublicKey, tweak []byte) error {
 	var pubkeyPoint GroupElementAffine
 	pubkeyPoint.fromBytes(pubkey.data[:])
 	if pubkeyPoint.isInfinity() {
-		return errors.New("invalid public key")
+		return ErrInvalidPubkey
 	}
 	
 	// Compute tweak*G
@@ -152,7 +172,7 @@ func ECPubkeyTweakAdd(pubkey *PublicKey, tweak []byte) error {
 	
 	// Check if result is infinity
 	if result.isInfinity() {
-		return errors.New("resulting public key is infinity")
+		return fmt.Errorf("%w: resulting public key is infinity", ErrInvalidPubkey)
 	}
 	
 	// Convert back to affine and store
@@ -178,7 +198,7 @@ func ECPubkeyTweakMul(pubkey *PublicKey, tweak []byte) error {
 	var pubkeyPoint GroupElementAffine
 	pubkeyPoint.fromBytes(pubkey.data[:])
 	if pubkeyPoint.isInfinity() {
-		return errors.New("invalid public key")
+		return ErrInvalidPubkey
 	}
 	
 	// Multiply by tweak using binary method
@@ -207,7 +227,7 @@ func ECPubkeyTweakMul(pubkey *PublicKey, tweak []byte) error {
 	
 	// Check if result is infinity
 	if result.isInfinity() {
-		return errors.New("resulting public key is infinity")
+		return fmt.Errorf("%w: resulting public key is infinity", ErrInvalidPubkey)
 	}
 	
 	// Convert back to affine and store
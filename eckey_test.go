@@ -1,6 +1,10 @@
 package p256k1
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"testing"
 )
 
@@ -178,6 +182,129 @@ func TestECPubkeyTweakAdd(t *testing.T) {
 	}
 }
 
+func TestECPubkeyTweakAddMatchesSeckey(t *testing.T) {
+	order, _ := hex.DecodeString(testGroupOrderHex)
+	zero := make([]byte, 32)
+
+	for i := 0; i < 64; i++ {
+		seckey, pubkey, err := ECKeyPairGenerate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tweak := make([]byte, 32)
+		if i > 0 {
+			if _, err := rand.Read(tweak); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if ECSeckeyTweakAdd(seckey, tweak) != nil {
+			continue // tweak not below the order
+		}
+		var want PublicKey
+		if err := ECPubkeyCreate(&want, seckey); err != nil {
+			t.Fatal(err)
+		}
+		if err := ECPubkeyTweakAdd(pubkey, tweak); err != nil {
+			t.Fatalf("tweak %x: %v", tweak, err)
+		}
+		if ECPubkeyCmp(pubkey, &want) != 0 {
+			t.Fatalf("tweak %x: pubkey tweak differs from seckey tweak", tweak)
+		}
+	}
+
+	seckey, pubkey, err := ECKeyPairGenerate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := *pubkey
+
+	// A zero tweak is the identity
+	if err := ECPubkeyTweakAdd(pubkey, zero); err != nil || *pubkey != orig {
+		t.Errorf("zero tweak: err %v, key changed %v", err, *pubkey != orig)
+	}
+
+	// The order itself overflows and leaves the key untouched
+	if ECPubkeyTweakAdd(pubkey, order) == nil || *pubkey != orig {
+		t.Error("tweak equal to the order should be rejected")
+	}
+
+	// n - seckey sends the key to infinity
+	negated := append([]byte(nil), seckey...)
+	if !ECSeckeyNegate(negated) {
+		t.Fatal("negate failed")
+	}
+	if ECPubkeyTweakAdd(pubkey, negated) == nil || *pubkey != orig {
+		t.Error("tweak to infinity should be rejected")
+	}
+}
+
+func TestECSeckeyTweakConsistency(t *testing.T) {
+	tweaks := []struct {
+		name string
+		sec  func(seckey, tweak []byte) error
+		pub  func(pubkey *PublicKey, tweak []byte) error
+	}{
+		{"add", ECSeckeyTweakAdd, ECPubkeyTweakAdd},
+		{"mul", ECSeckeyTweakMul, ECPubkeyTweakMul},
+	}
+	tweak := make([]byte, 32)
+	for _, tw := range tweaks {
+		for i := 0; i < 32; i++ {
+			seckey, pubkey, err := ECKeyPairGenerate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := rand.Read(tweak); err != nil {
+				t.Fatal(err)
+			}
+			if !ECSeckeyVerify(tweak) {
+				continue
+			}
+			if err := tw.sec(seckey, tweak); err != nil {
+				t.Fatalf("%s: seckey tweak %x: %v", tw.name, tweak, err)
+			}
+			if err := tw.pub(pubkey, tweak); err != nil {
+				t.Fatalf("%s: pubkey tweak %x: %v", tw.name, tweak, err)
+			}
+			var want PublicKey
+			if err := ECPubkeyCreate(&want, seckey); err != nil {
+				t.Fatal(err)
+			}
+			if ECPubkeyCmp(pubkey, &want) != 0 {
+				t.Fatalf("%s: tweak %x: pubkey of tweaked seckey differs from tweaked pubkey", tw.name, tweak)
+			}
+		}
+	}
+
+	seckey, _, err := ECKeyPairGenerate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := append([]byte(nil), seckey...)
+	order, _ := hex.DecodeString(testGroupOrderHex)
+	zero := make([]byte, 32)
+
+	// Multiplying by zero is rejected, and failures leave the key untouched
+	if ECSeckeyTweakMul(seckey, zero) == nil || !bytes.Equal(seckey, orig) {
+		t.Error("zero tweak should be rejected by ECSeckeyTweakMul")
+	}
+	negated := append([]byte(nil), seckey...)
+	ECSeckeyNegate(negated)
+	if !errors.Is(ECSeckeyTweakAdd(seckey, negated), ErrInvalidSeckey) || !bytes.Equal(seckey, orig) {
+		t.Error("tweak add to zero should be rejected")
+	}
+
+	// The input seckey is validated
+	for _, bad := range [][]byte{zero, order} {
+		for _, tw := range tweaks {
+			sk := append([]byte(nil), bad...)
+			if !errors.Is(tw.sec(sk, orig), ErrInvalidSeckey) {
+				t.Errorf("%s: seckey %x should be rejected", tw.name, bad)
+			}
+		}
+	}
+}
+
 func TestECPubkeyTweakMul(t *testing.T) {
 	// Generate key pair
 	seckey, pubkey, err := ECKeyPairGenerate()
@@ -1,6 +1,7 @@
 package p256k1
 
 import (
+	"fmt"
 	"sync"
 )
 
@@ -113,11 +114,45 @@ func NewEcmultGenContext() *EcmultGenContext {
 	return ctx
 }
 
+// IsBuilt reports whether the context's precomputed table has been built.
+// A zero EcmultGenContext is not built.
+func (ctx *EcmultGenContext) IsBuilt() bool {
+	return ctx != nil && ctx.initialized
+}
+
+// Build computes the context's precomputed table if it has not been built
+// yet. It is not safe to call concurrently with other uses of ctx.
+func (ctx *EcmultGenContext) Build() {
+	if !ctx.initialized {
+		ctx.initGenContext()
+	}
+}
+
+// MustBuild builds ctx and returns it, for initializing a context in one
+// expression. It panics if ctx is nil.
+func (ctx *EcmultGenContext) MustBuild() *EcmultGenContext {
+	if ctx == nil {
+		panic("ecmult_gen context is nil")
+	}
+	ctx.Build()
+	return ctx
+}
+
+// EcmultGen computes r = n*G using ctx, returning ErrContextNotBuilt if the
+// table has not been built
+func (ctx *EcmultGenContext) EcmultGen(r *GroupElementJacobian, n *Scalar) error {
+	if !ctx.IsBuilt() {
+		return fmt.Errorf("%w: ecmult_gen table not built", ErrContextNotBuilt)
+	}
+	ctx.ecmultGen(r, n)
+	return nil
+}
+
 // ecmultGen computes r = n * G where G is the generator point
 // Uses 8-bit byte-based lookup table (like btcec) for maximum efficiency
 func (ctx *EcmultGenContext) ecmultGen(r *GroupElementJacobian, n *Scalar) {
-	if !ctx.initialized {
-		panic("ecmult_gen context not initialized")
+	if !ctx.IsBuilt() {
+		panic("ecmult_gen context not built; call Build or use NewEcmultGenContext")
 	}
 
 	// Handle zero scalar
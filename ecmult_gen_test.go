@@ -0,0 +1,118 @@
+package p256k1
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEcmultGenContextBuild(t *testing.T) {
+	var k Scalar
+	k.setInt(12345)
+	var want GroupElementJacobian
+	EcmultGen(&want, &k)
+	var wantAff GroupElementAffine
+	wantAff.setGEJ(&want)
+
+	// An unbuilt context is caught, both by the error-returning method and
+	// by the internal multiplication
+	ctx := &EcmultGenContext{}
+	if ctx.IsBuilt() {
+		t.Fatal("zero context reports built")
+	}
+	var r GroupElementJacobian
+	if err := ctx.EcmultGen(&r, &k); !errors.Is(err, ErrContextNotBuilt) {
+		t.Errorf("unbuilt EcmultGen: got %v, want ErrContextNotBuilt", err)
+	}
+	func() {
+		defer func() {
+			msg, _ := recover().(string)
+			if !strings.Contains(msg, "not built") {
+				t.Errorf("unbuilt ecmultGen: got panic %q", msg)
+			}
+		}()
+		ctx.ecmultGen(&r, &k)
+	}()
+	var nilCtx *EcmultGenContext
+	if err := nilCtx.EcmultGen(&r, &k); !errors.Is(err, ErrContextNotBuilt) {
+		t.Errorf("nil EcmultGen: got %v, want ErrContextNotBuilt", err)
+	}
+
+	// Building makes it usable, and building again is a no-op
+	ctx.Build()
+	ctx.Build()
+	if !ctx.IsBuilt() {
+		t.Fatal("context not built after Build")
+	}
+	if err := ctx.EcmultGen(&r, &k); err != nil {
+		t.Fatalf("built EcmultGen failed: %v", err)
+	}
+	var got GroupElementAffine
+	got.setGEJ(&r)
+	if !got.equal(&wantAff) {
+		t.Error("built context gave the wrong point")
+	}
+
+	if !(&EcmultGenContext{}).MustBuild().IsBuilt() {
+		t.Error("MustBuild did not build the context")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("MustBuild on a nil context did not panic")
+			}
+		}()
+		nilCtx.MustBuild()
+	}()
+}
+
+func TestEcmultGenEdgeScalars(t *testing.T) {
+	var zero, one, nMinus1 Scalar
+	one.setInt(1)
+	nMinus1.negate(&one)
+
+	var r GroupElementJacobian
+	var aff GroupElementAffine
+
+	EcmultGen(&r, &one)
+	aff.setGEJ(&r)
+	if !aff.equal(&Generator) {
+		t.Error("1*G is not the generator")
+	}
+
+	// n reduces to zero as a scalar, so n*G is 0*G
+	EcmultGen(&r, &zero)
+	if !r.isInfinity() {
+		t.Error("n*G is not infinity")
+	}
+
+	// (n-1)*G = -G, and adding G gives infinity
+	EcmultGen(&r, &nMinus1)
+	aff.setGEJ(&r)
+	var negG GroupElementAffine
+	negG.negate(&Generator)
+	if !aff.equal(&negG) {
+		t.Error("(n-1)*G is not -G")
+	}
+	r.addGE(&r, &Generator)
+	if !r.isInfinity() {
+		t.Error("(n-1)*G + G is not infinity")
+	}
+
+	// Every byte position of the table is used: 2^(8i)*G by doubling
+	var want GroupElementJacobian
+	want.setGE(&Generator)
+	for i := 0; i < 32; i++ {
+		var k Scalar
+		var b [32]byte
+		b[31-i] = 1
+		k.setB32(b[:])
+		EcmultGen(&r, &k)
+		if !jacobianEqual(&r, &want) {
+			t.Errorf("2^%d*G does not match repeated doubling", 8*i)
+		}
+		for j := 0; j < 8; j++ {
+			want.double(&want)
+		}
+	}
+}
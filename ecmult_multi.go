@@ -0,0 +1,130 @@
+package p256k1
+
+import "math/bits"
+
+// ecmultPippengerThreshold is the number of points from which EcmultMulti
+// switches from Strauss's algorithm to Pippenger's. Strauss costs about 60
+// additions per point; Pippenger with a c-bit window costs about 256/c
+// additions per point plus 2^c per window, which wins once the windows can
+// be wide. In BenchmarkEcmultMulti the two are level between 50 and 70
+// points; Strauss is 1.5x faster at 10 points and Pippenger 2.2x faster at
+// 1000.
+const ecmultPippengerThreshold = 64
+
+// EcmultMulti sets r = sum scalars[i]*points[i] in variable time, for
+// public inputs such as those of batch verification. Small inputs use
+// Strauss's algorithm and large ones Pippenger's bucket method. It panics
+// if the slices differ in length.
+func EcmultMulti(r *GroupElementJacobian, scalars []Scalar, points []GroupElementAffine) {
+	if len(points) >= ecmultPippengerThreshold {
+		ecmultPippengerVar(r, scalars, points)
+	} else {
+		EcmultStrauss(r, scalars, points)
+	}
+}
+
+// EcmultStrauss sets r = sum scalars[i]*points[i] in variable time using
+// Strauss's algorithm: each point gets a wNAF of its scalar and a table of
+// odd multiples, and a single chain of doublings is shared by all of them,
+// so n points cost about 256 doublings plus n*256/(windowA+1) additions
+// instead of n full multiplications. Zero scalars and points at infinity
+// are skipped. It panics if the slices differ in length.
+func EcmultStrauss(r *GroupElementJacobian, scalars []Scalar, points []GroupElementAffine) {
+	if len(scalars) != len(points) {
+		panic("scalars and points differ in length")
+	}
+
+	type straussState struct {
+		pre  [1 << (windowA - 1)]GroupElementJacobian
+		wnaf [257]int
+	}
+	states := make([]straussState, 0, len(points))
+	bits := 0
+	for i := range points {
+		if points[i].isInfinity() || scalars[i].isZero() {
+			continue
+		}
+		states = append(states, straussState{})
+		st := &states[len(states)-1]
+
+		// wNAF encodes -q if its top bit is set, so use -a in that case
+		var aj GroupElementJacobian
+		aj.setGE(&points[i])
+		if scalars[i].getBits(255, 1) == 1 {
+			aj.negate(&aj)
+		}
+		buildOddMultiples(&st.pre, &aj, windowA)
+		if n := scalars[i].wNAF(st.wnaf[:], windowA); n > bits {
+			bits = n
+		}
+	}
+
+	r.setInfinity()
+	for i := bits - 1; i >= 0; i-- {
+		r.double(r)
+		for j := range states {
+			n := states[j].wnaf[i]
+			if n == 0 {
+				continue
+			}
+			var pt GroupElementJacobian
+			if n > 0 {
+				pt = states[j].pre[(n-1)/2]
+			} else {
+				pt = states[j].pre[(-n-1)/2]
+				pt.y.negate(&pt.y, pt.y.magnitude)
+			}
+			r.addVar(r, &pt)
+		}
+	}
+}
+
+// pippengerWindow returns the bucket window width for n points, about
+// log2(n) - 2 as in libsecp256k1, clamped to [1, 14]
+func pippengerWindow(n int) uint {
+	c := bits.Len(uint(n)) - 2
+	return uint(min(max(c, 1), 14))
+}
+
+// ecmultPippengerVar sets r = sum scalars[i]*points[i] in variable time
+// using Pippenger's bucket method: the scalars are cut into c-bit windows,
+// and for each window every point is added into the bucket of its digit,
+// after which sum d*bucket[d] is formed with two running sums. It panics if
+// the slices differ in length.
+func ecmultPippengerVar(r *GroupElementJacobian, scalars []Scalar, points []GroupElementAffine) {
+	if len(scalars) != len(points) {
+		panic("scalars and points differ in length")
+	}
+
+	c := pippengerWindow(len(points))
+	buckets := make([]GroupElementJacobian, 1<<c-1) // buckets[d-1] for digit d
+	r.setInfinity()
+	for w := int((256+c-1)/c) - 1; w >= 0; w-- {
+		for i := uint(0); i < c; i++ {
+			r.double(r)
+		}
+
+		for j := range buckets {
+			buckets[j].setInfinity()
+		}
+		for i := range points {
+			if points[i].isInfinity() {
+				continue
+			}
+			if d := scalars[i].getBitsClamped(uint(w)*c, c); d != 0 {
+				buckets[d-1].addGE(&buckets[d-1], &points[i])
+			}
+		}
+
+		// running = sum of buckets[j..], so summing it over all j weights
+		// each bucket by its digit
+		var running, sum GroupElementJacobian
+		running.setInfinity()
+		sum.setInfinity()
+		for j := len(buckets) - 1; j >= 0; j-- {
+			running.addVar(&running, &buckets[j])
+			sum.addVar(&sum, &running)
+		}
+		r.addVar(r, &sum)
+	}
+}
@@ -0,0 +1,169 @@
+package p256k1
+
+import (
+	"crypto/rand"
+	"fmt"
+	"testing"
+)
+
+// ecmultMultiRef computes sum scalars[i]*points[i] one multiplication at a
+// time
+func ecmultMultiRef(r *GroupElementJacobian, scalars []Scalar, points []GroupElementAffine) {
+	r.setInfinity()
+	for i := range points {
+		if points[i].isInfinity() {
+			continue
+		}
+		var t GroupElementJacobian
+		EcmultConst(&t, &points[i], &scalars[i])
+		r.addVar(r, &t)
+	}
+}
+
+// randomMultiInput returns n random scalars and points
+func randomMultiInput(tb testing.TB, n int) ([]Scalar, []GroupElementAffine) {
+	scalars := make([]Scalar, n)
+	points := make([]GroupElementAffine, n)
+	var buf [64]byte
+	for i := 0; i < n; i++ {
+		if _, err := rand.Read(buf[:]); err != nil {
+			tb.Fatal(err)
+		}
+		scalars[i].setB32(buf[:32])
+		var k Scalar
+		k.setB32(buf[32:])
+		var pj GroupElementJacobian
+		EcmultGen(&pj, &k)
+		points[i].setGEJ(&pj)
+	}
+	return scalars, points
+}
+
+func TestEcmultStrauss(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 3, 7, 16, 33} {
+		scalars, points := randomMultiInput(t, n)
+		if n >= 3 {
+			// A zero scalar, a point at infinity, and a scalar with its
+			// top bit set
+			scalars[0] = Scalar{}
+			points[1].setInfinity()
+			scalars[2].negate(&ScalarOne)
+		}
+
+		var got, want GroupElementJacobian
+		EcmultStrauss(&got, scalars, points)
+		ecmultMultiRef(&want, scalars, points)
+		if !jacobianEqual(&got, &want) {
+			t.Errorf("n = %d: EcmultStrauss differs from the sum of single multiplications", n)
+		}
+	}
+
+	// Terms that cancel give infinity
+	scalars, points := randomMultiInput(t, 1)
+	scalars = append(scalars, Scalar{})
+	scalars[1].negate(&scalars[0])
+	points = append(points, points[0])
+	var r GroupElementJacobian
+	EcmultStrauss(&r, scalars, points)
+	if !r.isInfinity() {
+		t.Error("a*P + (-a)*P should be infinity")
+	}
+}
+
+func TestEcmultMulti(t *testing.T) {
+	impls := []struct {
+		name string
+		fn   func(r *GroupElementJacobian, scalars []Scalar, points []GroupElementAffine)
+	}{
+		{"EcmultMulti", EcmultMulti},
+		{"Pippenger", ecmultPippengerVar},
+	}
+	for _, n := range []int{0, 1, 2, 5, 17, 70, ecmultPippengerThreshold + 3} {
+		scalars, points := randomMultiInput(t, n)
+		if n >= 3 {
+			scalars[0] = Scalar{}
+			points[1].setInfinity()
+			scalars[2].negate(&ScalarOne)
+		}
+		var want GroupElementJacobian
+		ecmultMultiRef(&want, scalars, points)
+		for _, impl := range impls {
+			var got GroupElementJacobian
+			impl.fn(&got, scalars, points)
+			if !jacobianEqual(&got, &want) {
+				t.Errorf("%s, n = %d: differs from the sum of single multiplications", impl.name, n)
+			}
+		}
+	}
+}
+
+// ecmultMultiNaive computes sum scalars[i]*points[i] by bit-by-bit
+// double-and-add across all scalars at once
+func ecmultMultiNaive(r *GroupElementJacobian, scalars []Scalar, points []GroupElementAffine) {
+	r.setInfinity()
+	for bit := 255; bit >= 0; bit-- {
+		r.double(r)
+		for i := range points {
+			if !points[i].isInfinity() && scalars[i].getBits(uint(bit), 1) == 1 {
+				r.addGE(r, &points[i])
+			}
+		}
+	}
+}
+
+func TestEcmultStraussDifferential(t *testing.T) {
+	var lb [1]byte
+	for iter := 0; iter < 24; iter++ {
+		if _, err := rand.Read(lb[:]); err != nil {
+			t.Fatal(err)
+		}
+		// Lengths on both sides of the Pippenger threshold
+		n := int(lb[0]) % (2 * ecmultPippengerThreshold)
+		scalars, points := randomMultiInput(t, n)
+		if n > 0 {
+			scalars[0].negate(&scalars[0])
+		}
+
+		var strauss, multi, naive GroupElementJacobian
+		EcmultStrauss(&strauss, scalars, points)
+		EcmultMulti(&multi, scalars, points)
+		ecmultMultiNaive(&naive, scalars, points)
+		if !jacobianEqual(&strauss, &multi) || !jacobianEqual(&strauss, &naive) {
+			t.Fatalf("n = %d: EcmultStrauss, EcmultMulti and the naive sum disagree", n)
+		}
+	}
+}
+
+func BenchmarkEcmultMulti(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		scalars, points := randomMultiInput(b, n)
+		b.Run(fmt.Sprintf("Strauss-%d", n), func(b *testing.B) {
+			var r GroupElementJacobian
+			for i := 0; i < b.N; i++ {
+				EcmultStrauss(&r, scalars, points)
+			}
+		})
+		b.Run(fmt.Sprintf("Pippenger-%d", n), func(b *testing.B) {
+			var r GroupElementJacobian
+			for i := 0; i < b.N; i++ {
+				ecmultPippengerVar(&r, scalars, points)
+			}
+		})
+	}
+}
+
+func BenchmarkEcmultStrauss(b *testing.B) {
+	scalars, points := randomMultiInput(b, 64)
+	b.Run("Strauss", func(b *testing.B) {
+		var r GroupElementJacobian
+		for i := 0; i < b.N; i++ {
+			EcmultStrauss(&r, scalars, points)
+		}
+	})
+	b.Run("Separate", func(b *testing.B) {
+		var r GroupElementJacobian
+		for i := 0; i < b.N; i++ {
+			ecmultMultiRef(&r, scalars, points)
+		}
+	})
+}
@@ -0,0 +1,30 @@
+package p256k1
+
+import "errors"
+
+// Errors returned by the package. Functions may wrap these with more detail,
+// so compare with errors.Is rather than ==.
+var (
+	// ErrInvalidSeckey is returned for a secret key that is zero or not
+	// below the group order, or that a tweak would make zero
+	ErrInvalidSeckey = errors.New("invalid secret key")
+
+	// ErrInvalidPubkey is returned for a public key that is not a valid
+	// curve point, or that a tweak would make the point at infinity
+	ErrInvalidPubkey = errors.New("invalid public key")
+
+	// ErrInvalidSignature is returned for a signature whose values are out
+	// of range or from which no public key can be recovered
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// ErrContextNotBuilt is returned when a context is nil or was not
+	// created with the capabilities an operation needs
+	ErrContextNotBuilt = errors.New("context not built for this operation")
+
+	// ErrParse is returned when an encoding is malformed
+	ErrParse = errors.New("parse error")
+
+	// ErrSignatureSelfCheck is returned when a context created with
+	// ContextSignVerify produces a signature that fails to verify
+	ErrSignatureSelfCheck = errors.New("signature failed self-check")
+)
@@ -0,0 +1,59 @@
+package p256k1
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestErrorTypes(t *testing.T) {
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	zero := make([]byte, 32)
+	order := groupOrderBytes[:]
+	msg := make([]byte, 32)
+	var sig ECDSASignature
+	var pubkey PublicKey
+	var out [32]byte
+
+	// Tweaking a key by its own negation gives zero
+	negated := bytes.Clone(kp.Seckey())
+	ECSeckeyNegate(negated)
+
+	var compact ECDSASignatureCompact
+	var header [65]byte
+	header[0] = 40
+	var rsig RecoverableSignature
+	_, headerErr := ParseCompactSigWithHeader(&rsig, header[:])
+	_, keypairErr := KeyPairCreate(zero)
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"ECDSASign zero key", ECDSASign(&sig, msg, zero), ErrInvalidSeckey},
+		{"ECDSASign key = n", ECDSASign(&sig, msg, order), ErrInvalidSeckey},
+		{"ECPubkeyCreate zero key", ECPubkeyCreate(&pubkey, zero), ErrInvalidSeckey},
+		{"KeyPairCreate zero key", keypairErr, ErrInvalidSeckey},
+		{"ECDH zero key", ECDH(out[:], kp.Pubkey(), zero, nil), ErrInvalidSeckey},
+		{"ECDH infinity", ECDH(out[:], &PublicKey{}, kp.Seckey(), nil), ErrInvalidPubkey},
+		{"ECSeckeyTweakAdd to zero", ECSeckeyTweakAdd(bytes.Clone(kp.Seckey()), negated), ErrInvalidSeckey},
+		{"ECPubkeyTweakAdd infinity", ECPubkeyTweakAdd(&PublicKey{}, kp.Seckey()), ErrInvalidPubkey},
+		{"ECPubkeyParse empty", ECPubkeyParse(&pubkey, nil), ErrParse},
+		{"ECPubkeyParse bad prefix", ECPubkeyParse(&pubkey, append([]byte{0x05}, order...)), ErrParse},
+		{"ECDSASignatureParseDER", ECDSASignatureParseDER(&sig, []byte{0x30, 0x06, 0x02, 0x01, 0x01, 0x02, 0x01}), ErrParse},
+		{"ParseCompactSigWithHeader bad header", headerErr, ErrParse},
+		{"FromCompact zero", sig.FromCompact(&compact), ErrInvalidSignature},
+		{"ContextRandomize nil", ContextRandomize(nil, nil), ErrContextNotBuilt},
+		{"ECDSASignWithContext verify-only", ECDSASignWithContext(ContextCreate(ContextVerify), &sig, msg, kp.Seckey()), ErrContextNotBuilt},
+	}
+
+	for _, tc := range tests {
+		if !errors.Is(tc.err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, tc.err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package p256k1
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// EthereumV returns the Ethereum v value for a recovery id: 27 + recid for
+// legacy signatures (chainID 0), or chainID*2 + 35 + recid as defined by
+// EIP-155 for replay-protected transactions. Ethereum only defines recovery
+// ids 0 and 1, and chainID must leave room for v in a uint64.
+func EthereumV(recid int, chainID uint64) (uint64, error) {
+	if recid != 0 && recid != 1 {
+		return 0, errors.New("recovery id must be 0 or 1 for Ethereum")
+	}
+	if chainID == 0 {
+		return 27 + uint64(recid), nil
+	}
+	if chainID > (math.MaxUint64-36)/2 {
+		return 0, fmt.Errorf("chain id %d is too large", chainID)
+	}
+	return chainID*2 + 35 + uint64(recid), nil
+}
+
+// EthereumRecoveryID returns the recovery id encoded in an Ethereum v value.
+// It accepts the raw form {0, 1}, the legacy form {27, 28}, and, if chainID
+// is non-zero, the EIP-155 form {chainID*2 + 35, chainID*2 + 36}.
+func EthereumRecoveryID(v uint64, chainID uint64) (int, error) {
+	switch {
+	case v == 0 || v == 1:
+		return int(v), nil
+	case v == 27 || v == 28:
+		return int(v - 27), nil
+	case chainID != 0 && chainID <= (math.MaxUint64-36)/2 &&
+		(v == chainID*2+35 || v == chainID*2+36):
+		return int(v - chainID*2 - 35), nil
+	}
+	return 0, fmt.Errorf("%w: invalid v value %d", ErrParse, v)
+}
+
+// RecoverableSignatureSerializeEthereum encodes sig as the r || s || v form
+// used by Ethereum, with v as given by EthereumV for chainID (0 for a legacy
+// signature) in big-endian with no leading zeros. v fits in one byte, for a
+// 65-byte result, unless the chain id is 110 or above. Signatures whose
+// recovery id is not 0 or 1 cannot be encoded.
+func RecoverableSignatureSerializeEthereum(sig *RecoverableSignature, chainID uint64) ([]byte, error) {
+	v, err := EthereumV(sig.recid, chainID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 64, 72)
+	sig.r.getB32(out[:32])
+	sig.s.getB32(out[32:64])
+	var vb [8]byte
+	binary.BigEndian.PutUint64(vb[:], v)
+	n := bits.LeadingZeros64(v) / 8
+	return append(out, vb[n:]...), nil
+}
+
+// RecoverableSignatureParseEthereum parses an r || s || v signature, where
+// v is a big-endian integer of 1 to 8 bytes following the 64 bytes of r and
+// s, as written by RecoverableSignatureSerializeEthereum. v may be 0/1,
+// 27/28, or the EIP-155 values for chainID (pass 0 if the signature is not
+// chain-specific); see EthereumRecoveryID.
+func RecoverableSignatureParseEthereum(sig *RecoverableSignature, input []byte, chainID uint64) error {
+	if len(input) < 65 || len(input) > 72 {
+		return errors.New("signature must be 64 bytes followed by a 1 to 8 byte v")
+	}
+
+	var v uint64
+	for _, b := range input[64:] {
+		v = v<<8 | uint64(b)
+	}
+	recid, err := EthereumRecoveryID(v, chainID)
+	if err != nil {
+		return err
+	}
+
+	var compact ECDSASignatureCompact
+	copy(compact[:], input[:64])
+	return sig.FromCompact(&compact, recid)
+}
+
+// EthereumAddress returns the Ethereum address of a public key: the last 20
+// bytes of the Keccak-256 digest of its uncompressed X || Y encoding
+func EthereumAddress(pubkey *PublicKey) [20]byte {
+	var ser [65]byte
+	ECPubkeySerialize(ser[:], pubkey, ECUncompressed)
+	digest := Keccak256(ser[1:])
+
+	var addr [20]byte
+	copy(addr[:], digest[12:])
+	return addr
+}
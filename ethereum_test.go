@@ -0,0 +1,207 @@
+package p256k1
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"testing"
+)
+
+// EIP-155 example transaction: chain id 1, private key 0x4646...46
+const (
+	eip155SeckeyHex  = "4646464646464646464646464646464646464646464646464646464646464646"
+	eip155SigHashHex = "daf5a779ae972f972197303d7b574746c7ef83eadac0f2791ad23db92e4c8e53"
+	eip155RHex       = "28ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276"
+	eip155SHex       = "67cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"
+	eip155V          = 37
+)
+
+func TestEthereumV(t *testing.T) {
+	tests := []struct {
+		recid   int
+		chainID uint64
+		v       uint64
+	}{
+		{0, 0, 27},
+		{1, 0, 28},
+		{0, 1, 37},
+		{1, 1, 38},
+		{1, 137, 310},
+	}
+	for _, tc := range tests {
+		if v, err := EthereumV(tc.recid, tc.chainID); err != nil || v != tc.v {
+			t.Errorf("EthereumV(%d, %d) = %d, %v; want %d", tc.recid, tc.chainID, v, err, tc.v)
+		}
+		recid, err := EthereumRecoveryID(tc.v, tc.chainID)
+		if err != nil || recid != tc.recid {
+			t.Errorf("EthereumRecoveryID(%d, %d) = %d, %v; want %d", tc.v, tc.chainID, recid, err, tc.recid)
+		}
+	}
+
+	for _, v := range []uint64{0, 1} {
+		if recid, err := EthereumRecoveryID(v, 5); err != nil || recid != int(v) {
+			t.Errorf("raw v %d should give recid %d, got %d, %v", v, v, recid, err)
+		}
+	}
+	for _, v := range []uint64{2, 26, 29, 35, 37} {
+		if _, err := EthereumRecoveryID(v, 0); err == nil {
+			t.Errorf("expected error for v %d without a chain id", v)
+		}
+	}
+	if _, err := EthereumRecoveryID(37, 2); err == nil {
+		t.Error("expected error for a v value from a different chain")
+	}
+
+	for _, recid := range []int{-1, 2, 3} {
+		if _, err := EthereumV(recid, 1); err == nil {
+			t.Errorf("expected error for recid %d", recid)
+		}
+	}
+	if _, err := EthereumV(0, math.MaxUint64/2); err == nil {
+		t.Error("expected error for a chain id whose v overflows")
+	}
+	if _, err := EthereumRecoveryID(35, math.MaxUint64/2); err == nil {
+		t.Error("expected error for a v value from an overflowing chain id")
+	}
+}
+
+func TestRecoverableSignatureEthereum(t *testing.T) {
+	seckey, _ := hex.DecodeString(eip155SeckeyHex)
+	msg, _ := hex.DecodeString(eip155SigHashHex)
+	r, _ := hex.DecodeString(eip155RHex)
+	s, _ := hex.DecodeString(eip155SHex)
+
+	var want PublicKey
+	if err := ECPubkeyCreate(&want, seckey); err != nil {
+		t.Fatalf("failed to create pubkey: %v", err)
+	}
+
+	// The published signature recovers the signing key
+	input := append(append(append([]byte{}, r...), s...), eip155V)
+	var sig RecoverableSignature
+	if err := RecoverableSignatureParseEthereum(&sig, input, 1); err != nil {
+		t.Fatalf("failed to parse EIP-155 signature: %v", err)
+	}
+	var recovered PublicKey
+	if err := ECDSARecover(&recovered, &sig, msg); err != nil {
+		t.Fatalf("failed to recover: %v", err)
+	}
+	if ECPubkeyCmp(&recovered, &want) != 0 {
+		t.Fatal("recovered wrong public key from EIP-155 vector")
+	}
+
+	// Without the chain id the EIP-155 v value is rejected
+	if err := RecoverableSignatureParseEthereum(&sig, input, 0); err == nil {
+		t.Error("expected error parsing EIP-155 v without a chain id")
+	}
+
+	// Serializing the parsed EIP-155 signature for chain 1 restores it
+	out, err := RecoverableSignatureSerializeEthereum(&sig, 1)
+	if err != nil {
+		t.Fatalf("failed to serialize: %v", err)
+	}
+	if !bytes.Equal(out, input) {
+		t.Errorf("serialized EIP-155 signature %x, want %x", out, input)
+	}
+
+	var signed RecoverableSignature
+	if err := ECDSASignRecoverable(&signed, msg, seckey); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	// A legacy v is 27 + recid and recovers the key
+	out, err = RecoverableSignatureSerializeEthereum(&signed, 0)
+	if err != nil {
+		t.Fatalf("failed to serialize: %v", err)
+	}
+	if len(out) != 65 || out[64] != byte(27+signed.recid) {
+		t.Errorf("unexpected v %x for recid %d", out[64:], signed.recid)
+	}
+	if err := ECDSARecover(&recovered, &signed, msg); err != nil || ECPubkeyCmp(&recovered, &want) != 0 {
+		t.Errorf("fresh signature did not recover the signing key: %v", err)
+	}
+
+	// Legacy and raw v values round-trip
+	var parsed RecoverableSignature
+	if err := RecoverableSignatureParseEthereum(&parsed, out, 0); err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if parsed != signed {
+		t.Error("signature did not round-trip")
+	}
+	out[64] -= 27
+	if err := RecoverableSignatureParseEthereum(&parsed, out, 0); err != nil || parsed != signed {
+		t.Errorf("raw v did not round-trip: %v", err)
+	}
+
+	// Chain ids from 110 up need more than one byte of v
+	for _, chainID := range []uint64{110, 137, 11155111} {
+		out, err := RecoverableSignatureSerializeEthereum(&signed, chainID)
+		if err != nil {
+			t.Fatalf("failed to serialize for chain %d: %v", chainID, err)
+		}
+		if len(out) == 65 || out[64] == 0 {
+			t.Errorf("chain %d: v %x should be multi-byte big-endian", chainID, out[64:])
+		}
+		if err := RecoverableSignatureParseEthereum(&parsed, out, chainID); err != nil || parsed != signed {
+			t.Errorf("chain %d: signature did not round-trip: %v", chainID, err)
+		}
+		if err := RecoverableSignatureParseEthereum(&parsed, out, 1); err == nil {
+			t.Errorf("chain %d: v accepted for chain 1", chainID)
+		}
+	}
+
+	// Recovery ids above 1 have no Ethereum encoding
+	signed.recid = 2
+	if _, err := RecoverableSignatureSerializeEthereum(&signed, 0); err == nil {
+		t.Error("expected error serializing recid 2")
+	}
+	if err := RecoverableSignatureParseEthereum(&parsed, out[:64], 0); err == nil {
+		t.Error("expected error for short input")
+	}
+	if err := RecoverableSignatureParseEthereum(&parsed, append(out[:64], make([]byte, 9)...), 0); err == nil {
+		t.Error("expected error for a v longer than 8 bytes")
+	}
+}
+
+func TestEthereumAddress(t *testing.T) {
+	vectors := []struct {
+		seckey  string
+		address string
+	}{
+		{"0000000000000000000000000000000000000000000000000000000000000001", "7e5f4552091a69125d5dfcb7b8c2659029395bdf"},
+		{"0000000000000000000000000000000000000000000000000000000000000002", "2b5ad5c4795c026514f8317c7a215e218dccd6cf"},
+		{eip155SeckeyHex, "9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f"},
+	}
+
+	for _, v := range vectors {
+		seckey, _ := hex.DecodeString(v.seckey)
+		var pubkey PublicKey
+		if err := ECPubkeyCreate(&pubkey, seckey); err != nil {
+			t.Fatalf("failed to create pubkey: %v", err)
+		}
+		addr := EthereumAddress(&pubkey)
+		if hex.EncodeToString(addr[:]) != v.address {
+			t.Errorf("EthereumAddress(%s) = %x, want %s", v.seckey, addr, v.address)
+		}
+	}
+
+	// The sender of the EIP-155 example transaction is recovered from its
+	// signature
+	msg, _ := hex.DecodeString(eip155SigHashHex)
+	r, _ := hex.DecodeString(eip155RHex)
+	s, _ := hex.DecodeString(eip155SHex)
+	input := append(append(append([]byte{}, r...), s...), eip155V)
+	var sig RecoverableSignature
+	if err := RecoverableSignatureParseEthereum(&sig, input, 1); err != nil {
+		t.Fatalf("failed to parse EIP-155 signature: %v", err)
+	}
+	var recovered PublicKey
+	if err := ECDSARecover(&recovered, &sig, msg); err != nil {
+		t.Fatalf("failed to recover: %v", err)
+	}
+	addr := EthereumAddress(&recovered)
+	if hex.EncodeToString(addr[:]) != "9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f" {
+		t.Errorf("recovered sender %x", addr)
+	}
+}
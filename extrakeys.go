@@ -1,7 +1,11 @@
 package p256k1
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
 	"errors"
+	"fmt"
+	"io"
 	"unsafe"
 )
 
@@ -24,10 +28,10 @@ func XOnlyPubkeyParse(input32 []byte) (*XOnlyPubkey, error) {
 		return nil, errors.New("input must be 32 bytes")
 	}
 
-	// Create a point from X coordinate
+	// Create a point from X coordinate, which must be below p
 	var x FieldElement
-	if err := x.setB32(input32); err != nil {
-		return nil, errors.New("invalid X coordinate")
+	if !x.setB32Limit(input32) {
+		return nil, fmt.Errorf("%w: invalid X coordinate", ErrParse)
 	}
 
 	// Try to recover Y coordinate (check if point is on curve)
@@ -35,13 +39,13 @@ func XOnlyPubkeyParse(input32 []byte) (*XOnlyPubkey, error) {
 	if !point.setXOVar(&x, false) {
 		// Try with odd Y
 		if !point.setXOVar(&x, true) {
-			return nil, errors.New("X coordinate does not correspond to a valid point")
+			return nil, fmt.Errorf("%w: X coordinate does not correspond to a valid point", ErrInvalidPubkey)
 		}
 	}
 
 	// Verify point is valid
 	if !point.isValid() {
-		return nil, errors.New("invalid point")
+		return nil, ErrInvalidPubkey
 	}
 
 	// Create x-only pubkey (just X coordinate)
@@ -66,7 +70,7 @@ func XOnlyPubkeyFromPubkey(pubkey *PublicKey) (*XOnlyPubkey, int, error) {
 	var pt GroupElementAffine
 	pt.fromBytes(pubkey.data[:])
 	if pt.isInfinity() {
-		return nil, 0, errors.New("invalid public key")
+		return nil, 0, ErrInvalidPubkey
 	}
 
 	// Normalize Y coordinate
@@ -88,6 +92,156 @@ func XOnlyPubkeyFromPubkey(pubkey *PublicKey) (*XOnlyPubkey, int, error) {
 	return &xonly, parity, nil
 }
 
+// XOnlyPubkeyBatchFromSeckeys computes the x-only public keys and Y parities
+// for a batch of secret keys. The generator multiplications are converted to
+// affine with a single batch inversion instead of one inversion per key, which
+// is considerably cheaper for large batches. If ctx can sign, its blinded
+// generator multiplication is used. out and parities must be at least
+// len(seckeys) long. It returns len(seckeys) on success; if a secret key is
+// invalid nothing is written and the index of the first invalid key is
+// returned.
+func XOnlyPubkeyBatchFromSeckeys(ctx *Context, out []XOnlyPubkey, parities []int, seckeys [][]byte) int {
+	n := len(seckeys)
+	if len(out) < n || len(parities) < n {
+		panic("output slices shorter than seckeys")
+	}
+	if n == 0 {
+		return 0
+	}
+
+	points := make([]GroupElementJacobian, n)
+	zs := make([]FieldElement, n)
+	var sec Scalar
+	for i, seckey := range seckeys {
+		if len(seckey) != 32 || !sec.setB32Seckey(seckey) {
+			sec.clear()
+			for j := 0; j < i; j++ {
+				points[j].clear()
+			}
+			return i
+		}
+		if ctx.canSign() {
+			ctx.ecmultGen(&points[i], &sec)
+		} else {
+			EcmultGen(&points[i], &sec)
+		}
+		zs[i] = points[i].z
+	}
+	sec.clear()
+
+	zInv := make([]FieldElement, n)
+	batchInverse(zInv, zs)
+
+	var pt GroupElementAffine
+	var zi2, zi3 FieldElement
+	for i := range points {
+		zi2.sqr(&zInv[i])
+		zi3.mul(&zi2, &zInv[i])
+		pt.x.mul(&points[i].x, &zi2)
+		pt.y.mul(&points[i].y, &zi3)
+		pt.x.normalize()
+		pt.y.normalize()
+
+		parities[i] = boolToInt(pt.y.isOdd())
+		pt.x.getB32(out[i].data[:])
+		points[i].clear()
+	}
+
+	return n
+}
+
+// XOnlyPubkeyTweakAddBatch computes the taproot-style tweaked keys
+// P_i + t_i*G for a batch of x-only internal keys, where P_i is the even-Y
+// point with x-coordinate internal[i] and t_i is tweaks[i]. The x-only result
+// and its Y parity are written to outputs[i] and parities[i]. As in
+// XOnlyPubkeyBatchFromSeckeys, the sums are converted to affine with a single
+// batch inversion. A tweak may be zero but must be 32 bytes and below the
+// group order. If ctx can sign, its blinded generator multiplication is used.
+// outputs and parities must be at least len(internal) long and tweaks must
+// have the same length as internal. It returns len(internal) on success; if
+// an internal key or tweak is invalid, or a tweaked key is infinity, nothing
+// is written and the index of the first failing entry is returned.
+func XOnlyPubkeyTweakAddBatch(ctx *Context, outputs []XOnlyPubkey, parities []int, internal []*XOnlyPubkey, tweaks [][]byte) int {
+	n := len(internal)
+	if len(tweaks) != n {
+		panic("tweaks and internal keys differ in length")
+	}
+	if len(outputs) < n || len(parities) < n {
+		panic("output slices shorter than internal")
+	}
+	if n == 0 {
+		return 0
+	}
+
+	points := make([]GroupElementJacobian, n)
+	zs := make([]FieldElement, n)
+	var p GroupElementAffine
+	var x FieldElement
+	var tw Scalar
+	for i := range internal {
+		if internal[i] == nil || len(tweaks[i]) != 32 {
+			return i
+		}
+		if !x.setB32Limit(internal[i].data[:]) || !p.setXOVar(&x, false) {
+			return i
+		}
+		if tw.setB32(tweaks[i]) {
+			return i
+		}
+
+		if ctx.canSign() {
+			ctx.ecmultGen(&points[i], &tw)
+		} else {
+			EcmultGen(&points[i], &tw)
+		}
+		points[i].addGE(&points[i], &p)
+		if points[i].isInfinity() {
+			return i
+		}
+		zs[i] = points[i].z
+	}
+
+	zInv := make([]FieldElement, n)
+	batchInverse(zInv, zs)
+
+	var zi2, zi3 FieldElement
+	for i := range points {
+		zi2.sqr(&zInv[i])
+		zi3.mul(&zi2, &zInv[i])
+		p.x.mul(&points[i].x, &zi2)
+		p.y.mul(&points[i].y, &zi3)
+		p.x.normalize()
+		p.y.normalize()
+
+		parities[i] = boolToInt(p.y.isOdd())
+		p.x.getB32(outputs[i].data[:])
+	}
+
+	return n
+}
+
+// XOnlyHasParity reports whether the x-coordinate x32 has a curve point with
+// the requested Y parity. Whenever x is on the curve both parities exist, so
+// this is in effect a curve membership check for x; it returns false if x32
+// is not 32 bytes, not below p, or not the x-coordinate of any point.
+func XOnlyHasParity(x32 []byte, wantOdd bool) bool {
+	if len(x32) != 32 {
+		return false
+	}
+
+	var x FieldElement
+	if !x.setB32Limit(x32) {
+		return false
+	}
+
+	var p GroupElementAffine
+	if !p.setXOVar(&x, wantOdd) {
+		return false
+	}
+	p.y.normalize()
+	return p.y.isOdd() == wantOdd
+}
+
 // XOnlyPubkeyCmp compares two x-only public keys lexicographically
 // Returns: <0 if xonly1 < xonly2, >0 if xonly1 > xonly2, 0 if equal
 func XOnlyPubkeyCmp(xonly1, xonly2 *XOnlyPubkey) int {
@@ -113,7 +267,7 @@ func KeyPairCreate(seckey []byte) (*KeyPair, error) {
 	}
 
 	if !ECSeckeyVerify(seckey) {
-		return nil, errors.New("invalid secret key")
+		return nil, ErrInvalidSeckey
 	}
 
 	// Create public key
@@ -129,16 +283,24 @@ func KeyPairCreate(seckey []byte) (*KeyPair, error) {
 	return kp, nil
 }
 
-// KeyPairGenerate generates a new random keypair
+// KeyPairGenerate generates a new random keypair using crypto/rand. Invalid
+// secret keys (zero or >= n) are rejected and redrawn, so an error is only
+// returned if the random source fails.
 func KeyPairGenerate() (*KeyPair, error) {
-	seckey, pubkey, err := ECKeyPairGenerate()
-	if err != nil {
+	return keyPairGenerate(rand.Reader)
+}
+
+// keyPairGenerate generates a keypair from the given random source
+func keyPairGenerate(rng io.Reader) (*KeyPair, error) {
+	kp := &KeyPair{}
+	if err := seckeyGenerate(kp.seckey[:], rng); err != nil {
 		return nil, err
 	}
 
-	kp := &KeyPair{}
-	copy(kp.seckey[:], seckey)
-	kp.pubkey = *pubkey
+	if err := ECPubkeyCreate(&kp.pubkey, kp.seckey[:]); err != nil {
+		kp.Clear()
+		return nil, err
+	}
 
 	return kp, nil
 }
@@ -159,6 +321,69 @@ func (kp *KeyPair) XOnlyPubkey() (*XOnlyPubkey, error) {
 	return xonly, err
 }
 
+// Serialize returns the keypair as 96 bytes: the 32-byte secret key followed
+// by the 64-byte internal public key (X || Y), the layout libsecp256k1 uses
+// for secp256k1_keypair. The result contains the secret key and must be
+// protected and cleared like it.
+func (kp *KeyPair) Serialize() [96]byte {
+	var out [96]byte
+	copy(out[:32], kp.seckey[:])
+	copy(out[32:], kp.pubkey.data[:])
+	return out
+}
+
+// KeyPairParse parses a keypair serialized with Serialize into kp. It checks
+// that the secret key is valid and that the embedded public key is exactly
+// seckey*G, so a corrupted or tampered blob is rejected rather than producing
+// signatures under the wrong key. If ctx can sign, its blinded generator
+// multiplication is used. On failure kp is cleared and false is returned.
+func KeyPairParse(ctx *Context, kp *KeyPair, data []byte) bool {
+	if kp == nil {
+		return false
+	}
+	kp.Clear()
+	if len(data) != 96 {
+		return false
+	}
+
+	var sec Scalar
+	if !sec.setB32Seckey(data[:32]) {
+		return false
+	}
+	var pj GroupElementJacobian
+	if ctx.canSign() {
+		ctx.ecmultGen(&pj, &sec)
+	} else {
+		EcmultGen(&pj, &sec)
+	}
+	var p GroupElementAffine
+	p.setGEJ(&pj)
+	var want PublicKey
+	pubkeySave(&want, &p)
+	sec.clear()
+	pj.clear()
+	p.clear()
+
+	if subtle.ConstantTimeCompare(want.data[:], data[32:]) != 1 {
+		return false
+	}
+	copy(kp.seckey[:], data[:32])
+	kp.pubkey = want
+	return true
+}
+
+// MatchesSeckey reports whether seckey is the keypair's secret key, e.g. to
+// check a candidate key in an authentication flow. The comparison takes the
+// same time whichever bytes differ, so it does not leak how much of the key
+// was guessed. A seckey that is not 32 bytes, or is zero, never matches; the
+// latter keeps a cleared keypair from matching anything.
+func (kp *KeyPair) MatchesSeckey(seckey []byte) bool {
+	var zero [32]byte
+	match := subtle.ConstantTimeCompare(kp.seckey[:], seckey)
+	isZero := subtle.ConstantTimeCompare(seckey, zero[:])
+	return match&^isZero == 1
+}
+
 // Clear clears the keypair to prevent leaking sensitive information
 func (kp *KeyPair) Clear() {
 	memclear(unsafe.Pointer(&kp.seckey[0]), 32)
@@ -1,6 +1,11 @@
 package p256k1
 
 import (
+	"bytes"
+	"encoding/hex"
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"testing"
 )
 
@@ -29,6 +34,24 @@ func TestXOnlyPubkeyParse(t *testing.T) {
 	}
 }
 
+func TestXOnlyPubkeyParseRange(t *testing.T) {
+	// x = 1 is on the curve, so p + 1 would parse as it if reduced
+	one, _ := hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000001")
+	if _, err := XOnlyPubkeyParse(one); err != nil {
+		t.Fatalf("x = 1 should parse: %v", err)
+	}
+	for _, v := range []string{
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", // p
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc30", // p + 1
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+	} {
+		b, _ := hex.DecodeString(v)
+		if _, err := XOnlyPubkeyParse(b); err == nil {
+			t.Errorf("XOnlyPubkeyParse(%s) accepted x >= p", v)
+		}
+	}
+}
+
 func TestXOnlyPubkeyFromPubkey(t *testing.T) {
 	// Generate keypair
 	kp, err := KeyPairGenerate()
@@ -119,6 +142,120 @@ func TestKeyPairGenerate(t *testing.T) {
 	}
 }
 
+func TestKeyPairGenerateRejectsInvalid(t *testing.T) {
+	zero := make([]byte, 32)
+	order, _ := hex.DecodeString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141")
+	max := bytes.Repeat([]byte{0xff}, 32)
+	valid, _ := hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000003")
+
+	// Zero, n and 2^256-1 must be skipped until a valid scalar is drawn
+	var stream []byte
+	stream = append(stream, zero...)
+	stream = append(stream, order...)
+	stream = append(stream, max...)
+	stream = append(stream, valid...)
+
+	kp, err := keyPairGenerate(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	if !bytes.Equal(kp.Seckey(), valid) {
+		t.Errorf("secret key = %x, want %x", kp.Seckey(), valid)
+	}
+
+	// Running out of randomness is the only error
+	if _, err := keyPairGenerate(bytes.NewReader(stream[:64])); err == nil {
+		t.Error("expected error when the random source is exhausted")
+	}
+	if _, err := keyPairGenerate(failingReader{}); err == nil {
+		t.Error("expected error when the random source fails")
+	}
+}
+
+func TestKeyPairGenerateSigns(t *testing.T) {
+	msg := make([]byte, 32)
+	for i := 0; i < 32; i++ {
+		kp, err := KeyPairGenerate()
+		if err != nil {
+			t.Fatalf("failed to generate keypair: %v", err)
+		}
+		if !ECSeckeyVerify(kp.Seckey()) {
+			t.Fatal("generated secret key is invalid")
+		}
+		msg[0] = byte(i)
+
+		var ecdsaSig ECDSASignature
+		if err := ECDSASign(&ecdsaSig, msg, kp.Seckey()); err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		if !ECDSAVerify(&ecdsaSig, msg, kp.Pubkey()) {
+			t.Error("ECDSA signature from generated key failed to verify")
+		}
+
+		xonly, err := kp.XOnlyPubkey()
+		if err != nil {
+			t.Fatalf("failed to get x-only pubkey: %v", err)
+		}
+		var sig [64]byte
+		if err := SchnorrSign(sig[:], msg, kp, nil); err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		if !SchnorrVerify(sig[:], msg, xonly) {
+			t.Error("Schnorr signature from generated key failed to verify")
+		}
+	}
+}
+
+func TestKeyPairSerializeParse(t *testing.T) {
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	blob := kp.Serialize()
+	if !bytes.Equal(blob[:32], kp.Seckey()) || !bytes.Equal(blob[32:], kp.Pubkey().data[:]) {
+		t.Fatal("serialized keypair has the wrong layout")
+	}
+
+	signCtx := ContextCreate(ContextSign)
+	defer ContextDestroy(signCtx)
+	var seed [32]byte
+	seed[0] = 7
+	signCtx.SetBlindingSeed(seed)
+	for _, ctx := range []*Context{nil, signCtx} {
+		var parsed KeyPair
+		if !KeyPairParse(ctx, &parsed, blob[:]) {
+			t.Fatal("failed to parse a serialized keypair")
+		}
+		if parsed != *kp {
+			t.Error("parsed keypair differs from the original")
+		}
+	}
+
+	// Flipping any bit of the secret key or the public key is detected
+	for _, i := range []int{0, 31, 32, 63, 64, 95} {
+		tampered := blob
+		tampered[i] ^= 0x01
+		parsed := *kp
+		if KeyPairParse(nil, &parsed, tampered[:]) {
+			t.Errorf("tampered byte %d was accepted", i)
+		}
+		if parsed != (KeyPair{}) {
+			t.Errorf("tampered byte %d: keypair not cleared on failure", i)
+		}
+	}
+
+	// An invalid secret key and a wrong length are rejected
+	var zeroKey [96]byte
+	copy(zeroKey[32:], blob[32:])
+	var parsed KeyPair
+	if KeyPairParse(nil, &parsed, zeroKey[:]) {
+		t.Error("zero secret key was accepted")
+	}
+	if KeyPairParse(nil, &parsed, blob[:95]) {
+		t.Error("short blob was accepted")
+	}
+}
+
 func TestXOnlyPubkeyCmp(t *testing.T) {
 	kp1, err := KeyPairGenerate()
 	if err != nil {
@@ -151,3 +288,349 @@ func TestXOnlyPubkeyCmp(t *testing.T) {
 		t.Error("different x-only pubkeys should not compare equal")
 	}
 }
+
+func TestXOnlyPubkeyBatchFromSeckeys(t *testing.T) {
+	const n = 16
+	seckeys := make([][]byte, n)
+	for i := range seckeys {
+		kp, err := KeyPairGenerate()
+		if err != nil {
+			t.Fatalf("failed to generate keypair: %v", err)
+		}
+		seckeys[i] = kp.Seckey()
+	}
+
+	out := make([]XOnlyPubkey, n)
+	parities := make([]int, n)
+	if got := XOnlyPubkeyBatchFromSeckeys(nil, out, parities, seckeys); got != n {
+		t.Fatalf("expected %d keys, got %d", n, got)
+	}
+
+	for i, seckey := range seckeys {
+		var pubkey PublicKey
+		if err := ECPubkeyCreate(&pubkey, seckey); err != nil {
+			t.Fatalf("failed to create pubkey: %v", err)
+		}
+		want, parity, err := XOnlyPubkeyFromPubkey(&pubkey)
+		if err != nil {
+			t.Fatalf("failed to convert to x-only: %v", err)
+		}
+		if XOnlyPubkeyCmp(want, &out[i]) != 0 {
+			t.Errorf("key %d: x-only pubkey mismatch", i)
+		}
+		if parity != parities[i] {
+			t.Errorf("key %d: parity mismatch: got %d, want %d", i, parities[i], parity)
+		}
+	}
+
+	// A signing context gives the same keys through its blinded generator
+	ctx := ContextCreate(ContextSign)
+	defer ContextDestroy(ctx)
+	ctxOut := make([]XOnlyPubkey, n)
+	ctxParities := make([]int, n)
+	if got := XOnlyPubkeyBatchFromSeckeys(ctx, ctxOut, ctxParities, seckeys); got != n {
+		t.Fatalf("expected %d keys with a context, got %d", n, got)
+	}
+	for i := range out {
+		if XOnlyPubkeyCmp(&out[i], &ctxOut[i]) != 0 || parities[i] != ctxParities[i] {
+			t.Errorf("key %d: context result differs", i)
+		}
+	}
+
+	// An invalid key reports its index and writes nothing
+	seckeys[5] = make([]byte, 32)
+	out = make([]XOnlyPubkey, n)
+	if got := XOnlyPubkeyBatchFromSeckeys(nil, out, parities, seckeys); got != 5 {
+		t.Errorf("expected index 5 for invalid key, got %d", got)
+	}
+	if out[0] != (XOnlyPubkey{}) {
+		t.Error("output should be untouched when a key is invalid")
+	}
+}
+
+func BenchmarkXOnlyPubkeyBatchFromSeckeys(b *testing.B) {
+	const n = 64
+	seckeys := make([][]byte, n)
+	for i := range seckeys {
+		kp, err := KeyPairGenerate()
+		if err != nil {
+			b.Fatalf("failed to generate keypair: %v", err)
+		}
+		seckeys[i] = kp.Seckey()
+	}
+	out := make([]XOnlyPubkey, n)
+	parities := make([]int, n)
+
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			XOnlyPubkeyBatchFromSeckeys(nil, out, parities, seckeys)
+		}
+	})
+	b.Run("single", func(b *testing.B) {
+		var pubkey PublicKey
+		for i := 0; i < b.N; i++ {
+			for _, seckey := range seckeys {
+				ECPubkeyCreate(&pubkey, seckey)
+				XOnlyPubkeyFromPubkey(&pubkey)
+			}
+		}
+	})
+}
+
+func TestXOnlyHasParity(t *testing.T) {
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	xonly, err := kp.XOnlyPubkey()
+	if err != nil {
+		t.Fatalf("failed to get x-only pubkey: %v", err)
+	}
+	x := xonly.Serialize()
+	if !XOnlyHasParity(x[:], false) || !XOnlyHasParity(x[:], true) {
+		t.Error("an on-curve x should have both parities")
+	}
+
+	// x = 5 gives 125 + 7 = 132, which is not a square mod p
+	var offCurve [32]byte
+	offCurve[31] = 5
+	if XOnlyHasParity(offCurve[:], false) || XOnlyHasParity(offCurve[:], true) {
+		t.Error("an off-curve x should have neither parity")
+	}
+
+	// p itself reduces to 0 but is not a valid encoding
+	p, _ := hex.DecodeString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f")
+	if XOnlyHasParity(p, false) {
+		t.Error("x >= p should be rejected")
+	}
+	if XOnlyHasParity(x[:31], false) {
+		t.Error("short input should be rejected")
+	}
+}
+
+// xonlyTweakAddRef tweaks the even-Y lift of internal one key at a time with
+// ECPubkeyTweakAdd
+func xonlyTweakAddRef(t testing.TB, internal *XOnlyPubkey, tweak []byte) (*XOnlyPubkey, int) {
+	var pubkey PublicKey
+	if err := ECPubkeyParse(&pubkey, append([]byte{0x02}, internal.data[:]...)); err != nil {
+		t.Fatalf("failed to lift internal key: %v", err)
+	}
+	if err := ECPubkeyTweakAdd(&pubkey, tweak); err != nil {
+		t.Fatalf("failed to tweak: %v", err)
+	}
+	out, parity, err := XOnlyPubkeyFromPubkey(&pubkey)
+	if err != nil {
+		t.Fatalf("failed to convert to x-only: %v", err)
+	}
+	return out, parity
+}
+
+func TestXOnlyPubkeyTweakAddBatch(t *testing.T) {
+	const n = 16
+	internal := make([]*XOnlyPubkey, n)
+	tweaks := make([][]byte, n)
+	for i := range internal {
+		kp, err := KeyPairGenerate()
+		if err != nil {
+			t.Fatalf("failed to generate keypair: %v", err)
+		}
+		if internal[i], err = kp.XOnlyPubkey(); err != nil {
+			t.Fatalf("failed to get x-only pubkey: %v", err)
+		}
+		tk, err := KeyPairGenerate()
+		if err != nil {
+			t.Fatalf("failed to generate tweak: %v", err)
+		}
+		tweaks[i] = tk.Seckey()
+	}
+
+	for _, ctx := range []*Context{nil, ContextCreate(ContextSign)} {
+		outputs := make([]XOnlyPubkey, n)
+		parities := make([]int, n)
+		if got := XOnlyPubkeyTweakAddBatch(ctx, outputs, parities, internal, tweaks); got != n {
+			t.Fatalf("expected %d keys, got %d", n, got)
+		}
+		for i := range internal {
+			want, parity := xonlyTweakAddRef(t, internal[i], tweaks[i])
+			if XOnlyPubkeyCmp(want, &outputs[i]) != 0 {
+				t.Errorf("key %d: tweaked key mismatch", i)
+			}
+			if parity != parities[i] {
+				t.Errorf("key %d: parity mismatch: got %d, want %d", i, parities[i], parity)
+			}
+		}
+	}
+
+	// A zero tweak leaves the internal key, with even Y
+	outputs := make([]XOnlyPubkey, 1)
+	parities := []int{1}
+	if XOnlyPubkeyTweakAddBatch(nil, outputs, parities, internal[:1], [][]byte{make([]byte, 32)}) != 1 ||
+		outputs[0] != *internal[0] || parities[0] != 0 {
+		t.Error("zero tweak should return the internal key")
+	}
+
+	// Failures report the first bad index and write nothing
+	var offCurve XOnlyPubkey
+	offCurve.data[31] = 5
+	order, _ := hex.DecodeString(testGroupOrderHex)
+	var k Scalar
+	k.setInt(3)
+	var kg GroupElementJacobian
+	EcmultGen(&kg, &k)
+	var kgAff GroupElementAffine
+	kgAff.setGEJ(&kg)
+	kgAff.x.normalize()
+	kgAff.y.normalize()
+	var lifted XOnlyPubkey
+	kgAff.x.getB32(lifted.data[:])
+	// t = -k (or k if 3*G has odd Y) makes P + t*G infinity
+	if !kgAff.y.isOdd() {
+		k.negate(&k)
+	}
+	negK := k.Bytes()
+
+	cases := []struct {
+		name     string
+		internal *XOnlyPubkey
+		tweak    []byte
+	}{
+		{"off-curve key", &offCurve, tweaks[3]},
+		{"nil key", nil, tweaks[3]},
+		{"overflowing tweak", internal[3], order},
+		{"short tweak", internal[3], tweaks[3][:31]},
+		{"infinity", &lifted, negK[:]},
+	}
+	for _, c := range cases {
+		in := append([]*XOnlyPubkey(nil), internal...)
+		tw := append([][]byte(nil), tweaks...)
+		in[3], tw[3] = c.internal, c.tweak
+		outputs := make([]XOnlyPubkey, n)
+		parities := make([]int, n)
+		if got := XOnlyPubkeyTweakAddBatch(nil, outputs, parities, in, tw); got != 3 {
+			t.Errorf("%s: expected index 3, got %d", c.name, got)
+		}
+		if outputs[0] != (XOnlyPubkey{}) {
+			t.Errorf("%s: output should be untouched", c.name)
+		}
+	}
+}
+
+func BenchmarkXOnlyPubkeyTweakAddBatch(b *testing.B) {
+	const n = 64
+	internal := make([]*XOnlyPubkey, n)
+	tweaks := make([][]byte, n)
+	for i := range internal {
+		kp, err := KeyPairGenerate()
+		if err != nil {
+			b.Fatalf("failed to generate keypair: %v", err)
+		}
+		if internal[i], err = kp.XOnlyPubkey(); err != nil {
+			b.Fatalf("failed to get x-only pubkey: %v", err)
+		}
+		tk, err := KeyPairGenerate()
+		if err != nil {
+			b.Fatalf("failed to generate tweak: %v", err)
+		}
+		tweaks[i] = tk.Seckey()
+	}
+	outputs := make([]XOnlyPubkey, n)
+	parities := make([]int, n)
+
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			XOnlyPubkeyTweakAddBatch(nil, outputs, parities, internal, tweaks)
+		}
+	})
+	b.Run("single", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := range internal {
+				xonlyTweakAddRef(b, internal[j], tweaks[j])
+			}
+		}
+	})
+}
+
+func TestKeyPairMatchesSeckey(t *testing.T) {
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	seckey := kp.Seckey()
+	if !kp.MatchesSeckey(seckey) {
+		t.Error("own secret key does not match")
+	}
+
+	other, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	if kp.MatchesSeckey(other.Seckey()) {
+		t.Error("another secret key matches")
+	}
+	for _, i := range []int{0, 15, 31} {
+		flipped := bytes.Clone(seckey)
+		flipped[i] ^= 1
+		if kp.MatchesSeckey(flipped) {
+			t.Errorf("key with byte %d flipped matches", i)
+		}
+	}
+	if kp.MatchesSeckey(seckey[:31]) || kp.MatchesSeckey(append(bytes.Clone(seckey), 0)) || kp.MatchesSeckey(nil) {
+		t.Error("wrong-length key matches")
+	}
+
+	kp.Clear()
+	if kp.MatchesSeckey(make([]byte, 32)) {
+		t.Error("zero key matches a cleared keypair")
+	}
+	if kp.MatchesSeckey(seckey) {
+		t.Error("old key matches a cleared keypair")
+	}
+}
+
+// TestKeyPairMatchesSeckeyConstantTime checks structurally that
+// MatchesSeckey has no data-dependent control flow: no branches or loops,
+// no short-circuit operators, and the bytes are compared only with
+// crypto/subtle
+func TestKeyPairMatchesSeckeyConstantTime(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "extrakeys.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fn *ast.FuncDecl
+	for _, decl := range f.Decls {
+		if d, ok := decl.(*ast.FuncDecl); ok && d.Name.Name == "MatchesSeckey" {
+			fn = d
+		}
+	}
+	if fn == nil {
+		t.Fatal("MatchesSeckey not found")
+	}
+
+	usesSubtle := false
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt, *ast.ForStmt, *ast.RangeStmt:
+			t.Errorf("branch at %s", fset.Position(n.Pos()))
+		case *ast.IndexExpr:
+			t.Errorf("byte access outside crypto/subtle at %s", fset.Position(n.Pos()))
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				t.Errorf("short-circuit operator at %s", fset.Position(n.Pos()))
+			}
+		case *ast.SelectorExpr:
+			if pkg, ok := n.X.(*ast.Ident); ok {
+				switch pkg.Name {
+				case "subtle":
+					usesSubtle = true
+				case "bytes":
+					t.Errorf("bytes.%s at %s", n.Sel.Name, fset.Position(n.Pos()))
+				}
+			}
+		}
+		return true
+	})
+	if !usesSubtle {
+		t.Error("MatchesSeckey does not use crypto/subtle")
+	}
+}
@@ -96,6 +96,21 @@ func (r *FieldElement) setB32(b []byte) error {
 	return nil
 }
 
+// setB32Limit sets a field element from a 32-byte big-endian array and
+// reports whether the value is below p. Unlike setB32 it does not reduce: on
+// success the result is normalized as is, and on failure r must not be used.
+func (r *FieldElement) setB32Limit(b []byte) bool {
+	if r.setB32(b) != nil {
+		return false
+	}
+	if r.n[4] == fieldModulusLimb4 && (r.n[3]&r.n[2]&r.n[1]) == fieldModulusLimb1 &&
+		r.n[0] >= fieldModulusLimb0 {
+		return false
+	}
+	r.normalized = true
+	return true
+}
+
 // getB32 converts a field element to a 32-byte big-endian array
 func (r *FieldElement) getB32(b []byte) {
 	if len(b) != 32 {
@@ -198,6 +213,41 @@ func (r *FieldElement) normalizeWeak() {
 	r.magnitude = 1
 }
 
+// reduceOnce performs a single conditional subtraction of p and reports
+// whether it occurred. The input must have magnitude 1 (e.g. straight from
+// setB32 or after normalizeWeak), in which case it is below 2p and the
+// result is fully normalized. This is cheaper than normalize when the limbs
+// are already weakly normalized.
+func (r *FieldElement) reduceOnce() bool {
+	if r.magnitude > 1 {
+		panic("field element magnitude must be 1")
+	}
+
+	t0, t1, t2, t3, t4 := r.n[0], r.n[1], r.n[2], r.n[3], r.n[4]
+
+	// r >= p if it carried into bit 256 or every limb is at its maximum
+	m := t1 & t2 & t3
+	x := (t4 >> 48) | uint64(boolToInt(t4 == limb4Max && m == limb0Max && t0 >= fieldModulusLimb0))
+
+	// Subtract p by adding 2^256 - p and dropping bit 256
+	t0 += x * fieldReductionConstant
+	t1 += t0 >> 52
+	t0 &= limb0Max
+	t2 += t1 >> 52
+	t1 &= limb0Max
+	t3 += t2 >> 52
+	t2 &= limb0Max
+	t4 += t3 >> 52
+	t3 &= limb0Max
+	t4 &= limb4Max
+
+	r.n[0], r.n[1], r.n[2], r.n[3], r.n[4] = t0, t1, t2, t3, t4
+	r.magnitude = 1
+	r.normalized = true
+
+	return x != 0
+}
+
 // reduce performs modular reduction (simplified implementation)
 func (r *FieldElement) reduce() {
 	// For now, just normalize to ensure proper representation
@@ -212,6 +262,15 @@ func (r *FieldElement) isZero() bool {
 	return r.n[0] == 0 && r.n[1] == 0 && r.n[2] == 0 && r.n[3] == 0 && r.n[4] == 0
 }
 
+// isOne returns true if the field element is one. Unlike isZero it does
+// not require r to be normalized: a normalized copy is compared, in constant
+// time.
+func (r *FieldElement) isOne() bool {
+	t := *r
+	t.normalize()
+	return t.equal(&FieldElementOne)
+}
+
 // isOdd returns true if the field element is odd
 func (r *FieldElement) isOdd() bool {
 	if !r.normalized {
@@ -230,6 +289,42 @@ func (r *FieldElement) normalizesToZeroVar() bool {
 	return t.isZero()
 }
 
+// normalizesToZero returns 1 if the field element normalizes to zero (is 0
+// or p) and 0 otherwise, in constant time. This follows the C
+// secp256k1_fe_normalizes_to_zero implementation.
+func (r *FieldElement) normalizesToZero() int {
+	t0, t1, t2, t3, t4 := r.n[0], r.n[1], r.n[2], r.n[3], r.n[4]
+
+	// Reduce t4 at the start so there will be at most a single carry from the first pass
+	x := t4 >> 48
+	t4 &= limb4Max
+
+	// z0 tracks a possible raw value of 0, and z1 a possible raw value of P
+	t0 += x * fieldReductionConstant
+	t1 += t0 >> 52
+	t0 &= limb0Max
+	z0 := t0
+	z1 := t0 ^ 0x1000003D0
+	t2 += t1 >> 52
+	t1 &= limb0Max
+	z0 |= t1
+	z1 &= t1
+	t3 += t2 >> 52
+	t2 &= limb0Max
+	z0 |= t2
+	z1 &= t2
+	t4 += t3 >> 52
+	t3 &= limb0Max
+	z0 |= t3
+	z1 &= t3
+	z0 |= t4
+	z1 &= t4 ^ 0xF000000000000
+
+	isZero := ((z0 | -z0) >> 63) ^ 1
+	isP := (((z1 ^ limb0Max) | -(z1 ^ limb0Max)) >> 63) ^ 1
+	return int(isZero | isP)
+}
+
 // equal returns true if two field elements are equal
 func (r *FieldElement) equal(a *FieldElement) bool {
 	// Both must be normalized for comparison
@@ -324,19 +419,19 @@ func (r *FieldElement) mulInt(a int) {
 }
 
 // cmov conditionally moves a field element. If flag is true, r = a; otherwise r is unchanged.
+// The metadata is masked as well, so nothing branches on flag.
 func (r *FieldElement) cmov(a *FieldElement, flag int) {
-	mask := uint64(-(int64(flag) & 1))
+	mask := ctMask(flag)
 	r.n[0] ^= mask & (r.n[0] ^ a.n[0])
 	r.n[1] ^= mask & (r.n[1] ^ a.n[1])
 	r.n[2] ^= mask & (r.n[2] ^ a.n[2])
 	r.n[3] ^= mask & (r.n[3] ^ a.n[3])
 	r.n[4] ^= mask & (r.n[4] ^ a.n[4])
 
-	// Update metadata conditionally
-	if flag != 0 {
-		r.magnitude = a.magnitude
-		r.normalized = a.normalized
-	}
+	r.magnitude ^= int(mask) & (r.magnitude ^ a.magnitude)
+	norm := ctMask(ctBool(r.normalized))
+	norm ^= mask & (norm ^ ctMask(ctBool(a.normalized)))
+	r.normalized = norm != 0
 }
 
 // toStorage converts a field element to storage format
@@ -381,6 +476,27 @@ func boolToInt(b bool) int {
 	return 0
 }
 
+// ctMask returns all ones if the low bit of flag is set and zero otherwise,
+// without branching. Use it to turn a 0/1 flag into a mask for cmov-style
+// selects rather than converting by hand.
+func ctMask(flag int) uint64 {
+	return -uint64(flag & 1)
+}
+
+// ctBool returns 1 if b is true and 0 otherwise, without branching. It
+// reads the byte backing b, which Go stores as 0 or 1, where boolToInt would
+// compile to a conditional. Use it with ctMask for secret booleans such as
+// the infinity and normalized flags.
+func ctBool(b bool) int {
+	return int(*(*uint8)(unsafe.Pointer(&b)))
+}
+
+// ctEq returns all ones if a == b and zero otherwise, without branching
+func ctEq(a, b uint64) uint64 {
+	x := a ^ b
+	return ((x | -x) >> 63) - 1
+}
+
 // batchInverse computes the inverses of a slice of FieldElements.
 func batchInverse(out []FieldElement, a []FieldElement) {
 	n := len(a)
@@ -621,6 +737,32 @@ func fieldNormalizeWeak(r *FieldElement) {
 	r.normalized = false
 }
 
+// fieldNormalizeBatch normalizes every element of fes in place
+func fieldNormalizeBatch(fes []FieldElement) {
+	for i := range fes {
+		fieldNormalize(&fes[i])
+	}
+}
+
+// feEqualBatch reports whether a[i] == b[i] for every i. The inputs need not
+// be normalized and are not modified. Slices of different lengths are never
+// equal. All pairs are compared regardless of earlier mismatches.
+func feEqualBatch(a, b []FieldElement) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	var diff uint64
+	for i := range a {
+		x, y := a[i], b[i]
+		fieldNormalize(&x)
+		fieldNormalize(&y)
+		diff |= (x.n[0] ^ y.n[0]) | (x.n[1] ^ y.n[1]) | (x.n[2] ^ y.n[2]) |
+			(x.n[3] ^ y.n[3]) | (x.n[4] ^ y.n[4])
+	}
+	return diff == 0
+}
+
 // fieldAdd adds two field elements
 func fieldAdd(r, a *FieldElement) {
 	r.n[0] += a.n[0]
@@ -1,6 +1,9 @@
 package p256k1
 
-import "math/bits"
+import (
+	"encoding/binary"
+	"math/bits"
+)
 
 // uint128 represents a 128-bit unsigned integer for field arithmetic
 type uint128 struct {
@@ -54,10 +57,60 @@ func (u uint128) rshift(n uint) uint128 {
 	}
 }
 
+// Uint128 is an unsigned 128-bit integer for implementing modular arithmetic
+// with 64-bit limbs, exposing the multiply-accumulate helpers the field code
+// uses internally. All operations wrap modulo 2^128.
+type Uint128 struct {
+	v uint128
+}
+
+// NewUint128 returns the value hi*2^64 + lo
+func NewUint128(hi, lo uint64) Uint128 {
+	return Uint128{uint128{high: hi, low: lo}}
+}
+
+// Uint128Mul64 returns the full 128-bit product a*b
+func Uint128Mul64(a, b uint64) Uint128 {
+	return Uint128{mulU64ToU128(a, b)}
+}
+
+// AddMul returns u + a*b
+func (u Uint128) AddMul(a, b uint64) Uint128 {
+	return Uint128{addMulU128(u.v, a, b)}
+}
+
+// Add64 returns u + a
+func (u Uint128) Add64(a uint64) Uint128 {
+	return Uint128{addU128(u.v, a)}
+}
+
+// Rshift returns u >> n
+func (u Uint128) Rshift(n uint) Uint128 {
+	if n >= 128 {
+		return Uint128{}
+	}
+	if n == 0 {
+		return u
+	}
+	return Uint128{u.v.rshift(n)}
+}
+
+// Lo returns the low 64 bits of u
+func (u Uint128) Lo() uint64 {
+	return u.v.lo()
+}
+
+// Hi returns the high 64 bits of u
+func (u Uint128) Hi() uint64 {
+	return u.v.hi()
+}
+
 // mul multiplies two field elements: r = a * b
 // This implementation follows the C secp256k1_fe_mul_inner algorithm
 // Optimized: avoid copies when magnitude is low enough
 func (r *FieldElement) mul(a, b *FieldElement) {
+	countFieldMul()
+
 	// Use pointers directly if magnitude is low enough (optimization)
 	var aNorm, bNorm *FieldElement
 	var aTemp, bTemp FieldElement
@@ -193,108 +246,95 @@ func (r *FieldElement) mul(a, b *FieldElement) {
 	r.normalized = false
 }
 
-// reduceFromWide reduces a 520-bit (10 limb) value modulo the field prime
-func (r *FieldElement) reduceFromWide(t [10]uint64) {
-	// The field prime is p = 2^256 - 2^32 - 977 = 0xFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F
-	// We use the fact that 2^256 ≡ 2^32 + 977 (mod p)
-	
-	// First, handle the upper limbs (t[5] through t[9])
-	// Each represents a multiple of 2^(52*i) where i >= 5
-	
-	// Reduction constant for secp256k1: 2^32 + 977 = 0x1000003D1
-	const M = uint64(0x1000003D1)
-	
-	// Start from the highest limb and work down
-	for i := 9; i >= 5; i-- {
-		if t[i] == 0 {
-			continue
+// mulWide multiplies two field elements via the full 512-bit product and
+// reduceFromWide: r = a * b. It is a slow, straightforward reference for
+// checking the interleaved reduction in mul and is not used on any hot path.
+func (r *FieldElement) mulWide(a, b *FieldElement) {
+	var an, bn [32]byte
+	aTemp, bTemp := *a, *b
+	aTemp.normalize()
+	bTemp.normalize()
+	aTemp.getB32(an[:])
+	bTemp.getB32(bn[:])
+
+	// Little-endian 64-bit words of the fully reduced inputs
+	var aw, bw [4]uint64
+	for i := 0; i < 4; i++ {
+		aw[i] = binary.BigEndian.Uint64(an[24-8*i:])
+		bw[i] = binary.BigEndian.Uint64(bn[24-8*i:])
+	}
+
+	// Schoolbook 4x4 word product
+	var t [10]uint64
+	for i := 0; i < 4; i++ {
+		var carry uint64
+		for j := 0; j < 4; j++ {
+			hi, lo := bits.Mul64(aw[i], bw[j])
+			var c uint64
+			lo, c = bits.Add64(lo, t[i+j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, carry, 0)
+			hi += c
+			t[i+j] = lo
+			carry = hi
 		}
-		
-		// t[i] * 2^(52*i) ≡ t[i] * 2^(52*(i-5)) * 2^(52*5) ≡ t[i] * 2^(52*(i-5)) * 2^260
-		// Since 2^256 ≡ M (mod p), we have 2^260 ≡ 2^4 * M ≡ 16 * M (mod p)
-		
-		// For i=5: 2^260 ≡ 16*M (mod p)
-		// For i=6: 2^312 ≡ 2^52 * 16*M ≡ 2^56 * M (mod p)
-		// etc.
-		
-		shift := uint(52 * (i - 5) + 4) // Additional 4 bits for the 16 factor
-		
-		// Multiply t[i] by the appropriate power of M
+		t[i+4] = carry
+	}
+
+	r.reduceFromWide(t)
+}
+
+// reduceFromWide sets r to the value of t modulo the field prime, where t
+// holds a 640-bit value as little-endian 64-bit words. The result is fully
+// normalized.
+func (r *FieldElement) reduceFromWide(t [10]uint64) {
+	// 2^256 = 2^32 + 977 (mod p), so the words above 256 bits fold down as
+	// lo + hi*C. Each pass shrinks the value by about 223 bits.
+	const C = uint64(0x1000003D1)
+
+	for t[4]|t[5]|t[6]|t[7]|t[8]|t[9] != 0 {
+		var next [10]uint64
+		copy(next[:4], t[:4])
+
 		var carry uint64
-		if shift < 64 {
-			// Simple case: can multiply directly
-			factor := M << shift
-			hi, lo := bits.Mul64(t[i], factor)
-			
-			// Add to appropriate position
-			pos := 0
-			t[pos], carry = bits.Add64(t[pos], lo, 0)
-			if pos+1 < 10 {
-				t[pos+1], carry = bits.Add64(t[pos+1], hi, carry)
-			}
-			
-			// Propagate carry
-			for j := pos + 2; j < 10 && carry != 0; j++ {
-				t[j], carry = bits.Add64(t[j], 0, carry)
-			}
-		} else {
-			// Need to handle larger shifts by distributing across limbs
-			hi, lo := bits.Mul64(t[i], M)
-			limbShift := shift / 52
-			bitShift := shift % 52
-			
-			if bitShift == 0 {
-				// Aligned to limb boundary
-				if limbShift < 10 {
-					t[limbShift], carry = bits.Add64(t[limbShift], lo, 0)
-					if limbShift+1 < 10 {
-						t[limbShift+1], carry = bits.Add64(t[limbShift+1], hi, carry)
-					}
-				}
-			} else {
-				// Need to split across limbs
-				loShifted := lo << bitShift
-				hiShifted := (lo >> (64 - bitShift)) | (hi << bitShift)
-				
-				if limbShift < 10 {
-					t[limbShift], carry = bits.Add64(t[limbShift], loShifted, 0)
-					if limbShift+1 < 10 {
-						t[limbShift+1], carry = bits.Add64(t[limbShift+1], hiShifted, carry)
-					}
-				}
-			}
-			
-			// Propagate any remaining carry
-			for j := int(limbShift) + 2; j < 10 && carry != 0; j++ {
-				t[j], carry = bits.Add64(t[j], 0, carry)
+		for i := 4; i < 10; i++ {
+			hi, lo := bits.Mul64(t[i], C)
+			var c uint64
+			lo, c = bits.Add64(lo, carry, 0)
+			hi += c
+			carry = hi
+			var c2 uint64
+			next[i-4], c2 = bits.Add64(next[i-4], lo, 0)
+			for j := i - 3; c2 != 0 && j < 10; j++ {
+				next[j], c2 = bits.Add64(next[j], 0, c2)
 			}
 		}
-		
-		t[i] = 0 // Clear the processed limb
+		for j := 6; carry != 0 && j < 10; j++ {
+			var c uint64
+			next[j], c = bits.Add64(next[j], carry, 0)
+			carry = c
+		}
+		t = next
 	}
-	
-	// Now we have a value in t[0..4] that may still be >= p
-	// Convert to 5x52 format and normalize
+
+	// The value is now below 2^256, so at most one subtraction of p remains
 	r.n[0] = t[0] & limb0Max
 	r.n[1] = ((t[0] >> 52) | (t[1] << 12)) & limb0Max
 	r.n[2] = ((t[1] >> 40) | (t[2] << 24)) & limb0Max
 	r.n[3] = ((t[2] >> 28) | (t[3] << 36)) & limb0Max
-	r.n[4] = ((t[3] >> 16) | (t[4] << 48)) & limb4Max
-	
+	r.n[4] = t[3] >> 16
+
 	r.magnitude = 1
 	r.normalized = false
-	
-	// Final reduction if needed
-	if r.n[4] == limb4Max && r.n[3] == limb0Max && r.n[2] == limb0Max && 
-	   r.n[1] == limb0Max && r.n[0] >= fieldModulusLimb0 {
-		r.reduce()
-	}
+	r.normalize()
 }
 
 // sqr squares a field element: r = a^2
 // This implementation follows the C secp256k1_fe_sqr_inner algorithm
 // Optimized: avoid copies when magnitude is low enough
 func (r *FieldElement) sqr(a *FieldElement) {
+	countFieldSqr()
+
 	// Use pointer directly if magnitude is low enough (optimization)
 	var aNorm *FieldElement
 	var aTemp FieldElement
@@ -416,6 +456,8 @@ func (r *FieldElement) sqr(a *FieldElement) {
 // This implements a^(p-2) mod p where p is the secp256k1 field prime
 // This follows secp256k1_fe_inv_var which normalizes the input first
 func (r *FieldElement) inv(a *FieldElement) {
+	countFieldInv()
+
 	// Normalize input first (as per secp256k1_fe_inv_var)
 	var aNorm FieldElement
 	aNorm = *a
@@ -453,102 +495,143 @@ func (r *FieldElement) inv(a *FieldElement) {
 	r.normalized = true
 }
 
-// sqrt computes the square root of a field element if it exists
-// This follows the C secp256k1_fe_sqrt implementation exactly
-func (r *FieldElement) sqrt(a *FieldElement) bool {
-	// Given that p is congruent to 3 mod 4, we can compute the square root of
-	// a mod p as the (p+1)/4'th power of a.
-	//
-	// As (p+1)/4 is an even number, it will have the same result for a and for
-	// (-a). Only one of these two numbers actually has a square root however,
-	// so we test at the end by squaring and comparing to the input.
-	
-	var aNorm FieldElement
-	aNorm = *a
-	
-	// Normalize input if magnitude is too high
-	if aNorm.magnitude > 8 {
-		aNorm.normalizeWeak()
-	} else {
-		aNorm.normalize()
+// invConst computes r = a^-1 using a fixed addition chain for a^(p-2). The
+// sequence of 255 squarings and 15 multiplications is the same for every
+// input and nothing branches on a, so it is safe for secret field elements
+// such as blinding factors. The inverse of zero is zero.
+func (r *FieldElement) invConst(a *FieldElement) {
+	countFieldInv()
+
+	aNorm := *a
+	aNorm.normalizeWeak()
+
+	// The binary representation of p - 2 has 5 blocks of 1s, with lengths in
+	// { 1, 2, 22, 223 }
+	x2, x22, x223 := fieldPowChain(&aNorm)
+
+	t1 := x223
+	for j := 0; j < 23; j++ {
+		t1.sqr(&t1)
 	}
-	
-	// The binary representation of (p + 1)/4 has 3 blocks of 1s, with lengths in
-	// { 2, 22, 223 }. Use an addition chain to calculate 2^n - 1 for each block:
-	// 1, [2], 3, 6, 9, 11, [22], 44, 88, 176, 220, [223]
-	
-	var x2, x3, x6, x9, x11, x22, x44, x88, x176, x220, x223, t1 FieldElement
-	
+	t1.mul(&t1, &x22)
+	for j := 0; j < 5; j++ {
+		t1.sqr(&t1)
+	}
+	t1.mul(&t1, &aNorm)
+	for j := 0; j < 3; j++ {
+		t1.sqr(&t1)
+	}
+	t1.mul(&t1, &x2)
+	for j := 0; j < 2; j++ {
+		t1.sqr(&t1)
+	}
+	r.mul(&t1, &aNorm)
+}
+
+// fieldPowChain computes a^(2^2-1), a^(2^22-1) and a^(2^223-1), the blocks
+// of ones shared by the exponents used for inversion and square roots
+func fieldPowChain(a *FieldElement) (x2, x22, x223 FieldElement) {
+	// Addition chain for 2^n - 1: 1, [2], 3, 6, 9, 11, [22], 44, 88, 176, 220, [223]
+	var x3, x6, x9, x11, x44, x88, x176, x220 FieldElement
+
 	// x2 = a^3
-	x2.sqr(&aNorm)
-	x2.mul(&x2, &aNorm)
-	
+	x2.sqr(a)
+	x2.mul(&x2, a)
+
 	// x3 = a^7
 	x3.sqr(&x2)
-	x3.mul(&x3, &aNorm)
-	
+	x3.mul(&x3, a)
+
 	// x6 = a^63
 	x6 = x3
 	for j := 0; j < 3; j++ {
 		x6.sqr(&x6)
 	}
 	x6.mul(&x6, &x3)
-	
+
 	// x9 = a^511
 	x9 = x6
 	for j := 0; j < 3; j++ {
 		x9.sqr(&x9)
 	}
 	x9.mul(&x9, &x3)
-	
+
 	// x11 = a^2047
 	x11 = x9
 	for j := 0; j < 2; j++ {
 		x11.sqr(&x11)
 	}
 	x11.mul(&x11, &x2)
-	
+
 	// x22 = a^4194303
 	x22 = x11
 	for j := 0; j < 11; j++ {
 		x22.sqr(&x22)
 	}
 	x22.mul(&x22, &x11)
-	
+
 	// x44 = a^17592186044415
 	x44 = x22
 	for j := 0; j < 22; j++ {
 		x44.sqr(&x44)
 	}
 	x44.mul(&x44, &x22)
-	
+
 	// x88 = a^72057594037927935
 	x88 = x44
 	for j := 0; j < 44; j++ {
 		x88.sqr(&x88)
 	}
 	x88.mul(&x88, &x44)
-	
+
 	// x176 = a^1180591620717411303423
 	x176 = x88
 	for j := 0; j < 88; j++ {
 		x176.sqr(&x176)
 	}
 	x176.mul(&x176, &x88)
-	
+
 	// x220 = a^172543658669764094685868767685
 	x220 = x176
 	for j := 0; j < 44; j++ {
 		x220.sqr(&x220)
 	}
 	x220.mul(&x220, &x44)
-	
+
 	// x223 = a^13479973333575319897333507543509815336818572211270286240551805124607
 	x223 = x220
 	for j := 0; j < 3; j++ {
 		x223.sqr(&x223)
 	}
 	x223.mul(&x223, &x3)
+
+	return x2, x22, x223
+}
+
+// sqrt computes the square root of a field element if it exists
+// This follows the C secp256k1_fe_sqrt implementation exactly
+func (r *FieldElement) sqrt(a *FieldElement) bool {
+	// Given that p is congruent to 3 mod 4, we can compute the square root of
+	// a mod p as the (p+1)/4'th power of a.
+	//
+	// As (p+1)/4 is an even number, it will have the same result for a and for
+	// (-a). Only one of these two numbers actually has a square root however,
+	// so we test at the end by squaring and comparing to the input.
+	
+	var aNorm FieldElement
+	aNorm = *a
+	
+	// Normalize input if magnitude is too high
+	if aNorm.magnitude > 8 {
+		aNorm.normalizeWeak()
+	} else {
+		aNorm.normalize()
+	}
+	
+	// The binary representation of (p + 1)/4 has 3 blocks of 1s, with lengths in
+	// { 2, 22, 223 }
+	x2, x22, x223 := fieldPowChain(&aNorm)
+	var t1 FieldElement
 	
 	// The final result is then assembled using a sliding window over the blocks.
 	t1 = x223
@@ -571,49 +654,84 @@ func (r *FieldElement) sqrt(a *FieldElement) bool {
 	
 	ret := check.equal(&aNorm)
 	
-	// If sqrt(a) doesn't exist, compute sqrt(-a) instead (as per field.h comment)
-	if !ret {
-		var negA FieldElement
-		negA.negate(&aNorm, 1)
-		negA.normalize()
-		
-		t1 = x223
-		for j := 0; j < 23; j++ {
-			t1.sqr(&t1)
-		}
-		t1.mul(&t1, &x22)
-		for j := 0; j < 6; j++ {
-			t1.sqr(&t1)
-		}
-		t1.mul(&t1, &x2)
-		t1.sqr(&t1)
-		r.sqr(&t1)
-		
-		check.sqr(r)
-		check.normalize()
-		
-		// Return whether sqrt(-a) exists
-		return check.equal(&negA)
-	}
-	
+	// If a is not a square, (p+1)/4 being even means r already holds
+	// sqrt(-a) (as per the field.h comment), but the result must still
+	// report that a itself has no square root
 	return ret
 }
 
-// isSquare checks if a field element is a quadratic residue
+// isSquare checks if a field element is a quadratic residue, by checking
+// whether sqrt finds a root. The operation count does not depend on a; see
+// fieldIsResidueVar for a faster variable-time check.
 func (a *FieldElement) isSquare() bool {
-	// Use Legendre symbol: a^((p-1)/2) mod p
-	// If result is 1, then a is a quadratic residue
+	var r FieldElement
+	return r.sqrt(a)
+}
+
+// fieldModulus64 is p as little-endian 64-bit words
+var fieldModulus64 = [4]uint64{0xFFFFFFFEFFFFFC2F, 0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF}
+
+// fieldIsResidueVar reports whether a is a square mod p (zero counts as a
+// square, as for sqrt). It computes the Jacobi symbol (a/p) with the binary
+// GCD-like algorithm, which needs only shifts and subtractions and is much
+// cheaper than the exponentiation in sqrt, so it is used to reject x
+// coordinates that are not on the curve before lifting them. Variable time:
+// only use it on public data.
+func fieldIsResidueVar(a *FieldElement) bool {
+	var s FieldElementStorage
+	a.toStorage(&s)
+	x, n := s.n, fieldModulus64
+	if x == ([4]uint64{}) {
+		return true
+	}
+
+	// Track the sign of the symbol as the parity of the number of flips
+	flip := uint64(0)
+	for {
+		// Remove factors of two: (2/n) = -1 iff n = 3 or 5 mod 8
+		for x[0] == 0 {
+			x[0], x[1], x[2], x[3] = x[1], x[2], x[3], 0
+		}
+		if tz := uint(bits.TrailingZeros64(x[0])); tz != 0 {
+			x[0] = x[0]>>tz | x[1]<<(64-tz)
+			x[1] = x[1]>>tz | x[2]<<(64-tz)
+			x[2] = x[2]>>tz | x[3]<<(64-tz)
+			x[3] >>= tz
+			if m := n[0] & 7; tz&1 == 1 && (m == 3 || m == 5) {
+				flip ^= 1
+			}
+		}
 
-	var result FieldElement
-	result = *a
+		// Quadratic reciprocity: swapping flips the sign iff both are
+		// 3 mod 4
+		if jacobiLess(&x, &n) {
+			x, n = n, x
+			if x[0]&3 == 3 && n[0]&3 == 3 {
+				flip ^= 1
+			}
+		}
 
-	// Compute a^((p-1)/2) - simplified implementation
-	for i := 0; i < 127; i++ { // Approximate (p-1)/2 bit length
-		result.sqr(&result)
+		// (x/n) = ((x-n)/n), and x-n is even
+		var borrow uint64
+		x[0], borrow = bits.Sub64(x[0], n[0], 0)
+		x[1], borrow = bits.Sub64(x[1], n[1], borrow)
+		x[2], borrow = bits.Sub64(x[2], n[2], borrow)
+		x[3], _ = bits.Sub64(x[3], n[3], borrow)
+		if x == ([4]uint64{}) {
+			// n is now gcd(a, p) = 1, since p is prime and a != 0
+			return flip == 0
+		}
 	}
+}
 
-	result.normalize()
-	return result.equal(&FieldElementOne)
+// jacobiLess reports whether a < b as little-endian 256-bit integers
+func jacobiLess(a, b *[4]uint64) bool {
+	for i := 3; i >= 0; i-- {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
 }
 
 // half computes r = a/2 mod p
@@ -1,6 +1,11 @@
 package p256k1
 
 import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"math/bits"
 	"testing"
 )
 
@@ -74,6 +79,42 @@ func TestFieldElementSetB32(t *testing.T) {
 	}
 }
 
+func TestFieldElementSetB32Limit(t *testing.T) {
+	valid := []string{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e", // p - 1
+		"effffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", // p - 2^252: low limbs as in p
+		"7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+	}
+	for _, v := range valid {
+		b, _ := hex.DecodeString(v)
+		var fe FieldElement
+		if !fe.setB32Limit(b) {
+			t.Errorf("setB32Limit(%s) = false", v)
+			continue
+		}
+		var out [32]byte
+		fe.getB32(out[:])
+		if !fe.normalized || hex.EncodeToString(out[:]) != v {
+			t.Errorf("setB32Limit(%s) did not round-trip as a normalized element", v)
+		}
+	}
+
+	invalid := []string{
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", // p
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc30", // p + 1
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		"00",
+	}
+	for _, v := range invalid {
+		b, _ := hex.DecodeString(v)
+		var fe FieldElement
+		if fe.setB32Limit(b) {
+			t.Errorf("setB32Limit(%s) = true", v)
+		}
+	}
+}
+
 func TestFieldElementArithmetic(t *testing.T) {
 	// Test addition
 	var a, b, c FieldElement
@@ -132,6 +173,257 @@ func TestFieldElementMultiplication(t *testing.T) {
 	}
 }
 
+func TestFieldElementMulWide(t *testing.T) {
+	pBig, _ := new(big.Int).SetString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", 16)
+
+	// addModulus adds k copies of p to the limbs, keeping the value but
+	// raising the magnitude
+	addModulus := func(a *FieldElement, k uint64) {
+		a.n[0] += k * fieldModulusLimb0
+		a.n[1] += k * fieldModulusLimb1
+		a.n[2] += k * fieldModulusLimb2
+		a.n[3] += k * fieldModulusLimb3
+		a.n[4] += k * fieldModulusLimb4
+		a.magnitude += int(k)
+		a.normalized = false
+	}
+
+	edges := [][]byte{
+		make([]byte, 32),
+		testFieldBytes(1),
+	}
+	pm1, _ := hex.DecodeString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e")
+	edges = append(edges, pm1)
+
+	var buf [64]byte
+	for i := 0; i < 200; i++ {
+		var ab, bb []byte
+		if i < len(edges)*len(edges) {
+			ab, bb = edges[i/len(edges)], edges[i%len(edges)]
+		} else {
+			if _, err := rand.Read(buf[:]); err != nil {
+				t.Fatal(err)
+			}
+			buf[0] &= 0x7f
+			buf[32] &= 0x7f
+			ab, bb = buf[:32], buf[32:]
+		}
+
+		var a, b FieldElement
+		a.setB32(ab)
+		b.setB32(bb)
+		addModulus(&a, uint64(i%8))
+		addModulus(&b, uint64((i/8)%8))
+
+		var fast, wide FieldElement
+		fast.mul(&a, &b)
+		wide.mulWide(&a, &b)
+		fast.normalize()
+		if !fast.equal(&wide) {
+			t.Fatalf("mul and mulWide disagree for %x * %x (magnitudes %d, %d)", ab, bb, a.magnitude, b.magnitude)
+		}
+
+		want := new(big.Int).Mul(new(big.Int).SetBytes(ab), new(big.Int).SetBytes(bb))
+		want.Mod(want, pBig)
+		var got [32]byte
+		wide.getB32(got[:])
+		if new(big.Int).SetBytes(got[:]).Cmp(want) != 0 {
+			t.Fatalf("mulWide wrong for %x * %x", ab, bb)
+		}
+	}
+
+	// reduceFromWide handles the full 640-bit input range
+	var w [10]uint64
+	for i := range w {
+		w[i] = ^uint64(0)
+	}
+	var r FieldElement
+	r.reduceFromWide(w)
+	want := new(big.Int).Lsh(big.NewInt(1), 640)
+	want.Sub(want, big.NewInt(1))
+	want.Mod(want, pBig)
+	var got [32]byte
+	r.getB32(got[:])
+	if new(big.Int).SetBytes(got[:]).Cmp(want) != 0 {
+		t.Error("reduceFromWide wrong for 2^640 - 1")
+	}
+}
+
+func TestFieldElementInvConst(t *testing.T) {
+	pm1, _ := hex.DecodeString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e")
+	inputs := [][]byte{pm1, testFieldBytes(1), testFieldBytes(0x80)}
+	one := make([]byte, 32)
+	one[31] = 1
+	inputs = append(inputs, one)
+	for i := 0; i < 16; i++ {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			t.Fatal(err)
+		}
+		b[0] &= 0x7f
+		inputs = append(inputs, b)
+	}
+
+	for _, in := range inputs {
+		var a, want, got, check FieldElement
+		a.setB32(in)
+		want.inv(&a)
+		got.invConst(&a)
+		want.normalize()
+		got.normalize()
+		if !got.equal(&want) {
+			t.Errorf("invConst(%x) disagrees with inv", in)
+		}
+
+		// a * a^-1 = 1, also for an unnormalized input
+		a.n[0] += fieldModulusLimb0
+		a.n[1] += fieldModulusLimb1
+		a.n[2] += fieldModulusLimb2
+		a.n[3] += fieldModulusLimb3
+		a.n[4] += fieldModulusLimb4
+		a.magnitude = 2
+		a.normalized = false
+		got.invConst(&a)
+		check.mul(&a, &got)
+		if !check.isOne() {
+			t.Errorf("a * invConst(a) != 1 for %x", in)
+		}
+	}
+
+	var zero, r FieldElement
+	r.invConst(&zero)
+	if !r.normalizesToZeroVar() {
+		t.Error("invConst(0) should be 0")
+	}
+}
+
+func TestFieldElementSqrtNonSquare(t *testing.T) {
+	// 132 = 5^3 + 7 is not a square mod p, but -132 is
+	var a, r, check, negA FieldElement
+	a.setInt(132)
+	if r.sqrt(&a) {
+		t.Fatal("sqrt should report that 132 has no square root")
+	}
+	check.sqr(&r)
+	check.normalize()
+	negA.negate(&a, 1)
+	negA.normalize()
+	if !check.equal(&negA) {
+		t.Error("sqrt of a non-square should leave sqrt(-a) in r")
+	}
+
+	a.setInt(4)
+	if !r.sqrt(&a) {
+		t.Fatal("sqrt should find a root of 4")
+	}
+	check.sqr(&r)
+	check.normalize()
+	if !check.equal(&a) {
+		t.Error("sqrt(4)^2 != 4")
+	}
+}
+
+func TestFieldElementIsSquare(t *testing.T) {
+	var zero, one, minusOne, a FieldElement
+	one.setInt(1)
+	minusOne.negate(&one, 1)
+	a.setInt(132)
+	for _, tc := range []struct {
+		name string
+		a    *FieldElement
+		want bool
+	}{
+		{"zero", &zero, true},
+		{"one", &one, true},
+		// p = 3 mod 4, so -1 is not a square
+		{"minus one", &minusOne, false},
+		{"132", &a, false},
+	} {
+		if got := tc.a.isSquare(); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	// Every square is a square, and exactly one of x and -x is for x != 0
+	var b [32]byte
+	for i := 0; i < 64; i++ {
+		if _, err := rand.Read(b[:]); err != nil {
+			t.Fatal(err)
+		}
+		var x, sq, neg FieldElement
+		x.setB32(b[:])
+		sq.sqr(&x)
+		if !sq.isSquare() {
+			t.Fatalf("%x: square reported as a non-residue", b)
+		}
+		neg.negate(&x, 1)
+		if x.isSquare() == neg.isSquare() {
+			t.Fatalf("%x: x and -x are both squares or both non-squares", b)
+		}
+	}
+}
+
+func TestFieldIsResidueVar(t *testing.T) {
+	var zero, one, minusOne, a, sq FieldElement
+	one.setInt(1)
+	minusOne.negate(&one, 1)
+	a.setInt(132)
+	for _, tc := range []struct {
+		name string
+		a    *FieldElement
+		want bool
+	}{
+		{"zero", &zero, true},
+		{"one", &one, true},
+		// p = 3 mod 4, so -1 is not a square
+		{"minus one", &minusOne, false},
+		{"132", &a, false},
+	} {
+		if got := fieldIsResidueVar(tc.a); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	// Agrees with sqrt on random elements, their squares, and unnormalized
+	// inputs
+	var b [32]byte
+	for i := 0; i < 500; i++ {
+		if _, err := rand.Read(b[:]); err != nil {
+			t.Fatal(err)
+		}
+		var x, r FieldElement
+		x.setB32(b[:])
+		if got, want := fieldIsResidueVar(&x), r.sqrt(&x); got != want {
+			t.Fatalf("%x: fieldIsResidueVar %v, sqrt %v", b, got, want)
+		}
+		if got := x.isSquare(); got != fieldIsResidueVar(&x) {
+			t.Fatalf("%x: isSquare disagrees", b)
+		}
+		sq.sqr(&x)
+		if !fieldIsResidueVar(&sq) {
+			t.Fatalf("%x: square reported as a non-residue", b)
+		}
+		x.add(&x)
+		x.add(&x)
+		if got, want := fieldIsResidueVar(&x), r.sqrt(&x); got != want {
+			t.Fatalf("%x (x4, unnormalized): fieldIsResidueVar %v, sqrt %v", b, got, want)
+		}
+	}
+
+	// geXOnCurveVar matches whether setXOVar can lift x
+	for i := 0; i < 100; i++ {
+		if _, err := rand.Read(b[:]); err != nil {
+			t.Fatal(err)
+		}
+		var x FieldElement
+		var p GroupElementAffine
+		x.setB32(b[:])
+		if got, want := geXOnCurveVar(&x), p.setXOVar(&x, false); got != want {
+			t.Fatalf("%x: geXOnCurveVar %v, setXOVar %v", b, got, want)
+		}
+	}
+}
+
 func TestFieldElementNormalization(t *testing.T) {
 	var fe FieldElement
 	fe.setInt(42)
@@ -151,6 +443,113 @@ func TestFieldElementNormalization(t *testing.T) {
 	}
 }
 
+func TestFieldElementReduceOnce(t *testing.T) {
+	cases := []struct {
+		name     string
+		in       string
+		out      string
+		subtract bool
+	}{
+		{"zero", "0000000000000000000000000000000000000000000000000000000000000000",
+			"0000000000000000000000000000000000000000000000000000000000000000", false},
+		{"p-1", "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e",
+			"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e", false},
+		{"p", "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f",
+			"0000000000000000000000000000000000000000000000000000000000000000", true},
+		{"p+1", "fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc30",
+			"0000000000000000000000000000000000000000000000000000000000000001", true},
+		{"2^256-1", "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			"00000000000000000000000000000000000000000000000000000001000003d0", true},
+	}
+
+	for _, tc := range cases {
+		in, _ := hex.DecodeString(tc.in)
+		var fe FieldElement
+		if err := fe.setB32(in); err != nil {
+			t.Fatalf("%s: failed to set field element: %v", tc.name, err)
+		}
+
+		if got := fe.reduceOnce(); got != tc.subtract {
+			t.Errorf("%s: reduceOnce() = %v, want %v", tc.name, got, tc.subtract)
+		}
+
+		var out [32]byte
+		fe.getB32(out[:])
+		if hex.EncodeToString(out[:]) != tc.out {
+			t.Errorf("%s: got %x, want %s", tc.name, out, tc.out)
+		}
+	}
+
+	// After normalizeWeak the value may carry into bit 256
+	var fe, one FieldElement
+	fe.setB32([]byte{
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	})
+	one.setInt(1)
+	fe.add(&one)
+	fe.normalizeWeak()
+	if !fe.reduceOnce() {
+		t.Error("2^256 should be reduced")
+	}
+	expected := FieldElement{n: [5]uint64{fieldReductionConstant, 0, 0, 0, 0}, magnitude: 1, normalized: true}
+	if !fe.equal(&expected) {
+		t.Error("2^256 mod p should equal 2^32 + 977")
+	}
+}
+
+func TestFieldNormalizeBatch(t *testing.T) {
+	// Unnormalized inputs: sums of large values and p + small values
+	p := []byte{
+		0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+		0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE, 0xFF, 0xFF, 0xFC, 0x2F,
+	}
+	fes := make([]FieldElement, 8)
+	for i := range fes {
+		if i%2 == 0 {
+			p[31] = 0x2F + byte(i)
+			fes[i].setB32(p)
+		} else {
+			var a FieldElement
+			a.setB32(testFieldBytes(byte(i * 17)))
+			fes[i] = a
+			fes[i].add(&a)
+			fes[i].add(&a)
+		}
+	}
+
+	want := make([]FieldElement, len(fes))
+	copy(want, fes)
+	for i := range want {
+		want[i].normalize()
+	}
+
+	if !feEqualBatch(fes, want) {
+		t.Error("unnormalized inputs should compare equal to their normalized forms")
+	}
+
+	fieldNormalizeBatch(fes)
+	for i := range fes {
+		if !fes[i].normalized || fes[i].n != want[i].n {
+			t.Errorf("element %d: batch normalization differs from normalize", i)
+		}
+	}
+
+	if !feEqualBatch(fes, want) {
+		t.Error("equal slices should compare equal")
+	}
+	want[len(want)-1].setInt(1)
+	if feEqualBatch(fes, want) {
+		t.Error("slices differing in the last element should not compare equal")
+	}
+	if feEqualBatch(fes, want[:len(want)-1]) {
+		t.Error("slices of different lengths should not compare equal")
+	}
+	if !feEqualBatch(nil, nil) {
+		t.Error("empty slices should compare equal")
+	}
+}
+
 func TestFieldElementOddness(t *testing.T) {
 	var even, odd FieldElement
 	even.setInt(4)
@@ -185,6 +584,43 @@ func TestFieldElementConditionalMove(t *testing.T) {
 	}
 }
 
+func TestCtMask(t *testing.T) {
+	if got := ctMask(0); got != 0 {
+		t.Errorf("ctMask(0) = %#x, want 0", got)
+	}
+	if got := ctMask(1); got != ^uint64(0) {
+		t.Errorf("ctMask(1) = %#x, want all ones", got)
+	}
+	// Only the low bit counts, as for the cmov flags
+	if got := ctMask(2); got != 0 {
+		t.Errorf("ctMask(2) = %#x, want 0", got)
+	}
+	if got := ctMask(-1); got != ^uint64(0) {
+		t.Errorf("ctMask(-1) = %#x, want all ones", got)
+	}
+
+	if ctBool(false) != 0 || ctBool(true) != 1 {
+		t.Errorf("ctBool(false), ctBool(true) = %d, %d, want 0, 1", ctBool(false), ctBool(true))
+	}
+
+	for _, tc := range []struct {
+		a, b uint64
+		want uint64
+	}{
+		{0, 0, ^uint64(0)},
+		{1, 1, ^uint64(0)},
+		{^uint64(0), ^uint64(0), ^uint64(0)},
+		{0, 1, 0},
+		{1 << 63, 0, 0},
+		{0, ^uint64(0), 0},
+		{0x123456789abcdef0, 0x123456789abcdef1, 0},
+	} {
+		if got := ctEq(tc.a, tc.b); got != tc.want {
+			t.Errorf("ctEq(%#x, %#x) = %#x, want %#x", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
 func TestFieldElementStorage(t *testing.T) {
 	var fe FieldElement
 	fe.setInt(12345)
@@ -392,3 +828,154 @@ func TestMontgomery(t *testing.T) {
 		}
 	})
 }
+
+func BenchmarkFieldMul(b *testing.B) {
+	var x, y FieldElement
+	x.setB32(testFieldBytes(0x11))
+	y.setB32(testFieldBytes(0x5a))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.mul(&x, &y)
+	}
+}
+
+func BenchmarkFieldSqr(b *testing.B) {
+	var x FieldElement
+	x.setB32(testFieldBytes(0x11))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.sqr(&x)
+	}
+}
+
+func BenchmarkFieldInv(b *testing.B) {
+	var x, r FieldElement
+	x.setB32(testFieldBytes(0x11))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.inv(&x)
+	}
+}
+
+func BenchmarkFieldIsResidue(b *testing.B) {
+	var x, r FieldElement
+	x.setB32(testFieldBytes(0x11))
+
+	b.Run("jacobi", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			fieldIsResidueVar(&x)
+		}
+	})
+	b.Run("sqrt", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			r.sqrt(&x)
+		}
+	})
+}
+
+// testFieldBytes returns a 32-byte big-endian value below p built from seed
+func testFieldBytes(seed byte) []byte {
+	b := make([]byte, 32)
+	for i := range b {
+		b[i] = seed + byte(i)*0x1d
+	}
+	b[0] &= 0x7f
+	return b
+}
+
+func TestUint128(t *testing.T) {
+	var buf [32]byte
+	for i := 0; i < 1000; i++ {
+		if _, err := rand.Read(buf[:]); err != nil {
+			t.Fatal(err)
+		}
+		a := binary.LittleEndian.Uint64(buf[0:])
+		b := binary.LittleEndian.Uint64(buf[8:])
+		c := binary.LittleEndian.Uint64(buf[16:])
+		d := binary.LittleEndian.Uint64(buf[24:])
+		if i == 0 {
+			a, b, c, d = ^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0)
+		}
+
+		hi, lo := bits.Mul64(a, b)
+		u := Uint128Mul64(a, b)
+		if u.Hi() != hi || u.Lo() != lo {
+			t.Fatalf("Uint128Mul64(%x, %x) = %x:%x, want %x:%x", a, b, u.Hi(), u.Lo(), hi, lo)
+		}
+
+		// u + c*d, wrapping modulo 2^128
+		phi, plo := bits.Mul64(c, d)
+		wlo, carry := bits.Add64(lo, plo, 0)
+		whi, _ := bits.Add64(hi, phi, carry)
+		if got := u.AddMul(c, d); got.Hi() != whi || got.Lo() != wlo {
+			t.Fatalf("AddMul = %x:%x, want %x:%x", got.Hi(), got.Lo(), whi, wlo)
+		}
+
+		wlo, carry = bits.Add64(lo, c, 0)
+		whi, _ = bits.Add64(hi, 0, carry)
+		if got := u.Add64(c); got.Hi() != whi || got.Lo() != wlo {
+			t.Fatalf("Add64 = %x:%x, want %x:%x", got.Hi(), got.Lo(), whi, wlo)
+		}
+
+		x := new(big.Int).Lsh(new(big.Int).SetUint64(hi), 64)
+		x.Or(x, new(big.Int).SetUint64(lo))
+		for _, n := range []uint{0, 1, 52, 63, 64, 65, 100, 127, 128} {
+			want := new(big.Int).Rsh(x, n)
+			got := u.Rshift(n)
+			gotBig := new(big.Int).Lsh(new(big.Int).SetUint64(got.Hi()), 64)
+			gotBig.Or(gotBig, new(big.Int).SetUint64(got.Lo()))
+			if gotBig.Cmp(want) != 0 {
+				t.Fatalf("Rshift(%d) of %x = %x, want %x", n, x, gotBig, want)
+			}
+		}
+	}
+
+	if u := NewUint128(1, 2); u.Hi() != 1 || u.Lo() != 2 {
+		t.Error("NewUint128 did not set hi and lo")
+	}
+}
+
+func TestFieldElementIsOne(t *testing.T) {
+	var zero, one, pMinus1 FieldElement
+	zero.setInt(0)
+	one.setInt(1)
+	pMinus1.negate(&one, 1)
+
+	if !one.isOne() || !FieldElementOne.isOne() {
+		t.Error("one is not one")
+	}
+	if zero.isOne() {
+		t.Error("zero is one")
+	}
+	if pMinus1.isOne() {
+		t.Error("p-1 is one")
+	}
+
+	// p+1 and 2p+1 are unnormalized encodings of one
+	for k := uint64(1); k <= 2; k++ {
+		u := one
+		u.n[0] += k * fieldModulusLimb0
+		u.n[1] += k * fieldModulusLimb1
+		u.n[2] += k * fieldModulusLimb2
+		u.n[3] += k * fieldModulusLimb3
+		u.n[4] += k * fieldModulusLimb4
+		u.magnitude = int(k) + 1
+		u.normalized = false
+		if !u.isOne() {
+			t.Errorf("%d*p+1 is not one", k)
+		}
+		if u.normalized {
+			t.Error("isOne normalized its receiver")
+		}
+	}
+
+	// (p-1)^2 = 1
+	var sq FieldElement
+	sq.sqr(&pMinus1)
+	if !sq.isOne() {
+		t.Error("(p-1)^2 is not one")
+	}
+}
@@ -1,6 +1,11 @@
 package p256k1
 
-// No imports needed for basic group operations
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
 
 // GroupElementAffine represents a point on the secp256k1 curve in affine coordinates (x, y)
 type GroupElementAffine struct {
@@ -47,6 +52,8 @@ func init() {
 	
 	GeneratorX.setB32(gxBytes)
 	GeneratorY.setB32(gyBytes)
+	GeneratorX.normalize()
+	GeneratorY.normalize()
 	
 	// Create generator point
 	Generator = GroupElementAffine{
@@ -112,6 +119,18 @@ func (r *GroupElementAffine) setXOVar(x *FieldElement, odd bool) bool {
 	return true
 }
 
+// geXOnCurveVar reports whether x is the x coordinate of a curve point,
+// i.e. whether x^3 + 7 is a square, without computing the square root
+// (variable time)
+func geXOnCurveVar(x *FieldElement) bool {
+	var y2, seven FieldElement
+	y2.sqr(x)
+	y2.mul(&y2, x)
+	seven.setInt(7)
+	y2.add(&seven)
+	return fieldIsResidueVar(&y2)
+}
+
 // isInfinity returns true if the group element is the point at infinity
 func (r *GroupElementAffine) isInfinity() bool {
 	return r.infinity
@@ -163,6 +182,86 @@ func (r *GroupElementAffine) negate(a *GroupElementAffine) {
 	r.infinity = false
 }
 
+// cmov conditionally moves a into r if flag is 1, without branching on
+// flag. The infinity flag is masked too, so the whole element is copied in
+// constant time.
+func (r *GroupElementAffine) cmov(a *GroupElementAffine, flag int) {
+	countGeCmov()
+
+	r.x.cmov(&a.x, flag)
+	r.y.cmov(&a.y, flag)
+	inf := ctMask(ctBool(r.infinity))
+	inf ^= ctMask(flag) & (inf ^ ctMask(ctBool(a.infinity)))
+	r.infinity = inf != 0
+}
+
+// GeCmovSelect sets out = table[index] in constant time. Every entry of the
+// table is read and conditionally moved, so neither the branches taken nor
+// the memory accessed depend on index. This is the primitive to use when
+// building custom windowed multiplications with secret scalars. It panics if
+// index is out of range.
+//
+// The full scan is deliberate: do not replace it with a direct table[index]
+// read or an early exit, as that leaks index through the cache. The
+// TestGeCmovSelectScansTable guard (p256k1_opcount tag) enforces this.
+func GeCmovSelect(out *GroupElementAffine, table []GroupElementAffine, index int) {
+	if index < 0 || index >= len(table) {
+		panic("table index out of range")
+	}
+
+	out.setInfinity()
+	for i := range table {
+		out.cmov(&table[i], subtle.ConstantTimeEq(int32(i), int32(index)))
+	}
+}
+
+// GeDouble sets r = 2*a, taking and returning affine coordinates. It costs
+// one field inversion, so it suits occasional protocol arithmetic; repeated
+// doubling should stay in Jacobian coordinates instead. r may alias a. The
+// doubling of infinity, or of a point with y = 0 (a vertical tangent), is
+// infinity.
+func GeDouble(r *GroupElementAffine, a *GroupElementAffine) {
+	if a.infinity || a.y.normalizesToZeroVar() {
+		r.setInfinity()
+		return
+	}
+
+	x, y := a.x, a.y
+	x.normalizeWeak()
+	y.normalizeWeak()
+
+	// lambda = 3*x^2 / (2*y)
+	var lambda, t FieldElement
+	t = y
+	t.mulInt(2)
+	t.inv(&t)
+	lambda.sqr(&x)
+	lambda.mulInt(3)
+	lambda.mul(&lambda, &t)
+
+	// x3 = lambda^2 - 2*x
+	var x3, negX FieldElement
+	x3.sqr(&lambda)
+	negX.negate(&x, 1)
+	x3.add(&negX)
+	x3.add(&negX)
+	x3.normalize()
+
+	// y3 = lambda*(x - x3) - y
+	var y3, negX3, negY FieldElement
+	negX3.negate(&x3, 1)
+	t = x
+	t.add(&negX3)
+	y3.mul(&lambda, &t)
+	negY.negate(&y, 1)
+	y3.add(&negY)
+	y3.normalize()
+
+	r.x = x3
+	r.y = y3
+	r.infinity = false
+}
+
 // setInfinity sets the group element to the point at infinity
 func (r *GroupElementAffine) setInfinity() {
 	r.x = FieldElementZero
@@ -192,6 +291,26 @@ func (r *GroupElementAffine) equal(a *GroupElementAffine) bool {
 }
 
 // Jacobian coordinate operations
+//
+// Magnitudes. mul and sqr weakly normalize a copy of any input above
+// magnitude 8 and return magnitude 1, so they accept any input. What can go
+// wrong is negate: it panics above magnitude 31, and is silently wrong if
+// told a smaller magnitude than its input has. Every negate below is passed
+// either the input's tracked magnitude or a constant matching how the input
+// was just computed. The outputs are bounded independently of the inputs:
+//
+//	double:             x 3, y 3, z 1
+//	addVar, subVar:     x 4, y 2, z 1 (double's when they double)
+//	addGE, addGEWithZR: x 4, y 2, z 1 (double's when they double)
+//	addGEConst:         x 2, y 2, z 1, or b's if a is infinity
+//	setGE:              x and y as in the affine input, z 1
+//
+// addGEWithZR negates a.x, and addGEConst negates values of magnitude
+// a.x + 1 and up to a.y + 2, so their inputs must stay below about 30.
+// Every output is far below that, so chains of these operations, as in
+// EcmultConst and the ecmult functions, never need intermediate
+// normalization. Only negate (here and on a Y coordinate in Strauss) raises
+// a magnitude, by one, and it is not applied repeatedly.
 
 // setInfinity sets the Jacobian group element to the point at infinity
 func (r *GroupElementJacobian) setInfinity() {
@@ -367,7 +486,7 @@ func (r *GroupElementJacobian) addVar(a, b *GroupElementJacobian) {
 	// s1 = a->y * z22 * b->z
 	// s2 = b->y * z12 * a->z
 	// h = u2 - u1
-	// i = s2 - s1
+	// i = s1 - s2
 	// If h == 0 and i == 0: double(a)
 	// If h == 0 and i != 0: infinity
 	// Otherwise: add
@@ -398,7 +517,7 @@ func (r *GroupElementJacobian) addVar(a, b *GroupElementJacobian) {
 	h.negate(&u1, 1)
 	h.add(&u2)
 	
-	// i = s2 - s1
+	// i = s1 - s2
 	i.negate(&s2, 1)
 	i.add(&s1)
 	
@@ -461,6 +580,76 @@ func (r *GroupElementJacobian) addVar(a, b *GroupElementJacobian) {
 	r.y.add(&h3)
 }
 
+// subVar sets r = a - b (variable-time point subtraction in Jacobian
+// coordinates). This is addVar with b's Y negated inline, so no negated copy
+// of b is needed.
+func (r *GroupElementJacobian) subVar(a, b *GroupElementJacobian) {
+	if b.infinity {
+		*r = *a
+		return
+	}
+	if a.infinity {
+		r.negate(b)
+		return
+	}
+
+	var z22, z12, u1, u2, s1, s2, h, i, h2, h3, t FieldElement
+
+	z22.sqr(&b.z)
+	z12.sqr(&a.z)
+	u1.mul(&a.x, &z22)
+	u2.mul(&b.x, &z12)
+
+	// s1 = a->y * z22 * b->z
+	s1.mul(&a.y, &z22)
+	s1.mul(&s1, &b.z)
+
+	// s2 = b->y * z12 * a->z
+	s2.mul(&b.y, &z12)
+	s2.mul(&s2, &a.z)
+
+	// h = u2 - u1
+	h.negate(&u1, 1)
+	h.add(&u2)
+
+	// addVar's i = s1 - s2 with b's Y negated: i = s1 + s2
+	i = s2
+	i.add(&s1)
+
+	if h.normalizesToZeroVar() {
+		if i.normalizesToZeroVar() {
+			// a == -b, so a - b = 2a
+			r.double(a)
+		} else {
+			// a == b, so a - b is infinity
+			r.setInfinity()
+		}
+		return
+	}
+
+	r.infinity = false
+
+	t.mul(&h, &b.z)
+	r.z.mul(&a.z, &t)
+
+	h2.sqr(&h)
+	h2.negate(&h2, 1)
+	h3.mul(&h2, &h)
+	t.mul(&u1, &h2)
+
+	// r->x = i^2 + h3 + 2*t
+	r.x.sqr(&i)
+	r.x.add(&h3)
+	r.x.add(&t)
+	r.x.add(&t)
+
+	// r->y = (t + r->x) * i + h3 * s1
+	t.add(&r.x)
+	r.y.mul(&t, &i)
+	h3.mul(&h3, &s1)
+	r.y.add(&h3)
+}
+
 // addGEWithZR sets r = a + b where a is Jacobian and b is affine
 // If rzr is not nil, sets *rzr = h such that r->z == a->z * h
 // This follows the C secp256k1_gej_add_ge_var implementation exactly
@@ -507,7 +696,7 @@ func (r *GroupElementJacobian) addGEWithZR(a *GroupElementJacobian, b *GroupElem
 	h.negate(&u1, a.x.magnitude)
 	h.add(&u2)
 	
-	// i = s2 - s1
+	// i = s1 - s2
 	i.negate(&s2, 1)
 	i.add(&s1)
 	
@@ -516,13 +705,12 @@ func (r *GroupElementJacobian) addGEWithZR(a *GroupElementJacobian, b *GroupElem
 		if i.normalizesToZeroVar() {
 			// Points are equal - double
 			// C code: secp256k1_gej_double_var(r, a, rzr)
-			// For doubling, rzr should be set to 2*a->y (but we'll use a simpler approach)
-			// Actually, rzr = 2*a->y based on the double_var implementation
-			// But for our use case (building odd multiples), we shouldn't hit this case
+			// double computes Z3 = Y1*Z1 (the halved formula), so the
+			// z-ratio is a->y. Older C versions used Z3 = 2*Y1*Z1 and
+			// hence rzr = 2*a->y, which does not match this double.
 			if rzr != nil {
-				// Approximate: rzr = 2*a->y (from double_var logic)
-				// But simpler: just set to 0 since we shouldn't hit this
-				rzr.setInt(0)
+				*rzr = a.y
+				rzr.normalizeWeak()
 			}
 			r.double(a)
 			return
@@ -585,6 +773,83 @@ func (r *GroupElementJacobian) addGEWithZR(a *GroupElementJacobian, b *GroupElem
 	r.y.add(&h3)
 }
 
+// cmov conditionally moves a into r if flag is 1, without branching on flag
+func (r *GroupElementJacobian) cmov(a *GroupElementJacobian, flag int) {
+	r.x.cmov(&a.x, flag)
+	r.y.cmov(&a.y, flag)
+	r.z.cmov(&a.z, flag)
+	inf := ctMask(ctBool(r.infinity))
+	inf ^= ctMask(flag) & (inf ^ ctMask(ctBool(a.infinity)))
+	r.infinity = inf != 0
+}
+
+// addGEConst sets r = a + b in constant time, where a is Jacobian and b is
+// affine and must not be infinity. Unlike addGE there are no branches: the
+// complete formula covers doubling, a = -b is detected from the result's Z,
+// and a = infinity is handled by a final conditional move. The only
+// data-dependent choice, the degenerate lambda = R/0 case, is made with cmov.
+// This follows the C secp256k1_gej_add_ge implementation.
+// Operations: 7 mul, 5 sqr
+func (r *GroupElementJacobian) addGEConst(a *GroupElementJacobian, b *GroupElementAffine) {
+	var zz, u1, u2, s1, s2, t, tt, m, n, q, rr, mAlt, rrAlt FieldElement
+
+	zz.sqr(&a.z)      // z = Z1^2
+	u1 = a.x          // u1 = U1 = X1*Z2^2
+	u2.mul(&b.x, &zz) // u2 = U2 = X2*Z1^2
+	s1 = a.y          // s1 = S1 = Y1*Z2^3
+	s2.mul(&b.y, &zz) // s2 = Y2*Z1^2
+	s2.mul(&s2, &a.z) // s2 = S2 = Y2*Z1^3
+	t = u1
+	t.add(&u2) // t = T = U1+U2
+	m = s1
+	m.add(&s2)          // m = M = S1+S2
+	rr.sqr(&t)          // rr = T^2
+	mAlt.negate(&u2, 1) // Malt = -X2*Z1^2
+	tt.mul(&u1, &mAlt)  // tt = -U1*U2
+	rr.add(&tt)         // rr = R = T^2-U1*U2
+
+	// If lambda = R/M = R/0 we have a problem (except in the "trivial" case
+	// that Z = z1z2 = 0, handled below). This only occurs when y1 == -y2
+	// and x1^3 == x2^3 but x1 != x2, where (y1 - y2)/(x1 - x2) is an
+	// alternative expression for lambda.
+	degenerate := m.normalizesToZero()
+	rrAlt = s1
+	rrAlt.mulInt(2) // rr_alt = Y1*Z2^3 - Y2*Z1^3
+	mAlt.add(&u1)   // Malt = X1*Z2^2 - X2*Z1^2
+	rrAlt.cmov(&rr, degenerate^1)
+	mAlt.cmov(&m, degenerate^1)
+
+	// From here on rrAlt/mAlt is lambda, and R and M are the explicit
+	// expressions x1^2 + x2^2 + x1x2 and y1 + y2
+	n.sqr(&mAlt)              // n = Malt^2
+	q.negate(&t, t.magnitude) // q = -T
+	q.mul(&q, &n)             // q = Q = -T*Malt^2
+
+	// Either M == Malt or M == 0, so M^3 * Malt is either Malt^4 (computed
+	// by squaring) or zero (computed by cmov)
+	n.sqr(&n)                   // n = Malt^4
+	n.cmov(&m, degenerate)      // n = M^3 * Malt
+	t.sqr(&rrAlt)               // t = Ralt^2
+	r.z.mul(&a.z, &mAlt)        // r->z = Z3 = Malt*Z
+	t.add(&q)                   // t = Ralt^2 + Q
+	r.x = t                     // r->x = X3 = Ralt^2 + Q
+	t.mulInt(2)                 // t = 2*X3
+	t.add(&q)                   // t = 2*X3 + Q
+	t.mul(&t, &rrAlt)           // t = Ralt*(2*X3 + Q)
+	t.add(&n)                   // t = Ralt*(2*X3 + Q) + M^3*Malt
+	r.y.negate(&t, t.magnitude) // r->y = -(Ralt*(2*X3 + Q) + M^3*Malt)
+	r.y.half(&r.y)              // r->y = Y3 = -(Ralt*(2*X3 + Q) + M^3*Malt)/2
+
+	// In case a is infinity, replace r with (b->x, b->y, 1)
+	ainf := ctBool(a.infinity)
+	r.x.cmov(&b.x, ainf)
+	r.y.cmov(&b.y, ainf)
+	r.z.cmov(&FieldElementOne, ainf)
+
+	// r is infinity exactly when a = -b, in which case Z3 = (x1 - x2)*Z = 0
+	r.infinity = r.z.normalizesToZero() != 0
+}
+
 // addGE sets r = a + b where a is Jacobian and b is affine
 // This follows the C secp256k1_gej_add_ge_var implementation exactly
 // Operations: 8 mul, 3 sqr, 11 add/negate/normalizes_to_zero
@@ -707,3 +972,59 @@ func (r *GroupElementAffine) fromBytes(buf []byte) {
 	r.y.setB32(buf[32:64])
 	r.infinity = false
 }
+
+// groupElementJSON is the JSON form of an affine point, for debugging
+type groupElementJSON struct {
+	X        string `json:"x"`
+	Y        string `json:"y"`
+	Infinity bool   `json:"infinity"`
+}
+
+// MarshalJSON encodes the point as {"x":"<hex>","y":"<hex>","infinity":bool}
+// with normalized big-endian coordinates. It is meant for logging and
+// debugging internal points; the point at infinity has zero coordinates.
+func (r *GroupElementAffine) MarshalJSON() ([]byte, error) {
+	var buf [64]byte
+	p := *r
+	p.toBytes(buf[:])
+	return json.Marshal(groupElementJSON{
+		X:        hex.EncodeToString(buf[:32]),
+		Y:        hex.EncodeToString(buf[32:]),
+		Infinity: p.infinity,
+	})
+}
+
+// UnmarshalJSON decodes a point written by MarshalJSON. Coordinates must be
+// 32-byte hex values below p describing a point on the curve.
+func (r *GroupElementAffine) UnmarshalJSON(data []byte) error {
+	var v groupElementJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("%w: %v", ErrParse, err)
+	}
+	if v.Infinity {
+		r.setInfinity()
+		return nil
+	}
+
+	var x, y FieldElement
+	for _, c := range []struct {
+		s  string
+		fe *FieldElement
+	}{{v.X, &x}, {v.Y, &y}} {
+		b, err := hex.DecodeString(c.s)
+		if err != nil || len(b) != 32 {
+			return fmt.Errorf("%w: coordinate must be 32 bytes of hex", ErrParse)
+		}
+		if !c.fe.setB32Limit(b) {
+			return fmt.Errorf("%w: coordinate not below field prime", ErrParse)
+		}
+	}
+
+	var p GroupElementAffine
+	p.setXY(&x, &y)
+	if !p.isValid() {
+		return fmt.Errorf("%w: point not on curve", ErrInvalidPubkey)
+	}
+	*r = p
+	return nil
+}
@@ -1,6 +1,11 @@
 package p256k1
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -71,6 +76,418 @@ func TestGroupElementJacobian(t *testing.T) {
 	}
 }
 
+func TestGroupElementJacobianSubVar(t *testing.T) {
+	var g, twoG, threeG GroupElementJacobian
+	g.setGE(&Generator)
+	twoG.double(&g)
+	threeG.addVar(&twoG, &g)
+
+	var inf GroupElementJacobian
+	inf.setInfinity()
+
+	cases := []struct {
+		name string
+		a, b *GroupElementJacobian
+	}{
+		{"3G-2G", &threeG, &twoG},
+		{"2G-3G", &twoG, &threeG},
+		{"G-G", &g, &g},
+		{"inf-G", &inf, &g},
+		{"G-inf", &g, &inf},
+		{"inf-inf", &inf, &inf},
+	}
+
+	// a - (-a) exercises the doubling branch
+	var negG GroupElementJacobian
+	negG.negate(&g)
+	cases = append(cases, struct {
+		name string
+		a, b *GroupElementJacobian
+	}{"G-(-G)", &g, &negG})
+
+	for _, tc := range cases {
+		var sub, negB, expected GroupElementJacobian
+		sub.subVar(tc.a, tc.b)
+		negB.negate(tc.b)
+		expected.addVar(tc.a, &negB)
+
+		var subAff, expectedAff GroupElementAffine
+		subAff.setGEJ(&sub)
+		expectedAff.setGEJ(&expected)
+		if !subAff.equal(&expectedAff) {
+			t.Errorf("%s: subVar does not match addVar with negated operand", tc.name)
+		}
+	}
+}
+
+// exhaustiveCurves are curves y^2 = x^3 + b over secp256k1's field whose
+// groups have a subgroup of small order, as used by libsecp256k1's
+// exhaustive tests. The group formulas do not involve b, so they can be
+// checked on every pair of points of the subgroup. Both orders are 1 mod 3,
+// so each subgroup is closed under the endomorphism (x, y) -> (beta*x, y)
+// and contains the pairs that hit addGEConst's degenerate case. g is
+// ((#E/order) * P) for the point P with x = 3, where #E is the curve's order.
+var exhaustiveCurves = []struct {
+	b      int
+	order  int
+	gx, gy string
+}{
+	{2, 13,
+		"3ad3e1ec92dc070a346a975330b12e1d929c645be56584aa88e489aa3d68746b",
+		"ab34e4942373ed8cf787bb15bc0b9fc1f15065bc091001aa6c607f91c8520383"},
+	{4, 199,
+		"fa7cc9a70737f2dba749dd392b4fb0693b017a7da808c2f1fb12940c9ea66c18",
+		"78ac123a5ed8aef38732bc911f3a286848df246c808dae72cfe525727f0501ed"},
+}
+
+// TestGroupLawExhaustive checks the group operations on every element, and
+// every pair of elements, of each subgroup in exhaustiveCurves against
+// addition of indices mod the order
+func TestGroupLawExhaustive(t *testing.T) {
+	for _, c := range exhaustiveCurves {
+		c := c
+		t.Run(fmt.Sprintf("order%d", c.order), func(t *testing.T) {
+			testGroupLawExhaustive(t, c.b, c.order, c.gx, c.gy)
+		})
+	}
+}
+
+func testGroupLawExhaustive(t *testing.T, b int, order int, gx, gy string) {
+	var g GroupElementAffine
+	xb, _ := hex.DecodeString(gx)
+	yb, _ := hex.DecodeString(gy)
+	g.x.setB32(xb)
+	g.y.setB32(yb)
+
+	// The generator is on y^2 = x^3 + b
+	var lhs, rhs, fb FieldElement
+	lhs.sqr(&g.y)
+	rhs.sqr(&g.x)
+	rhs.mul(&rhs, &g.x)
+	fb.setInt(b)
+	rhs.add(&fb)
+	lhs.normalize()
+	rhs.normalize()
+	if !lhs.equal(&rhs) {
+		t.Fatal("generator is not on the curve")
+	}
+
+	// Multiples 0..order-1 by repeated addition, so z != 1; the next one
+	// must be infinity again
+	jac := make([]GroupElementJacobian, order)
+	aff := make([]GroupElementAffine, order)
+	jac[0].setInfinity()
+	for k := 1; k < order; k++ {
+		jac[k].addGE(&jac[k-1], &g)
+		if jac[k].isInfinity() {
+			t.Fatalf("%d*G is infinity", k)
+		}
+	}
+	for k := range jac {
+		aff[k].setGEJ(&jac[k])
+	}
+	var wrap GroupElementJacobian
+	wrap.addGE(&jac[order-1], &g)
+	if !wrap.isInfinity() {
+		t.Fatalf("%d*G is not infinity", order)
+	}
+
+	// Compare without an inversion: r = (X, Y, Z) is the affine (x, y)
+	// iff X = x*Z^2 and Y = y*Z^3
+	check := func(op string, i, j, expected int, r *GroupElementJacobian) {
+		t.Helper()
+		want := &aff[((expected%order)+order)%order]
+		ok := r.isInfinity() == want.isInfinity()
+		if ok && !r.isInfinity() {
+			var z2, z3, x, y, rx, ry FieldElement
+			z2.sqr(&r.z)
+			z3.mul(&z2, &r.z)
+			x.mul(&want.x, &z2)
+			y.mul(&want.y, &z3)
+			rx, ry = r.x, r.y
+			x.normalize()
+			y.normalize()
+			rx.normalize()
+			ry.normalize()
+			ok = x.equal(&rx) && y.equal(&ry)
+		}
+		if !ok {
+			t.Errorf("%s(%d*G, %d*G) != %d*G", op, i, j, expected)
+		}
+	}
+
+	for i := 0; i < order; i++ {
+		var r GroupElementJacobian
+		r.double(&jac[i])
+		check("double", i, i, 2*i, &r)
+		r.negate(&jac[i])
+		check("negate", i, i, -i, &r)
+
+		for j := 0; j < order; j++ {
+			r.addVar(&jac[i], &jac[j])
+			check("addVar", i, j, i+j, &r)
+
+			r.addGE(&jac[i], &aff[j])
+			check("addGE", i, j, i+j, &r)
+
+			r.subVar(&jac[i], &jac[j])
+			check("subVar", i, j, i-j, &r)
+
+			if j != 0 {
+				r.addGEConst(&jac[i], &aff[j])
+				check("addGEConst", i, j, i+j, &r)
+
+				var rzr FieldElement
+				r.addGEWithZR(&jac[i], &aff[j], &rzr)
+				check("addGEWithZR", i, j, i+j, &r)
+				if i != 0 && !r.isInfinity() {
+					var z FieldElement
+					z.mul(&jac[i].z, &rzr)
+					z.normalize()
+					rz := r.z
+					rz.normalize()
+					if !z.equal(&rz) {
+						t.Errorf("addGEWithZR(%d*G, %d*G): z ratio is wrong", i, j)
+					}
+				}
+			}
+		}
+	}
+
+	// Constant-time multiplication by every scalar below twice the order.
+	// Its table holds all multiples up to 2^ecmultConstGroupSize - 1, so it
+	// needs an order above that or the table would contain infinity.
+	if order <= 1<<ecmultConstGroupSize {
+		return
+	}
+	for k := 0; k < 2*order; k++ {
+		var s Scalar
+		s.setInt(uint(k))
+		var r GroupElementJacobian
+		EcmultConst(&r, &g, &s)
+		check("EcmultConst", k, 1, k, &r)
+	}
+}
+
+func TestGroupMagnitudeBounds(t *testing.T) {
+	// Long chains of double/addGE/addVar/addGEConst, as in EcmultConst,
+	// must keep coordinate magnitudes within the bounds documented in
+	// group.go, which are what lets them skip intermediate normalization
+	var r, g GroupElementJacobian
+	g.setGE(&Generator)
+	r = g
+	check := func(op string, i, x, y int) {
+		t.Helper()
+		if r.x.magnitude > x || r.y.magnitude > y || r.z.magnitude > 1 {
+			t.Fatalf("%s at step %d: magnitudes x=%d y=%d z=%d, want at most %d %d 1",
+				op, i, r.x.magnitude, r.y.magnitude, r.z.magnitude, x, y)
+		}
+	}
+	for i := 0; i < 512; i++ {
+		r.double(&r)
+		check("double", i, 3, 3)
+		r.addGE(&r, &Generator)
+		check("addGE", i, 4, 2)
+		r.addVar(&r, &g)
+		check("addVar", i, 4, 2)
+		r.addGEConst(&r, &Generator)
+		check("addGEConst", i, 2, 2)
+	}
+
+	var k Scalar
+	k.setInt(1)
+	k.negate(&k)
+	EcmultConst(&r, &Generator, &k)
+	check("EcmultConst", 0, 3, 3)
+
+	var aff GroupElementAffine
+	aff.setGEJ(&r)
+	if !aff.isValid() {
+		t.Error("result of long addition chain is not on the curve")
+	}
+}
+
+func TestGeCmovSelect(t *testing.T) {
+	// Table of infinity, G, 2G, ..., 15G
+	table := make([]GroupElementAffine, 16)
+	table[0].setInfinity()
+	var acc, g GroupElementJacobian
+	g.setGE(&Generator)
+	acc = g
+	for i := 1; i < len(table); i++ {
+		table[i].setGEJ(&acc)
+		table[i].x.normalize()
+		table[i].y.normalize()
+		acc.addVar(&acc, &g)
+	}
+
+	for i := range table {
+		// Start from a different entry so stale state would be detected
+		out := table[(i+7)%len(table)]
+		GeCmovSelect(&out, table, i)
+		if !out.equal(&table[i]) || out.infinity != table[i].infinity {
+			t.Errorf("GeCmovSelect selected wrong entry for index %d", i)
+		}
+	}
+
+	// The selection must not allocate for any index
+	var out GroupElementAffine
+	for _, index := range []int{0, 5, len(table) - 1} {
+		allocs := testing.AllocsPerRun(100, func() {
+			GeCmovSelect(&out, table, index)
+		})
+		if allocs != 0 {
+			t.Errorf("GeCmovSelect allocated %v times for index %d", allocs, index)
+		}
+	}
+
+	// Selecting with cmov flag 0 must leave the destination untouched
+	out = table[3]
+	out.cmov(&table[4], 0)
+	if !out.equal(&table[3]) {
+		t.Error("cmov with flag 0 modified the destination")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for out of range index")
+		}
+	}()
+	GeCmovSelect(&out, table, len(table))
+}
+
+func TestAddGEWithZRRatio(t *testing.T) {
+	// Build a Jacobian point with z != 1 and an affine copy of it, so
+	// addGEWithZR takes the equal-points (doubling) branch
+	var g, a GroupElementJacobian
+	g.setGE(&Generator)
+	a.double(&g)
+	a.addVar(&a, &g) // 3G with non-trivial z
+
+	var b GroupElementAffine
+	b.setGEJ(&a)
+
+	checkRatio := func(name string, r, a *GroupElementJacobian, rzr *FieldElement) {
+		var expectedZ FieldElement
+		expectedZ.mul(&a.z, rzr)
+		expectedZ.normalize()
+		z := r.z
+		z.normalize()
+		if !z.equal(&expectedZ) {
+			t.Errorf("%s: r.z != a.z * rzr", name)
+		}
+	}
+
+	var r GroupElementJacobian
+	var rzr FieldElement
+	r.addGEWithZR(&a, &b, &rzr)
+
+	var rAff, expected GroupElementAffine
+	var sixG GroupElementJacobian
+	sixG.double(&a)
+	rAff.setGEJ(&r)
+	expected.setGEJ(&sixG)
+	if !rAff.equal(&expected) {
+		t.Fatal("addGEWithZR(3G, 3G) != 6G")
+	}
+	checkRatio("doubling", &r, &a, &rzr)
+
+	// The general addition branch must keep the same invariant
+	var gAff GroupElementAffine
+	gAff.setGEJ(&g)
+	r.addGEWithZR(&a, &gAff, &rzr)
+	checkRatio("addition", &r, &a, &rzr)
+}
+
+// jacobianEqual reports whether two Jacobian points are the same group element
+func jacobianEqual(a, b *GroupElementJacobian) bool {
+	if a.isInfinity() || b.isInfinity() {
+		return a.isInfinity() && b.isInfinity()
+	}
+	var aa, ba GroupElementAffine
+	aa.setGEJ(a)
+	ba.setGEJ(b)
+	aa.x.normalize()
+	aa.y.normalize()
+	ba.x.normalize()
+	ba.y.normalize()
+	return aa.x.equal(&ba.x) && aa.y.equal(&ba.y)
+}
+
+func TestAddGEConst(t *testing.T) {
+	point := func(k uint) (GroupElementJacobian, GroupElementAffine) {
+		var s Scalar
+		s.setInt(k)
+		var j GroupElementJacobian
+		EcmultGen(&j, &s)
+		var a GroupElementAffine
+		a.setGEJ(&j)
+		a.x.normalize()
+		a.y.normalize()
+		return j, a
+	}
+
+	// Generic additions agree with the variable-time addGE
+	for k := uint(1); k < 16; k++ {
+		aj, _ := point(k)
+		_, b := point(k*7 + 3)
+		var want, got GroupElementJacobian
+		want.addGE(&aj, &b)
+		got.addGEConst(&aj, &b)
+		if !jacobianEqual(&got, &want) {
+			t.Errorf("%dG + %dG: addGEConst differs from addGE", k, k*7+3)
+		}
+	}
+
+	aj, a := point(5)
+
+	// a == b doubles
+	var want, got GroupElementJacobian
+	want.double(&aj)
+	got.addGEConst(&aj, &a)
+	if !jacobianEqual(&got, &want) {
+		t.Error("P + P: addGEConst differs from double")
+	}
+
+	// a == -b gives infinity
+	var neg GroupElementAffine
+	neg.negate(&a)
+	got.addGEConst(&aj, &neg)
+	if !got.isInfinity() {
+		t.Error("P + (-P): expected infinity")
+	}
+
+	// a == infinity gives b
+	var inf GroupElementJacobian
+	inf.setInfinity()
+	got.addGEConst(&inf, &a)
+	want.setGE(&a)
+	if !jacobianEqual(&got, &want) {
+		t.Error("infinity + P: expected P")
+	}
+
+	// Degenerate case: b = (beta*x, -y) has y1 = -y2 and x1^3 = x2^3 with
+	// x1 != x2, so the usual lambda = R/M is R/0
+	betaBytes, _ := hex.DecodeString("7ae96a2b657c07106e64479eac3434e99cf0497512f58995c1396c28719501ee")
+	var beta FieldElement
+	beta.setB32(betaBytes)
+	var b GroupElementAffine
+	b.x.mul(&a.x, &beta)
+	b.x.normalize()
+	b.y.negate(&a.y, 1)
+	b.y.normalize()
+	if !b.isValid() {
+		t.Fatal("(beta*x, -y) should be on the curve")
+	}
+	var bj GroupElementJacobian
+	bj.setGE(&b)
+	want.addVar(&aj, &bj)
+	got.addGEConst(&aj, &b)
+	if got.isInfinity() || !jacobianEqual(&got, &want) {
+		t.Error("degenerate case: addGEConst differs from addVar")
+	}
+}
+
 func TestGroupElementStorage(t *testing.T) {
 	// Test storage conversion
 	var storage GroupElementStorage
@@ -139,3 +556,202 @@ func BenchmarkGroupAdd(b *testing.B) {
 		jac1.addVar(&jac1, &jac2)
 	}
 }
+
+func BenchmarkGeCmovSelect(b *testing.B) {
+	table := make([]GroupElementAffine, 16)
+	for i := range table {
+		table[i] = Generator
+	}
+
+	var out GroupElementAffine
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		GeCmovSelect(&out, table, i&15)
+	}
+}
+
+func TestGroupElementAffineJSON(t *testing.T) {
+	for i := 0; i < 16; i++ {
+		var b [32]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			t.Fatal(err)
+		}
+		var k Scalar
+		k.setB32(b[:])
+		var pj GroupElementJacobian
+		EcmultGen(&pj, &k)
+		var p GroupElementAffine
+		p.setGEJ(&pj)
+
+		data, err := json.Marshal(&p)
+		if err != nil {
+			t.Fatalf("MarshalJSON failed: %v", err)
+		}
+		var v struct {
+			X, Y     string
+			Infinity bool
+		}
+		if err := json.Unmarshal(data, &v); err != nil {
+			t.Fatalf("output is not valid JSON: %v", err)
+		}
+		var want [64]byte
+		p.toBytes(want[:])
+		if v.X != hex.EncodeToString(want[:32]) || v.Y != hex.EncodeToString(want[32:]) || v.Infinity {
+			t.Errorf("unexpected JSON %s", data)
+		}
+
+		var q GroupElementAffine
+		if err := json.Unmarshal(data, &q); err != nil {
+			t.Fatalf("UnmarshalJSON failed: %v", err)
+		}
+		if !q.equal(&p) {
+			t.Error("point did not round-trip through JSON")
+		}
+	}
+
+	// Unnormalized coordinates are emitted in canonical form
+	g := Generator
+	g.x.n[0] += fieldModulusLimb0
+	g.x.n[1] += fieldModulusLimb1
+	g.x.n[2] += fieldModulusLimb2
+	g.x.n[3] += fieldModulusLimb3
+	g.x.n[4] += fieldModulusLimb4
+	g.x.normalized = false
+	data, err := json.Marshal(&g)
+	if err != nil {
+		t.Fatalf("MarshalJSON failed: %v", err)
+	}
+	want, _ := json.Marshal(&Generator)
+	if string(data) != string(want) {
+		t.Errorf("unnormalized point marshalled as %s, want %s", data, want)
+	}
+
+	var inf GroupElementAffine
+	inf.setInfinity()
+	data, err = json.Marshal(&inf)
+	if err != nil {
+		t.Fatalf("MarshalJSON failed: %v", err)
+	}
+	var q GroupElementAffine
+	if err := json.Unmarshal(data, &q); err != nil || !q.isInfinity() {
+		t.Errorf("infinity did not round-trip: %v", err)
+	}
+
+	bad := []string{
+		`{"x":"00","y":"00","infinity":false}`,
+		`{"x":"` + strings.Repeat("ff", 32) + `","y":"` + strings.Repeat("00", 32) + `","infinity":false}`,
+		`{"x":"` + strings.Repeat("00", 31) + `01","y":"` + strings.Repeat("00", 31) + `01","infinity":false}`,
+		`not json`,
+	}
+	for _, s := range bad {
+		if err := json.Unmarshal([]byte(s), &q); err == nil {
+			t.Errorf("expected error for %s", s)
+		}
+	}
+}
+
+func TestSetGEJDoesNotMutateInput(t *testing.T) {
+	var k Scalar
+	k.setInt(12345)
+	var pj GroupElementJacobian
+	EcmultGen(&pj, &k)
+	pj.double(&pj)
+
+	before := pj
+	var p GroupElementAffine
+	p.setGEJ(&pj)
+	if pj != before {
+		t.Error("setGEJ modified its input")
+	}
+
+	// The result is still correct when converting the same input again
+	var q GroupElementAffine
+	q.setGEJ(&pj)
+	if !p.equal(&q) || !p.isValid() {
+		t.Error("converting the unchanged input should give the same valid point")
+	}
+}
+
+func TestGeDouble(t *testing.T) {
+	var two Scalar
+	two.setInt(2)
+
+	for i := 0; i < 32; i++ {
+		var seed [32]byte
+		if _, err := rand.Read(seed[:]); err != nil {
+			t.Fatal(err)
+		}
+		var k Scalar
+		k.setB32(seed[:])
+		var pj GroupElementJacobian
+		EcmultGen(&pj, &k)
+		var p GroupElementAffine
+		p.setGEJ(&pj)
+
+		var wantJ GroupElementJacobian
+		EcmultConst(&wantJ, &p, &two)
+		var want GroupElementAffine
+		want.setGEJ(&wantJ)
+
+		var got GroupElementAffine
+		GeDouble(&got, &p)
+		if !got.equal(&want) || !got.isValid() {
+			t.Fatalf("GeDouble(%d) does not match EcmultConst by 2", i)
+		}
+
+		// In place
+		GeDouble(&p, &p)
+		if !p.equal(&want) {
+			t.Fatalf("in-place GeDouble(%d) does not match", i)
+		}
+	}
+
+	var inf, r GroupElementAffine
+	inf.setInfinity()
+	GeDouble(&r, &inf)
+	if !r.isInfinity() {
+		t.Error("doubling infinity did not give infinity")
+	}
+
+	// secp256k1 has no point with y = 0, but the vertical tangent case must
+	// still give infinity rather than dividing by zero
+	var vert GroupElementAffine
+	vert.x.setInt(7)
+	vert.y.setInt(0)
+	GeDouble(&r, &vert)
+	if !r.isInfinity() {
+		t.Error("doubling a point with y = 0 did not give infinity")
+	}
+}
+
+func TestGeneratorConstant(t *testing.T) {
+	const gx = "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	const gy = "483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"
+
+	if !Generator.x.normalized || !Generator.y.normalized {
+		t.Error("generator coordinates are not normalized")
+	}
+	var x, y [32]byte
+	Generator.x.getB32(x[:])
+	Generator.y.getB32(y[:])
+	if hex.EncodeToString(x[:]) != gx || hex.EncodeToString(y[:]) != gy {
+		t.Errorf("generator is (%x, %x)", x, y)
+	}
+	if !Generator.isValid() {
+		t.Error("generator is not on the curve")
+	}
+}
+
+func TestGeneratorCompressed(t *testing.T) {
+	const want = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+
+	var pubkey PublicKey
+	pubkeySave(&pubkey, &Generator)
+	var compressed [33]byte
+	if n := ECPubkeySerialize(compressed[:], &pubkey, ECCompressed); n != 33 {
+		t.Fatalf("serialized %d bytes", n)
+	}
+	if got := hex.EncodeToString(compressed[:]); got != want {
+		t.Errorf("compressed generator is %s, want %s", got, want)
+	}
+}
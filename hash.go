@@ -2,8 +2,10 @@ package p256k1
 
 import (
 	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"hash"
+	"math/bits"
 	"sync"
 	"unsafe"
 
@@ -298,6 +300,30 @@ func TaggedHash(tag []byte, data []byte) [32]byte {
 	return result
 }
 
+// taggedHashWriter is a SHA256 hash seeded with SHA256(tag)||SHA256(tag)
+type taggedHashWriter struct {
+	hash.Hash
+	prefix [32]byte
+}
+
+// TaggedHashWriter returns a hash.Hash that computes the BIP-340 tagged hash
+// of everything written to it, so protocols can stream data into a tagged
+// hash instead of building the input up front. Sum gives the same result as
+// TaggedHash over the concatenated writes, and Reset restores the tagged
+// starting state.
+func TaggedHashWriter(tag string) hash.Hash {
+	w := &taggedHashWriter{Hash: sha256.New(), prefix: getTaggedHashPrefix([]byte(tag))}
+	w.Reset()
+	return w
+}
+
+// Reset restores the writer to the state after absorbing the tag prefix
+func (w *taggedHashWriter) Reset() {
+	w.Hash.Reset()
+	w.Hash.Write(w.prefix[:])
+	w.Hash.Write(w.prefix[:])
+}
+
 // HashToScalar converts a 32-byte hash to a scalar value
 func HashToScalar(hash []byte) (*Scalar, error) {
 	if len(hash) != 32 {
@@ -309,6 +335,37 @@ func HashToScalar(hash []byte) (*Scalar, error) {
 	return &scalar, nil
 }
 
+// TaggedHashToScalar hashes msgs under the domain-separation tag into a
+// scalar. Each message is length-prefixed so that different splits of the
+// same bytes hash differently. The digest is expanded to 512 bits and reduced
+// mod n, so the bias of the result is negligible (about 2^-256).
+func TaggedHashToScalar(tag string, msgs ...[]byte) Scalar {
+	var data []byte
+	var lenBuf [8]byte
+	for _, msg := range msgs {
+		binary.BigEndian.PutUint64(lenBuf[:], uint64(len(msg)))
+		data = append(data, lenBuf[:]...)
+		data = append(data, msg...)
+	}
+
+	tagBytes := []byte(tag)
+	digest := TaggedHash(tagBytes, data)
+
+	// Expand to 64 bytes: H(tag, digest || 0) || H(tag, digest || 1)
+	var wide [64]byte
+	var expand [33]byte
+	copy(expand[:32], digest[:])
+	h0 := TaggedHash(tagBytes, expand[:])
+	expand[32] = 1
+	h1 := TaggedHash(tagBytes, expand[:])
+	copy(wide[:32], h0[:])
+	copy(wide[32:], h1[:])
+
+	var r Scalar
+	r.setB64(wide[:])
+	return r
+}
+
 // HashToField converts a 32-byte hash to a field element
 func HashToField(hash []byte) (*FieldElement, error) {
 	if len(hash) != 32 {
@@ -321,3 +378,215 @@ func HashToField(hash []byte) (*FieldElement, error) {
 	}
 	return &field, nil
 }
+
+// RIPEMD-160 message word selection, rotation amounts and round constants
+// for the left and right lines
+var (
+	ripemdR = [80]uint8{
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+		7, 4, 13, 1, 10, 6, 15, 3, 12, 0, 9, 5, 2, 14, 11, 8,
+		3, 10, 14, 4, 9, 15, 8, 1, 2, 7, 0, 6, 13, 11, 5, 12,
+		1, 9, 11, 10, 0, 8, 12, 4, 13, 3, 7, 15, 14, 5, 6, 2,
+		4, 0, 5, 9, 7, 12, 2, 10, 14, 1, 3, 8, 11, 6, 15, 13,
+	}
+	ripemdRP = [80]uint8{
+		5, 14, 7, 0, 9, 2, 11, 4, 13, 6, 15, 8, 1, 10, 3, 12,
+		6, 11, 3, 7, 0, 13, 5, 10, 14, 15, 8, 12, 4, 9, 1, 2,
+		15, 5, 1, 3, 7, 14, 6, 9, 11, 8, 12, 2, 10, 0, 4, 13,
+		8, 6, 4, 1, 3, 11, 15, 0, 5, 12, 2, 13, 9, 7, 10, 14,
+		12, 15, 10, 4, 1, 5, 8, 7, 6, 2, 13, 14, 0, 3, 9, 11,
+	}
+	ripemdS = [80]uint8{
+		11, 14, 15, 12, 5, 8, 7, 9, 11, 13, 14, 15, 6, 7, 9, 8,
+		7, 6, 8, 13, 11, 9, 7, 15, 7, 12, 15, 9, 11, 7, 13, 12,
+		11, 13, 6, 7, 14, 9, 13, 15, 14, 8, 13, 6, 5, 12, 7, 5,
+		11, 12, 14, 15, 14, 15, 9, 8, 9, 14, 5, 6, 8, 6, 5, 12,
+		9, 15, 5, 11, 6, 8, 13, 12, 5, 12, 13, 14, 11, 8, 5, 6,
+	}
+	ripemdSP = [80]uint8{
+		8, 9, 9, 11, 13, 15, 15, 5, 7, 7, 8, 11, 14, 14, 12, 6,
+		9, 13, 15, 7, 12, 8, 9, 11, 7, 7, 12, 7, 6, 15, 13, 11,
+		9, 7, 15, 11, 8, 6, 6, 14, 12, 13, 5, 14, 13, 13, 7, 5,
+		15, 5, 8, 11, 14, 14, 6, 14, 6, 9, 12, 9, 12, 5, 15, 8,
+		8, 5, 12, 9, 12, 5, 14, 6, 8, 13, 6, 5, 15, 13, 11, 11,
+	}
+	ripemdK  = [5]uint32{0x00000000, 0x5A827999, 0x6ED9EBA1, 0x8F1BBCDC, 0xA953FD4E}
+	ripemdKP = [5]uint32{0x50A28BE6, 0x5C4DD124, 0x6D703EF3, 0x7A6D76E9, 0x00000000}
+)
+
+// ripemdF is the RIPEMD-160 boolean function for round j/16
+func ripemdF(round int, x, y, z uint32) uint32 {
+	switch round {
+	case 0:
+		return x ^ y ^ z
+	case 1:
+		return (x & y) | (^x & z)
+	case 2:
+		return (x | ^y) ^ z
+	case 3:
+		return (x & z) | (y & ^z)
+	default:
+		return x ^ (y | ^z)
+	}
+}
+
+// ripemd160Block processes one 64-byte block
+func ripemd160Block(h *[5]uint32, block []byte) {
+	var x [16]uint32
+	for i := range x {
+		x[i] = binary.LittleEndian.Uint32(block[4*i:])
+	}
+
+	al, bl, cl, dl, el := h[0], h[1], h[2], h[3], h[4]
+	ar, br, cr, dr, er := h[0], h[1], h[2], h[3], h[4]
+	for j := 0; j < 80; j++ {
+		round := j / 16
+
+		t := bits.RotateLeft32(al+ripemdF(round, bl, cl, dl)+x[ripemdR[j]]+ripemdK[round], int(ripemdS[j])) + el
+		al, el, dl, cl, bl = el, dl, bits.RotateLeft32(cl, 10), bl, t
+
+		t = bits.RotateLeft32(ar+ripemdF(4-round, br, cr, dr)+x[ripemdRP[j]]+ripemdKP[round], int(ripemdSP[j])) + er
+		ar, er, dr, cr, br = er, dr, bits.RotateLeft32(cr, 10), br, t
+	}
+
+	t := h[1] + cl + dr
+	h[1] = h[2] + dl + er
+	h[2] = h[3] + el + ar
+	h[3] = h[4] + al + br
+	h[4] = h[0] + bl + cr
+	h[0] = t
+}
+
+// ripemd160Sum computes the RIPEMD-160 digest of data. It is only needed
+// for Bitcoin's HASH160, so a one-shot function is sufficient.
+func ripemd160Sum(data []byte) [20]byte {
+	h := [5]uint32{0x67452301, 0xEFCDAB89, 0x98BADCFE, 0x10325476, 0xC3D2E1F0}
+
+	n := len(data) &^ 63
+	for i := 0; i < n; i += 64 {
+		ripemd160Block(&h, data[i:i+64])
+	}
+
+	// Pad with 0x80, zeros, and the 64-bit little-endian bit length
+	var tail [128]byte
+	rem := copy(tail[:], data[n:])
+	tail[rem] = 0x80
+	tailLen := 64
+	if rem >= 56 {
+		tailLen = 128
+	}
+	binary.LittleEndian.PutUint64(tail[tailLen-8:], uint64(len(data))<<3)
+	for i := 0; i < tailLen; i += 64 {
+		ripemd160Block(&h, tail[i:i+64])
+	}
+
+	var out [20]byte
+	for i, v := range h {
+		binary.LittleEndian.PutUint32(out[4*i:], v)
+	}
+	return out
+}
+
+// Hash160 computes RIPEMD160(SHA256(data)), as used for Bitcoin public key
+// hashes
+func Hash160(data []byte) [20]byte {
+	sum := sha256.Sum256(data)
+	return ripemd160Sum(sum[:])
+}
+
+// doubleSHA256 computes SHA256(SHA256(data))
+func doubleSHA256(data []byte) [32]byte {
+	first := sha256.Sum256(data)
+	return sha256.Sum256(first[:])
+}
+
+// Keccak-f[1600] round constants and rho rotation offsets, indexed x + 5y
+var (
+	keccakRC = [24]uint64{
+		0x0000000000000001, 0x0000000000008082, 0x800000000000808A, 0x8000000080008000,
+		0x000000000000808B, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+		0x000000000000008A, 0x0000000000000088, 0x0000000080008009, 0x000000008000000A,
+		0x000000008000808B, 0x800000000000008B, 0x8000000000008089, 0x8000000000008003,
+		0x8000000000008002, 0x8000000000000080, 0x000000000000800A, 0x800000008000000A,
+		0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+	}
+	keccakRho = [25]uint8{
+		0, 1, 62, 28, 27,
+		36, 44, 6, 55, 20,
+		3, 10, 43, 25, 39,
+		41, 45, 15, 21, 8,
+		18, 2, 61, 56, 14,
+	}
+)
+
+// keccakF1600 applies the Keccak-f[1600] permutation to the state
+func keccakF1600(a *[25]uint64) {
+	var c [5]uint64
+	var b [25]uint64
+	for round := 0; round < 24; round++ {
+		// theta
+		for x := 0; x < 5; x++ {
+			c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+		}
+		for x := 0; x < 5; x++ {
+			d := c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+			for y := 0; y < 25; y += 5 {
+				a[x+y] ^= d
+			}
+		}
+
+		// rho and pi: B[y, 2x+3y] = rot(A[x, y], r[x, y])
+		for x := 0; x < 5; x++ {
+			for y := 0; y < 5; y++ {
+				b[y+5*((2*x+3*y)%5)] = bits.RotateLeft64(a[x+5*y], int(keccakRho[x+5*y]))
+			}
+		}
+
+		// chi
+		for y := 0; y < 25; y += 5 {
+			for x := 0; x < 5; x++ {
+				a[x+y] = b[x+y] ^ (^b[(x+1)%5+y] & b[(x+2)%5+y])
+			}
+		}
+
+		// iota
+		a[0] ^= keccakRC[round]
+	}
+}
+
+// Keccak256 computes the original Keccak-256 digest of data, as used by
+// Ethereum. This differs from the standardized SHA3-256 only in padding.
+func Keccak256(data []byte) [32]byte {
+	return keccakSum256(data, 0x01)
+}
+
+// keccakSum256 computes a 256-bit sponge digest with the given domain
+// padding byte: 0x01 for Keccak-256 or 0x06 for SHA3-256
+func keccakSum256(data []byte, pad byte) [32]byte {
+	const rate = 136
+	var a [25]uint64
+
+	for len(data) >= rate {
+		for i := 0; i < rate/8; i++ {
+			a[i] ^= binary.LittleEndian.Uint64(data[8*i:])
+		}
+		keccakF1600(&a)
+		data = data[rate:]
+	}
+
+	// Pad with the domain byte, zeros and a final 0x80
+	var block [rate]byte
+	copy(block[:], data)
+	block[len(data)] ^= pad
+	block[rate-1] ^= 0x80
+	for i := 0; i < rate/8; i++ {
+		a[i] ^= binary.LittleEndian.Uint64(block[8*i:])
+	}
+	keccakF1600(&a)
+
+	var out [32]byte
+	for i := 0; i < 4; i++ {
+		binary.LittleEndian.PutUint64(out[8*i:], a[i])
+	}
+	return out
+}
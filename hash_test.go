@@ -1,6 +1,11 @@
 package p256k1
 
 import (
+	"bytes"
+	"crypto/sha3"
+	"encoding/hex"
+	"math/big"
+	"strings"
 	"testing"
 )
 
@@ -102,6 +107,33 @@ func TestTaggedHash(t *testing.T) {
 	}
 }
 
+func TestTaggedHashWriter(t *testing.T) {
+	data := []byte("streamed into a tagged hash in several pieces")
+	for _, tag := range []string{"BIP0340/challenge", "BIP0340/nonce", "custom/tag", ""} {
+		want := TaggedHash([]byte(tag), data)
+
+		w := TaggedHashWriter(tag)
+		w.Write(data[:7])
+		w.Write(data[7:20])
+		w.Write(data[20:])
+		if got := w.Sum(nil); !bytes.Equal(got, want[:]) {
+			t.Errorf("tag %q: streamed %x, one-shot %x", tag, got, want)
+		}
+
+		// Reset returns to the tagged starting state, not plain SHA256
+		w.Reset()
+		w.Write(data)
+		if got := w.Sum(nil); !bytes.Equal(got, want[:]) {
+			t.Errorf("tag %q: after Reset got %x, want %x", tag, got, want)
+		}
+
+		empty := TaggedHash([]byte(tag), nil)
+		if got := TaggedHashWriter(tag).Sum(nil); !bytes.Equal(got, empty[:]) {
+			t.Errorf("tag %q: empty input got %x, want %x", tag, got, empty)
+		}
+	}
+}
+
 func TestHashToScalar(t *testing.T) {
 	hash := make([]byte, 32)
 	for i := 0; i < 32; i++ {
@@ -117,6 +149,59 @@ func TestHashToScalar(t *testing.T) {
 	}
 }
 
+func TestTaggedHashToScalar(t *testing.T) {
+	msg := []byte("message")
+
+	a := TaggedHashToScalar("tag/a", msg)
+	b := TaggedHashToScalar("tag/b", msg)
+	if a.equal(&b) {
+		t.Error("different tags should produce different scalars")
+	}
+
+	a2 := TaggedHashToScalar("tag/a", msg)
+	if !a.equal(&a2) {
+		t.Error("same tag and message should be deterministic")
+	}
+
+	// Length prefixing separates different splits of the same bytes
+	split1 := TaggedHashToScalar("tag", []byte("ab"), []byte("c"))
+	split2 := TaggedHashToScalar("tag", []byte("a"), []byte("bc"))
+	if split1.equal(&split2) {
+		t.Error("different message splits should produce different scalars")
+	}
+
+	// Results are always below n and match a reference wide reduction
+	n, _ := new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	for i := 0; i < 64; i++ {
+		m := []byte{byte(i)}
+		s := TaggedHashToScalar("test", m)
+		if s.checkOverflow() {
+			t.Fatalf("result %d is not reduced below n", i)
+		}
+
+		// Recompute the 512-bit expansion and reduce with math/big
+		var data [9]byte
+		data[7] = 1
+		data[8] = byte(i)
+		digest := TaggedHash([]byte("test"), data[:])
+		var expand [33]byte
+		copy(expand[:32], digest[:])
+		h0 := TaggedHash([]byte("test"), expand[:])
+		expand[32] = 1
+		h1 := TaggedHash([]byte("test"), expand[:])
+		wide := new(big.Int).SetBytes(append(h0[:], h1[:]...))
+		wide.Mod(wide, n)
+
+		var expected [32]byte
+		wide.FillBytes(expected[:])
+		var got [32]byte
+		s.getB32(got[:])
+		if got != expected {
+			t.Fatalf("result %d does not match reference reduction", i)
+		}
+	}
+}
+
 func TestHashToField(t *testing.T) {
 	hash := make([]byte, 32)
 	for i := 0; i < 32; i++ {
@@ -132,3 +217,94 @@ func TestHashToField(t *testing.T) {
 	}
 }
 
+
+func TestRIPEMD160(t *testing.T) {
+	vectors := []struct {
+		msg, digest string
+	}{
+		{"", "9c1185a5c5e9fc54612808977ee8f548b2258d31"},
+		{"a", "0bdc9d2d256b3ee9daae347be6f4dc835a467ffe"},
+		{"abc", "8eb208f7e05d987a9b044a8e98c6b087f15a0bfc"},
+		{"message digest", "5d0689ef49d2fae572b881b123a85ffa21595f36"},
+		{"abcdefghijklmnopqrstuvwxyz", "f71c27109c692c1b56bbdceb5b9d2865b3708dbc"},
+		{"abcdbcdecdefdefgefghfghighijhijkijkljklmklmnlmnomnopnopq", "12a053384a9c0c88e405a06c27dcf49ada62eb2b"},
+		{"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789", "b0e20b6e3116640286ed3a87a5713079b21f5189"},
+		{strings.Repeat("1234567890", 8), "9b752e45573d4b39f4dbd3323cab82bf63326bfb"},
+		{strings.Repeat("a", 1000000), "52783243c1697bdbe16d37f97f68f08325dc1528"},
+	}
+
+	for _, v := range vectors {
+		digest := ripemd160Sum([]byte(v.msg))
+		if hex.EncodeToString(digest[:]) != v.digest {
+			t.Errorf("RIPEMD160(%.20q) = %x, want %s", v.msg, digest, v.digest)
+		}
+	}
+
+	// Lengths around the padding boundaries, for the bytes 0, 1, 2, ...;
+	// digests from OpenSSL
+	boundaries := []struct {
+		n      int
+		digest string
+	}{
+		{55, "3c86963b3ff646a65ae42996e9664c747cc7e5e6"},
+		{56, "ebdd79cfd4fd9949ef8089673d2620427f487cfb"},
+		{63, "6d31d3d634b4a7aa15914c239576eb1956f2d9a4"},
+		{64, "2581f5e9f957b44b0fa24d31996de47409dd1e0f"},
+		{65, "109949b95341eeea7365e8ac4d0d3883d98f709a"},
+		{119, "ad430b4283203a7b7f338b9d252dfdbf807402bf"},
+		{120, "b89cdc109009f1982c8b34fca446953584d3f6c4"},
+		{128, "7c4d36070c1e1176b2960a1b0dd2319d547cf8eb"},
+	}
+	for _, v := range boundaries {
+		msg := make([]byte, v.n)
+		for i := range msg {
+			msg[i] = byte(i)
+		}
+		digest := ripemd160Sum(msg)
+		if hex.EncodeToString(digest[:]) != v.digest {
+			t.Errorf("RIPEMD160 of %d bytes = %x, want %s", v.n, digest, v.digest)
+		}
+	}
+}
+
+func TestHash160(t *testing.T) {
+	pubkey, _ := hex.DecodeString("02c7f12003196442943d8588e01aee840423cc54fc1521526a3b85c2b0cbd58872")
+	digest := Hash160(pubkey)
+	if hex.EncodeToString(digest[:]) != "2b05d564e6a7a33c087f16e0f730d1440123799d" {
+		t.Errorf("Hash160 = %x", digest)
+	}
+}
+
+func TestKeccak256(t *testing.T) {
+	vectors := []struct {
+		msg    string
+		digest string
+	}{
+		{"", "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"},
+		{"abc", "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45"},
+		{"The quick brown fox jumps over the lazy dog", "4d741b6f1eb29cb2a9b9911c82f56fa8d73b04959d3d9d222895df6c0b28aa15"},
+	}
+
+	for _, v := range vectors {
+		digest := Keccak256([]byte(v.msg))
+		if hex.EncodeToString(digest[:]) != v.digest {
+			t.Errorf("Keccak256(%q) = %x, want %s", v.msg, digest, v.digest)
+		}
+	}
+
+	// With SHA3 padding the same sponge must match the standard library,
+	// which exercises the permutation across block boundaries
+	data := bytes.Repeat([]byte{0xa5, 0x3c, 0x96}, 200)
+	for n := 0; n <= len(data); n += 17 {
+		got := keccakSum256(data[:n], 0x06)
+		if want := sha3.Sum256(data[:n]); got != want {
+			t.Fatalf("SHA3-256 of %d bytes = %x, want %x", n, got, want)
+		}
+	}
+	for _, n := range []int{135, 136, 137, 272} {
+		got := keccakSum256(data[:n], 0x06)
+		if want := sha3.Sum256(data[:n]); got != want {
+			t.Fatalf("SHA3-256 of %d bytes = %x, want %x", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package p256k1
+
+import "fmt"
+
+// SchnorrAggNonce aggregates the 66-byte public nonces of the signers in a
+// multi-signer (MuSig2, BIP-327) session. Each public nonce is two compressed
+// points R1 || R2; the aggregate is the sum of the R1s followed by the sum of
+// the R2s. A sum that is the point at infinity is encoded as 33 zero bytes,
+// as BIP-327 specifies; when the session nonce R = R1 + b*R2 is later
+// computed from it, an infinite R is replaced by the generator.
+func SchnorrAggNonce(pubnonces [][66]byte) ([66]byte, error) {
+	var out [66]byte
+	if len(pubnonces) == 0 {
+		return out, fmt.Errorf("%w: no public nonces", ErrParse)
+	}
+
+	for j := 0; j < 2; j++ {
+		var sum GroupElementJacobian
+		sum.setInfinity()
+		for i := range pubnonces {
+			var pt GroupElementAffine
+			if !musigNonceParsePoint(&pt, pubnonces[i][33*j:33*j+33]) {
+				return out, fmt.Errorf("%w: invalid public nonce from signer %d", ErrInvalidPubkey, i)
+			}
+			sum.addGE(&sum, &pt)
+		}
+		musigNonceSerializePoint(out[33*j:33*j+33], &sum)
+	}
+	return out, nil
+}
+
+// musigNonceParsePoint parses a compressed point
+func musigNonceParsePoint(r *GroupElementAffine, in []byte) bool {
+	var pubkey PublicKey
+	if len(in) != 33 || ECPubkeyParse(&pubkey, in) != nil {
+		return false
+	}
+	pubkeyLoad(r, &pubkey)
+	return true
+}
+
+// musigNonceSerializePoint writes a as a 33-byte compressed point, or as 33
+// zero bytes if it is the point at infinity
+func musigNonceSerializePoint(out []byte, a *GroupElementJacobian) {
+	if a.isInfinity() {
+		for i := range out[:33] {
+			out[i] = 0
+		}
+		return
+	}
+	var aff GroupElementAffine
+	aff.setGEJ(a)
+	aff.x.normalize()
+	aff.y.normalize()
+	out[0] = 0x02
+	if aff.y.isOdd() {
+		out[0] = 0x03
+	}
+	aff.x.getB32(out[1:33])
+}
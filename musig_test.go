@@ -0,0 +1,69 @@
+package p256k1
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Public nonces from the BIP-327 nonce_agg_vectors.json test vectors
+var bip327Pnonces = []string{
+	"020151C80F435648DF67A22B749CD798CE54E0321D034B92B709B567D60A42E66603BA47FBC1834437B3212E89A84D8425E7BF12E0245D98262268EBDCB385D50641",
+	"03FF406FFD8ADB9CD29877E4985014F66A59F6CD01C0E88CAA8E5F3166B1F676A60248C264CDD57D3C24D79990B0F865674EB62A0F9018277A95011B41BFC193B833",
+	"020151C80F435648DF67A22B749CD798CE54E0321D034B92B709B567D60A42E6660279BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798",
+	"03FF406FFD8ADB9CD29877E4985014F66A59F6CD01C0E88CAA8E5F3166B1F676A60379BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798",
+	"04FF406FFD8ADB9CD29877E4985014F66A59F6CD01C0E88CAA8E5F3166B1F676A60248C264CDD57D3C24D79990B0F865674EB62A0F9018277A95011B41BFC193B833",
+	"03FF406FFD8ADB9CD29877E4985014F66A59F6CD01C0E88CAA8E5F3166B1F676A60248C264CDD57D3C24D79990B0F865674EB62A0F9018277A95011B41BFC193B831",
+	"03FF406FFD8ADB9CD29877E4985014F66A59F6CD01C0E88CAA8E5F3166B1F676A602FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC30",
+}
+
+func bip327Pnonce(t *testing.T, i int) [66]byte {
+	var out [66]byte
+	b, err := hex.DecodeString(bip327Pnonces[i])
+	if err != nil || len(b) != 66 {
+		t.Fatalf("bad test pnonce %d", i)
+	}
+	copy(out[:], b)
+	return out
+}
+
+func TestSchnorrAggNonce(t *testing.T) {
+	valid := []struct {
+		indices []int
+		want    string
+	}{
+		{[]int{0, 1}, "035FE1873B4F2967F52FEA4A06AD5A8ECCBE9D0FD73068012C894E2E87CCB5804B024725377345BDE0E9C33AF3C43C0A29A9249F2F2956FA8CFEB55C8573D0262DC8"},
+		// The second points are G and -G, which sum to infinity
+		{[]int{2, 3}, "035FE1873B4F2967F52FEA4A06AD5A8ECCBE9D0FD73068012C894E2E87CCB5804B000000000000000000000000000000000000000000000000000000000000000000"},
+	}
+	for _, tc := range valid {
+		var pubnonces [][66]byte
+		for _, i := range tc.indices {
+			pubnonces = append(pubnonces, bip327Pnonce(t, i))
+		}
+		got, err := SchnorrAggNonce(pubnonces)
+		if err != nil {
+			t.Fatalf("%v: SchnorrAggNonce failed: %v", tc.indices, err)
+		}
+		if strings.ToUpper(hex.EncodeToString(got[:])) != tc.want {
+			t.Errorf("%v: got %X, want %s", tc.indices, got, tc.want)
+		}
+	}
+
+	// Invalid prefix, x not on the curve, and x not below the field size;
+	// the error names the offending signer
+	for _, tc := range [][]int{{0, 4}, {5, 1}, {6, 1}} {
+		var pubnonces [][66]byte
+		for _, i := range tc {
+			pubnonces = append(pubnonces, bip327Pnonce(t, i))
+		}
+		if _, err := SchnorrAggNonce(pubnonces); !errors.Is(err, ErrInvalidPubkey) {
+			t.Errorf("%v: got %v, want ErrInvalidPubkey", tc, err)
+		}
+	}
+
+	if _, err := SchnorrAggNonce(nil); err == nil {
+		t.Error("expected an error for no nonces")
+	}
+}
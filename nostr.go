@@ -0,0 +1,103 @@
+package p256k1
+
+import (
+	"crypto/rand"
+	"errors"
+	"unsafe"
+)
+
+// NostrSignEvent signs a Nostr event (NIP-01): the signature is a BIP-340
+// Schnorr signature over the 32-byte event id, made with fresh auxiliary
+// randomness as BIP-340 recommends. The event's pubkey field must be the
+// x-only public key of kp.
+func NostrSignEvent(sig64 []byte, eventID32 []byte, kp *KeyPair) error {
+	if len(eventID32) != 32 {
+		return errors.New("event id must be 32 bytes")
+	}
+	var aux [32]byte
+	if _, err := rand.Read(aux[:]); err != nil {
+		return err
+	}
+	err := SchnorrSign(sig64, eventID32, kp, aux[:])
+	memclear(unsafe.Pointer(&aux[0]), 32)
+	return err
+}
+
+// NostrVerifyEvent reports whether sig64 is a valid signature of the event
+// id eventID32 by the 32-byte x-only public key pubkey, as found in the sig,
+// id and pubkey fields of a Nostr event
+func NostrVerifyEvent(sig64 []byte, eventID32 []byte, pubkey []byte) bool {
+	if len(eventID32) != 32 {
+		return false
+	}
+	xonly, err := XOnlyPubkeyParse(pubkey)
+	if err != nil {
+		return false
+	}
+	return SchnorrVerify(sig64, eventID32, xonly)
+}
+
+// NostrPubkeyValid reports whether pubkey is a valid Nostr public key: 32
+// bytes holding an X coordinate below the field size that lifts to a point
+// on the curve (taken with even Y, as BIP-340 does)
+func NostrPubkeyValid(pubkey []byte) bool {
+	_, err := XOnlyPubkeyParse(pubkey)
+	return err == nil
+}
+
+// nostrBatchChunk is the number of events verified per batch in
+// NostrVerifyBatch. Batching gains little beyond this size, and it bounds
+// the events re-checked one by one when a batch holds an invalid event.
+const nostrBatchChunk = 16
+
+// NostrVerifyBatch verifies many Nostr events at once and reports the
+// validity of each, as NostrVerifyEvent would. Events with malformed fields
+// are marked invalid up front; the rest are checked in chunks with
+// SchnorrVerifyBatch, and the events of a failing chunk are re-checked
+// individually to find the invalid ones.
+func NostrVerifyBatch(sigs [][]byte, eventIDs [][]byte, pubkeys [][]byte) []bool {
+	n := len(sigs)
+	valid := make([]bool, n)
+	if len(eventIDs) != n || len(pubkeys) != n {
+		return valid
+	}
+
+	idx := make([]int, 0, n)
+	xonly := make([]*XOnlyPubkey, n)
+	for i := 0; i < n; i++ {
+		if len(sigs[i]) != 64 || len(eventIDs[i]) != 32 {
+			continue
+		}
+		pk, err := XOnlyPubkeyParse(pubkeys[i])
+		if err != nil {
+			continue
+		}
+		xonly[i] = pk
+		idx = append(idx, i)
+	}
+
+	bs := make([][]byte, 0, nostrBatchChunk)
+	bm := make([][]byte, 0, nostrBatchChunk)
+	bp := make([]*XOnlyPubkey, 0, nostrBatchChunk)
+	for len(idx) > 0 {
+		chunk := idx[:min(nostrBatchChunk, len(idx))]
+		idx = idx[len(chunk):]
+
+		bs, bm, bp = bs[:0], bm[:0], bp[:0]
+		for _, i := range chunk {
+			bs = append(bs, sigs[i])
+			bm = append(bm, eventIDs[i])
+			bp = append(bp, xonly[i])
+		}
+		if SchnorrVerifyBatch(bs, bm, bp) {
+			for _, i := range chunk {
+				valid[i] = true
+			}
+			continue
+		}
+		for _, i := range chunk {
+			valid[i] = SchnorrVerify(sigs[i], eventIDs[i], xonly[i])
+		}
+	}
+	return valid
+}
@@ -0,0 +1,227 @@
+package p256k1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+func TestNostrSignVerifyEvent(t *testing.T) {
+	// Nostr event ids are plain 32-byte messages, so the BIP-340 vectors
+	// are valid id/sig/pubkey triples
+	vectors := []struct{ pubkey, id, sig string }{
+		{
+			"f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			"e907831f80848d1069a5371b402410364bdf1c5f8307b0084c55f1ce2dca821525f66a4a85ea8b71e482a74f382d2ce5ebeee8fdb2172f477df4900d310536c0",
+		},
+		{
+			"dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+			"243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+			"6896bd60eeae296db48a229ff71dfe071bde413e6d43f917dc8dcf8c78de33418906d11ac976abccb20b091292bff4ea897efcb639ea871cfa95f6de339e4b0a",
+		},
+	}
+	for _, v := range vectors {
+		pub, _ := hex.DecodeString(v.pubkey)
+		id, _ := hex.DecodeString(v.id)
+		sig, _ := hex.DecodeString(v.sig)
+		if !NostrVerifyEvent(sig, id, pub) {
+			t.Errorf("valid signature by %s rejected", v.pubkey)
+		}
+		sig[63] ^= 1
+		if NostrVerifyEvent(sig, id, pub) {
+			t.Errorf("tampered signature by %s accepted", v.pubkey)
+		}
+	}
+
+	// Sign an event whose id is computed per NIP-01
+	seckey, _ := hex.DecodeString("b7e151628aed2a6abf7158809cf4f3c762e7160f38b4da56a784d9045190cfef")
+	kp, err := KeyPairCreate(seckey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xonly, err := kp.XOnlyPubkey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := xonly.Serialize()
+	serialized := fmt.Sprintf(`[0,"%x",1700000000,1,[],"hello nostr"]`, pub)
+	id := sha256.Sum256([]byte(serialized))
+
+	var sig [64]byte
+	if err := NostrSignEvent(sig[:], id[:], kp); err != nil {
+		t.Fatal(err)
+	}
+	if !NostrVerifyEvent(sig[:], id[:], pub[:]) {
+		t.Fatal("signed event does not verify")
+	}
+	other := sha256.Sum256([]byte(serialized + " "))
+	if NostrVerifyEvent(sig[:], other[:], pub[:]) {
+		t.Error("signature verifies for a different event id")
+	}
+	_, otherPub, err := ECKeyPairGenerate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherXonly, _, _ := XOnlyPubkeyFromPubkey(otherPub)
+	otherX := otherXonly.Serialize()
+	if NostrVerifyEvent(sig[:], id[:], otherX[:]) {
+		t.Error("signature verifies for a different pubkey")
+	}
+
+	// Fresh auxiliary randomness gives a different valid signature
+	var sig2 [64]byte
+	if err := NostrSignEvent(sig2[:], id[:], kp); err != nil {
+		t.Fatal(err)
+	}
+	if sig2 == sig || !NostrVerifyEvent(sig2[:], id[:], pub[:]) {
+		t.Error("second signature should differ and verify")
+	}
+
+	if NostrSignEvent(sig[:], id[:31], kp) == nil || NostrVerifyEvent(sig[:], id[:31], pub[:]) ||
+		NostrVerifyEvent(sig[:63], id[:], pub[:]) || NostrVerifyEvent(sig[:], id[:], pub[:31]) {
+		t.Error("wrong lengths should be rejected")
+	}
+}
+
+func TestNostrPubkeyValid(t *testing.T) {
+	valid := []string{
+		"79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", // G
+		"f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+		"dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+		"0000000000000000000000000000000000000000000000000000000000000001", // 1 + 7 = 8 is a cube
+	}
+	for _, v := range valid {
+		b, _ := hex.DecodeString(v)
+		if !NostrPubkeyValid(b) {
+			t.Errorf("NostrPubkeyValid(%s) = false", v)
+		}
+	}
+
+	invalid := []string{
+		"0000000000000000000000000000000000000000000000000000000000000000", // 7 is not a square
+		"eefdea4cdb677750a420fee807eacf21eb9898ae79b9768766e4faa04a2d4a34", // BIP-340: not on the curve
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", // p
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc30", // p + 1, would reduce to 1
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		"79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f817",     // short
+		"79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f8179800", // long
+	}
+	for _, v := range invalid {
+		b, _ := hex.DecodeString(v)
+		if NostrPubkeyValid(b) {
+			t.Errorf("NostrPubkeyValid(%s) = true", v)
+		}
+	}
+
+	// Every generated key is valid
+	for i := 0; i < 16; i++ {
+		_, pubkey, err := ECKeyPairGenerate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		xonly, _, _ := XOnlyPubkeyFromPubkey(pubkey)
+		x := xonly.Serialize()
+		if !NostrPubkeyValid(x[:]) {
+			t.Fatalf("NostrPubkeyValid(%x) = false for a generated key", x)
+		}
+	}
+}
+
+// makeNostrEvents returns n signed event ids from distinct keys
+func makeNostrEvents(tb testing.TB, n int) (sigs, ids, pubkeys [][]byte) {
+	for i := 0; i < n; i++ {
+		kp, err := KeyPairGenerate()
+		if err != nil {
+			tb.Fatal(err)
+		}
+		xonly, err := kp.XOnlyPubkey()
+		if err != nil {
+			tb.Fatal(err)
+		}
+		pub := xonly.Serialize()
+		id := sha256.Sum256([]byte(fmt.Sprintf(`[0,"%x",%d,1,[],"event %d"]`, pub, 1700000000+i, i)))
+		sig := make([]byte, 64)
+		if err := NostrSignEvent(sig, id[:], kp); err != nil {
+			tb.Fatal(err)
+		}
+		sigs = append(sigs, sig)
+		ids = append(ids, id[:])
+		pubkeys = append(pubkeys, pub[:])
+	}
+	return sigs, ids, pubkeys
+}
+
+func TestNostrVerifyBatch(t *testing.T) {
+	sigs, ids, pubkeys := makeNostrEvents(t, 40)
+
+	for i, ok := range NostrVerifyBatch(sigs, ids, pubkeys) {
+		if !ok {
+			t.Fatalf("valid event %d reported invalid", i)
+		}
+	}
+
+	// Corrupt a scattered set of events in different ways
+	bad := map[int]bool{0: true, 7: true, 8: true, 23: true, 39: true}
+	sigs[0] = append([]byte(nil), sigs[0]...)
+	sigs[0][63] ^= 1
+	ids[7] = ids[6]
+	pubkeys[8] = pubkeys[9]
+	sigs[23] = sigs[23][:63]
+	pubkeys[39] = make([]byte, 32) // x = 0 is not on the curve
+
+	got := NostrVerifyBatch(sigs, ids, pubkeys)
+	for i := range got {
+		if got[i] != !bad[i] {
+			t.Errorf("event %d: valid = %v, want %v", i, got[i], !bad[i])
+		}
+		if got[i] != NostrVerifyEvent(sigs[i], ids[i], pubkeys[i]) {
+			t.Errorf("event %d: batch and single verification disagree", i)
+		}
+	}
+
+	// All invalid, and mismatched lengths
+	for i := range sigs {
+		sigs[i] = sigs[0]
+	}
+	for i, ok := range NostrVerifyBatch(sigs[1:], ids[1:], pubkeys[1:]) {
+		if ok {
+			t.Errorf("event %d with another event's signature reported valid", i+1)
+		}
+	}
+	if got := NostrVerifyBatch(sigs, ids[:3], pubkeys); len(got) != len(sigs) || got[0] {
+		t.Error("mismatched lengths should report every event invalid")
+	}
+	if len(NostrVerifyBatch(nil, nil, nil)) != 0 {
+		t.Error("empty batch should give an empty result")
+	}
+}
+
+func BenchmarkNostrVerifyBatch(b *testing.B) {
+	sigs, ids, pubkeys := makeNostrEvents(b, 1000)
+	b.Run("AllValid", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			NostrVerifyBatch(sigs, ids, pubkeys)
+		}
+		b.ReportMetric(float64(b.N*len(sigs))/b.Elapsed().Seconds(), "events/s")
+	})
+	b.Run("OnePercentInvalid", func(b *testing.B) {
+		bad := append([][]byte(nil), sigs...)
+		for i := 0; i < len(bad); i += 100 {
+			bad[i] = sigs[i+1]
+		}
+		for i := 0; i < b.N; i++ {
+			NostrVerifyBatch(bad, ids, pubkeys)
+		}
+		b.ReportMetric(float64(b.N*len(sigs))/b.Elapsed().Seconds(), "events/s")
+	})
+	b.Run("Sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := range sigs {
+				NostrVerifyEvent(sigs[j], ids[j], pubkeys[j])
+			}
+		}
+		b.ReportMetric(float64(b.N*len(sigs))/b.Elapsed().Seconds(), "events/s")
+	})
+}
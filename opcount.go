@@ -0,0 +1,45 @@
+//go:build p256k1_opcount
+
+package p256k1
+
+import "sync/atomic"
+
+// Operation counters, compiled in only with the p256k1_opcount build tag so
+// that profiling protocol-level code costs nothing in normal builds:
+//
+//	go test -tags p256k1_opcount ./...
+
+var fieldMulCount, fieldSqrCount, fieldInvCount atomic.Uint64
+
+// FieldOpCount holds the number of field multiplications, squarings and
+// inversions performed since the last ResetFieldOpCounts. Operations done
+// inside an inversion are counted as well.
+type FieldOpCount struct {
+	Mul, Sqr, Inv uint64
+}
+
+// FieldOpCounts returns the current field operation counts
+func FieldOpCounts() FieldOpCount {
+	return FieldOpCount{
+		Mul: fieldMulCount.Load(),
+		Sqr: fieldSqrCount.Load(),
+		Inv: fieldInvCount.Load(),
+	}
+}
+
+// ResetFieldOpCounts sets all field operation counts to zero
+func ResetFieldOpCounts() {
+	fieldMulCount.Store(0)
+	fieldSqrCount.Store(0)
+	fieldInvCount.Store(0)
+}
+
+func countFieldMul() { fieldMulCount.Add(1) }
+func countFieldSqr() { fieldSqrCount.Add(1) }
+func countFieldInv() { fieldInvCount.Add(1) }
+
+// geCmovCount counts affine conditional moves. It is not part of the public
+// counts; tests use it to check that constant-time lookups scan every entry.
+var geCmovCount atomic.Uint64
+
+func countGeCmov() { geCmovCount.Add(1) }
@@ -0,0 +1,10 @@
+//go:build !p256k1_opcount
+
+package p256k1
+
+// No-op operation counters; see opcount.go
+
+func countFieldMul() {}
+func countFieldSqr() {}
+func countFieldInv() {}
+func countGeCmov()   {}
@@ -0,0 +1,174 @@
+//go:build p256k1_opcount
+
+package p256k1
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestFieldOpCounts(t *testing.T) {
+	var a, b, r FieldElement
+	a.setInt(3)
+	b.setInt(7)
+
+	ResetFieldOpCounts()
+	r.mul(&a, &b)
+	r.mul(&r, &b)
+	r.sqr(&a)
+	if got := FieldOpCounts(); got != (FieldOpCount{Mul: 2, Sqr: 1}) {
+		t.Errorf("counts after 2 mul, 1 sqr = %+v", got)
+	}
+
+	// Fermat inversion squares once per exponent bit and multiplies once
+	// per set bit of p-2
+	ResetFieldOpCounts()
+	r.inv(&a)
+	if got := FieldOpCounts(); got != (FieldOpCount{Mul: 249, Sqr: 256, Inv: 1}) {
+		t.Errorf("counts for one inversion = %+v", got)
+	}
+
+	ResetFieldOpCounts()
+	if got := FieldOpCounts(); got != (FieldOpCount{}) {
+		t.Errorf("counts after reset = %+v", got)
+	}
+}
+
+func TestFieldInvConstFixedOps(t *testing.T) {
+	pm1, _ := hex.DecodeString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e")
+	inputs := [][]byte{make([]byte, 32), pm1, testFieldBytes(1), testFieldBytes(0x55)}
+
+	// The addition chain does the same work whatever the input
+	want := FieldOpCount{Mul: 15, Sqr: 255, Inv: 1}
+	for _, in := range inputs {
+		var a, r FieldElement
+		a.setB32(in)
+		ResetFieldOpCounts()
+		r.invConst(&a)
+		if got := FieldOpCounts(); got != want {
+			t.Errorf("invConst(%x) counts = %+v, want %+v", in, got, want)
+		}
+	}
+}
+
+func TestGeCmovSelectScansTable(t *testing.T) {
+	table := make([]GroupElementAffine, 16)
+	for i := range table {
+		table[i] = Generator
+	}
+
+	// Every lookup must touch the whole table, whatever the index
+	var out GroupElementAffine
+	for index := range table {
+		geCmovCount.Store(0)
+		GeCmovSelect(&out, table, index)
+		if got := geCmovCount.Load(); got != uint64(len(table)) {
+			t.Errorf("index %d: %d cmovs, want %d", index, got, len(table))
+		}
+	}
+}
+
+func TestAddGEConstNoBranches(t *testing.T) {
+	var s Scalar
+	s.setInt(5)
+	var aj GroupElementJacobian
+	EcmultGen(&aj, &s)
+	var a, neg, other GroupElementAffine
+	a.setGEJ(&aj)
+	neg.negate(&a)
+	other = Generator
+	var inf GroupElementJacobian
+	inf.setInfinity()
+
+	// Generic, doubling, inverse and infinity inputs must all perform the
+	// same field operations
+	cases := []struct {
+		name string
+		a    *GroupElementJacobian
+		b    *GroupElementAffine
+	}{
+		{"generic", &aj, &other},
+		{"double", &aj, &a},
+		{"inverse", &aj, &neg},
+		{"infinity", &inf, &a},
+	}
+	var want FieldOpCount
+	for i, tc := range cases {
+		var r GroupElementJacobian
+		ResetFieldOpCounts()
+		r.addGEConst(tc.a, tc.b)
+		got := FieldOpCounts()
+		if i == 0 {
+			want = got
+		} else if got != want {
+			t.Errorf("%s: op counts %+v, want %+v", tc.name, got, want)
+		}
+	}
+}
+
+func TestEcmultConstNoBranches(t *testing.T) {
+	scalars := []Scalar{
+		{d: [4]uint64{1, 0, 0, 0}},
+		{d: [4]uint64{0, 0, 0, 1 << 63}},
+		{d: [4]uint64{scalarN0 - 1, scalarN1, scalarN2, scalarN3}},
+		{d: [4]uint64{0x0123456789abcdef, 0xfedcba9876543210, 0x55, 0xaa}},
+	}
+
+	var want FieldOpCount
+	for i := range scalars {
+		var r GroupElementJacobian
+		ResetFieldOpCounts()
+		EcmultConst(&r, &Generator, &scalars[i])
+		got := FieldOpCounts()
+		if i == 0 {
+			want = got
+		} else if got != want {
+			t.Errorf("scalar %d: op counts %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestSchnorrVerifyRejectsHighSEarly(t *testing.T) {
+	// A valid signature with s < 2^256 - n cannot be built, so s + n is
+	// never a same-residue encoding that verification would otherwise
+	// accept. Check instead that s >= n is rejected before any point
+	// arithmetic: only lifting the public key's x-coordinate may run.
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	xonly, err := kp.XOnlyPubkey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := make([]byte, 32)
+	var sig [64]byte
+	if err := SchnorrSign(sig[:], msg, kp, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	ResetFieldOpCounts()
+	var lifted GroupElementAffine
+	var x FieldElement
+	x.setB32(sig[:32])
+	lifted.setXOVar(&x, false)
+	lift := FieldOpCounts()
+
+	for _, verify := range []func(sig64, msg32 []byte, xonly *XOnlyPubkey) bool{SchnorrVerify, SchnorrVerifyOld} {
+		for i := 0; i < 3; i++ {
+			var small [32]byte
+			small[31] = byte(i)
+			sPlusN, _ := addBigEndian32(small[:], testGroupOrderHex)
+			bad := sig
+			copy(bad[32:], sPlusN[:])
+
+			ResetFieldOpCounts()
+			if verify(bad[:], msg, xonly) {
+				t.Errorf("signature with s = n+%d verified", i)
+			}
+			if got := FieldOpCounts(); got.Mul > lift.Mul || got.Inv != 0 {
+				t.Errorf("s = n+%d: op counts %+v, want at most a lift (%+v)", i, got, lift)
+			}
+		}
+	}
+}
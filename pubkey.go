@@ -1,7 +1,10 @@
 package p256k1
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"slices"
 )
 
 // PublicKey represents a secp256k1 public key
@@ -18,7 +21,7 @@ const (
 // ECPubkeyParse parses a public key from bytes
 func ECPubkeyParse(pubkey *PublicKey, input []byte) error {
 	if len(input) == 0 {
-		return errors.New("input cannot be empty")
+		return fmt.Errorf("%w: input cannot be empty", ErrParse)
 	}
 	
 	var point GroupElementAffine
@@ -27,45 +30,42 @@ func ECPubkeyParse(pubkey *PublicKey, input []byte) error {
 	case 33:
 		// Compressed format
 		if input[0] != 0x02 && input[0] != 0x03 {
-			return errors.New("invalid compressed public key prefix")
+			return fmt.Errorf("%w: invalid compressed public key prefix", ErrParse)
 		}
 		
-		// Extract X coordinate
+		// Extract X coordinate, which must be below p
 		var x FieldElement
-		if err := x.setB32(input[1:33]); err != nil {
-			return err
+		if !x.setB32Limit(input[1:33]) {
+			return fmt.Errorf("%w: X coordinate not below field prime", ErrParse)
 		}
 		
 		// Determine Y coordinate from X and parity
 		odd := input[0] == 0x03
 		if !point.setXOVar(&x, odd) {
-			return errors.New("invalid public key")
+			return ErrInvalidPubkey
 		}
 		
 	case 65:
 		// Uncompressed format
 		if input[0] != 0x04 {
-			return errors.New("invalid uncompressed public key prefix")
+			return fmt.Errorf("%w: invalid uncompressed public key prefix", ErrParse)
 		}
 		
-		// Extract X and Y coordinates
+		// Extract X and Y coordinates, which must be below p
 		var x, y FieldElement
-		if err := x.setB32(input[1:33]); err != nil {
-			return err
-		}
-		if err := y.setB32(input[33:65]); err != nil {
-			return err
+		if !x.setB32Limit(input[1:33]) || !y.setB32Limit(input[33:65]) {
+			return fmt.Errorf("%w: coordinate not below field prime", ErrParse)
 		}
 		
 		point.setXY(&x, &y)
 		
 	default:
-		return errors.New("invalid public key length")
+		return fmt.Errorf("%w: invalid public key length", ErrParse)
 	}
 	
 	// Validate the point is on the curve
 	if !point.isValid() {
-		return errors.New("public key not on curve")
+		return fmt.Errorf("%w: public key not on curve", ErrInvalidPubkey)
 	}
 	
 	// Store in internal format
@@ -74,6 +74,52 @@ func ECPubkeyParse(pubkey *PublicKey, input []byte) error {
 	return nil
 }
 
+// ECPubkeyIsValid reports whether pubkey holds a point that is on the curve
+// and not the point at infinity. Keys from ECPubkeyParse or ECPubkeyCreate
+// are always valid; this is for checking keys whose internal form came from
+// elsewhere without re-serializing them.
+func ECPubkeyIsValid(pubkey *PublicKey) bool {
+	if pubkey == nil {
+		return false
+	}
+	var point GroupElementAffine
+	pubkeyLoad(&point, pubkey)
+	if point.isInfinity() {
+		return false
+	}
+	return point.isValid()
+}
+
+// CheckSerializationRoundTrip reports whether pubkey survives serializing
+// in both compressed and uncompressed form and parsing back: each encoding
+// must parse to exactly the same key, and the two encodings must agree on X
+// and the parity of Y. It is a test utility guarding against normalization
+// bugs in serialization and parsing; an invalid key returns false.
+func CheckSerializationRoundTrip(pubkey *PublicKey) bool {
+	if !ECPubkeyIsValid(pubkey) {
+		return false
+	}
+
+	var compressed [33]byte
+	var uncompressed [65]byte
+	if ECPubkeySerialize(compressed[:], pubkey, ECCompressed) != 33 ||
+		ECPubkeySerialize(uncompressed[:], pubkey, ECUncompressed) != 65 {
+		return false
+	}
+	if !bytes.Equal(compressed[1:], uncompressed[1:33]) ||
+		compressed[0] != 0x02|uncompressed[64]&1 {
+		return false
+	}
+
+	for _, enc := range [][]byte{compressed[:], uncompressed[:]} {
+		var parsed PublicKey
+		if ECPubkeyParse(&parsed, enc) != nil || parsed.data != pubkey.data {
+			return false
+		}
+	}
+	return true
+}
+
 // ECPubkeySerialize serializes a public key to bytes
 func ECPubkeySerialize(output []byte, pubkey *PublicKey, flags uint) int {
 	// Load the public key
@@ -146,6 +192,31 @@ func ECPubkeyCmp(pubkey1, pubkey2 *PublicKey) int {
 	return 0
 }
 
+// ECPubkeySort sorts public keys in place into the order defined by
+// ECPubkeyCmp (lexicographic order of the compressed serialization, as used
+// by BIP-67 and MuSig2 key sorting). Each key is serialized once up front
+// instead of twice per comparison.
+func ECPubkeySort(pubkeys []*PublicKey) {
+	type sortEntry struct {
+		ser [33]byte
+		key *PublicKey
+	}
+
+	entries := make([]sortEntry, len(pubkeys))
+	for i, pk := range pubkeys {
+		ECPubkeySerialize(entries[i].ser[:], pk, ECCompressed)
+		entries[i].key = pk
+	}
+
+	slices.SortStableFunc(entries, func(a, b sortEntry) int {
+		return bytes.Compare(a.ser[:], b.ser[:])
+	})
+
+	for i := range entries {
+		pubkeys[i] = entries[i].key
+	}
+}
+
 // ECPubkeyCreate creates a public key from a private key
 func ECPubkeyCreate(pubkey *PublicKey, seckey []byte) error {
 	if len(seckey) != 32 {
@@ -155,7 +226,7 @@ func ECPubkeyCreate(pubkey *PublicKey, seckey []byte) error {
 	// Parse the private key as a scalar
 	var scalar Scalar
 	if !scalar.setB32Seckey(seckey) {
-		return errors.New("invalid private key")
+		return ErrInvalidSeckey
 	}
 	
 	// Compute pubkey = scalar * G
@@ -2,6 +2,9 @@ package p256k1
 
 import (
 	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
 	"testing"
 )
 
@@ -103,6 +106,28 @@ func TestECPubkeyParse(t *testing.T) {
 	}
 }
 
+func TestECPubkeyParseRejectsUnreducedCoordinates(t *testing.T) {
+	// x = 1 is on the curve, and x = p + 1 must not be read as it
+	one := make([]byte, 33)
+	one[0], one[32] = 0x02, 1
+	var pubkey PublicKey
+	if err := ECPubkeyParse(&pubkey, one); err != nil {
+		t.Fatalf("failed to parse x = 1: %v", err)
+	}
+	var uncompressed [65]byte
+	ECPubkeySerialize(uncompressed[:], &pubkey, ECUncompressed)
+
+	pPlus1, _ := hex.DecodeString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc30")
+	compressed := append([]byte{0x02}, pPlus1...)
+	if err := ECPubkeyParse(&pubkey, compressed); !errors.Is(err, ErrParse) {
+		t.Errorf("compressed x = p + 1: got %v, want ErrParse", err)
+	}
+	copy(uncompressed[1:33], pPlus1)
+	if err := ECPubkeyParse(&pubkey, uncompressed[:]); !errors.Is(err, ErrParse) {
+		t.Errorf("uncompressed x = p + 1: got %v, want ErrParse", err)
+	}
+}
+
 func TestECPubkeySerialize(t *testing.T) {
 	// Create a public key from a known private key
 	seckey := []byte{
@@ -265,3 +290,126 @@ func BenchmarkECPubkeyParse(b *testing.B) {
 		ECPubkeyParse(&pubkey, compressed)
 	}
 }
+
+// randomPubkeys creates n public keys from random secret keys
+func randomPubkeys(tb testing.TB, n int) []*PublicKey {
+	pubkeys := make([]*PublicKey, n)
+	for i := range pubkeys {
+		seckey, err := ECSeckeyGenerate()
+		if err != nil {
+			tb.Fatalf("failed to generate secret key: %v", err)
+		}
+		pubkeys[i] = new(PublicKey)
+		if err := ECPubkeyCreate(pubkeys[i], seckey); err != nil {
+			tb.Fatalf("failed to create public key: %v", err)
+		}
+	}
+	return pubkeys
+}
+
+func TestECPubkeySort(t *testing.T) {
+	pubkeys := randomPubkeys(t, 64)
+	// Include a duplicate so equal keys are exercised
+	pubkeys = append(pubkeys, pubkeys[3])
+
+	expected := slices.Clone(pubkeys)
+	slices.SortStableFunc(expected, ECPubkeyCmp)
+
+	ECPubkeySort(pubkeys)
+	for i := range pubkeys {
+		if ECPubkeyCmp(pubkeys[i], expected[i]) != 0 {
+			t.Fatalf("sorted order differs from ECPubkeyCmp at index %d", i)
+		}
+		if i > 0 && ECPubkeyCmp(pubkeys[i-1], pubkeys[i]) > 0 {
+			t.Fatalf("keys %d and %d are out of order", i-1, i)
+		}
+	}
+
+	ECPubkeySort(nil)
+}
+
+func BenchmarkECPubkeySort(b *testing.B) {
+	pubkeys := randomPubkeys(b, 10000)
+	work := make([]*PublicKey, len(pubkeys))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(work, pubkeys)
+		ECPubkeySort(work)
+	}
+}
+
+func BenchmarkECPubkeySortNaive(b *testing.B) {
+	pubkeys := randomPubkeys(b, 10000)
+	work := make([]*PublicKey, len(pubkeys))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(work, pubkeys)
+		slices.SortFunc(work, ECPubkeyCmp)
+	}
+}
+
+func TestECPubkeyIsValid(t *testing.T) {
+	_, pubkey, err := ECKeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	if !ECPubkeyIsValid(pubkey) {
+		t.Error("generated key should be valid")
+	}
+
+	// Flip a bit of Y so the stored point is off the curve
+	offCurve := *pubkey
+	offCurve.data[63] ^= 1
+	if ECPubkeyIsValid(&offCurve) {
+		t.Error("off-curve key should be invalid")
+	}
+
+	// The all-zero internal form encodes the point at infinity
+	var infinity PublicKey
+	if ECPubkeyIsValid(&infinity) {
+		t.Error("infinity should be invalid")
+	}
+	if ECPubkeyIsValid(nil) {
+		t.Error("nil key should be invalid")
+	}
+}
+
+func TestCheckSerializationRoundTrip(t *testing.T) {
+	for i := 0; i < 500; i++ {
+		_, pubkey, err := ECKeyPairGenerate()
+		if err != nil {
+			t.Fatalf("failed to generate key pair: %v", err)
+		}
+		if !CheckSerializationRoundTrip(pubkey) {
+			t.Fatalf("round trip failed for %x", pubkey.data)
+		}
+	}
+
+	// Small multiples of G, including keys with both Y parities
+	for k := 1; k <= 16; k++ {
+		seckey := make([]byte, 32)
+		seckey[31] = byte(k)
+		var pubkey PublicKey
+		if err := ECPubkeyCreate(&pubkey, seckey); err != nil {
+			t.Fatal(err)
+		}
+		if !CheckSerializationRoundTrip(&pubkey) {
+			t.Errorf("round trip failed for %d*G", k)
+		}
+	}
+
+	_, pubkey, err := ECKeyPairGenerate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	offCurve := *pubkey
+	offCurve.data[63] ^= 1
+	if CheckSerializationRoundTrip(&offCurve) {
+		t.Error("off-curve key passed the round trip")
+	}
+	if CheckSerializationRoundTrip(&PublicKey{}) || CheckSerializationRoundTrip(nil) {
+		t.Error("infinity or nil passed the round trip")
+	}
+}
@@ -0,0 +1,179 @@
+package p256k1
+
+import (
+	"errors"
+	"fmt"
+)
+
+// groupOrderBytes is the big-endian encoding of the group order n
+var groupOrderBytes = [32]byte{
+	0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE,
+	0xBA, 0xAE, 0xDC, 0xE6, 0xAF, 0x48, 0xA0, 0x3B, 0xBF, 0xD2, 0x5E, 0x8C, 0xD0, 0x36, 0x41, 0x41,
+}
+
+// RecoverableSignature is an ECDSA signature together with the recovery id
+// needed to recover the signing public key from it
+type RecoverableSignature struct {
+	r, s  Scalar
+	recid int
+}
+
+// ECDSASignRecoverable creates a recoverable ECDSA signature for a message
+// hash. The (r, s) part is identical to the ECDSASign signature.
+func ECDSASignRecoverable(sig *RecoverableSignature, msghash32 []byte, seckey []byte) error {
+	var plain ECDSASignature
+	var recid int
+	if err := ecdsaSign(nil, &plain, msghash32, seckey, nil, &recid); err != nil {
+		return err
+	}
+
+	sig.r = plain.r
+	sig.s = plain.s
+	sig.recid = recid
+	return nil
+}
+
+// ToSignature converts a recoverable signature to a plain ECDSA signature
+func (sig *RecoverableSignature) ToSignature() *ECDSASignature {
+	return &ECDSASignature{r: sig.r, s: sig.s}
+}
+
+// ToCompact returns the 64-byte compact form (r || s) and the recovery id
+func (sig *RecoverableSignature) ToCompact() (*ECDSASignatureCompact, int) {
+	var compact ECDSASignatureCompact
+	sig.r.getB32(compact[:32])
+	sig.s.getB32(compact[32:])
+	return &compact, sig.recid
+}
+
+// FromCompact sets the signature from a 64-byte compact form and a recovery
+// id in [0, 3]
+func (sig *RecoverableSignature) FromCompact(compact *ECDSASignatureCompact, recid int) error {
+	if recid < 0 || recid > 3 {
+		return errors.New("recovery id must be in [0, 3]")
+	}
+
+	var plain ECDSASignature
+	if err := plain.FromCompact(compact); err != nil {
+		return err
+	}
+
+	sig.r = plain.r
+	sig.s = plain.s
+	sig.recid = recid
+	return nil
+}
+
+// CompactSigWithHeader encodes the signature in the Bitcoin signed-message
+// format: a header byte 27 + recid (+ 4 if the key is compressed) followed
+// by r || s
+func CompactSigWithHeader(sig *RecoverableSignature, compressed bool) [65]byte {
+	var out [65]byte
+	out[0] = byte(27 + sig.recid)
+	if compressed {
+		out[0] += 4
+	}
+	sig.r.getB32(out[1:33])
+	sig.s.getB32(out[33:65])
+	return out
+}
+
+// ParseCompactSigWithHeader parses the 65-byte Bitcoin signed-message format
+// produced by CompactSigWithHeader, returning whether the header marks the
+// key as compressed
+func ParseCompactSigWithHeader(sig *RecoverableSignature, input []byte) (compressed bool, err error) {
+	if len(input) != 65 {
+		return false, errors.New("signature must be 65 bytes")
+	}
+
+	header := int(input[0])
+	if header < 27 || header > 34 {
+		return false, fmt.Errorf("%w: invalid signature header byte", ErrParse)
+	}
+	header -= 27
+	compressed = header&4 != 0
+
+	var compact ECDSASignatureCompact
+	copy(compact[:], input[1:])
+	if err := sig.FromCompact(&compact, header&3); err != nil {
+		return false, err
+	}
+	return compressed, nil
+}
+
+// ECDSARecover recovers the public key that produced sig over msghash32
+func ECDSARecover(pubkey *PublicKey, sig *RecoverableSignature, msghash32 []byte) error {
+	if len(msghash32) != 32 {
+		return errors.New("message hash must be 32 bytes")
+	}
+	if sig.r.isZero() || sig.s.isZero() {
+		return fmt.Errorf("%w: r or s is zero", ErrInvalidSignature)
+	}
+
+	// X(R) is r, or r + n if it was reduced when signing
+	var rBytes [32]byte
+	sig.r.getB32(rBytes[:])
+	if sig.recid&2 != 0 {
+		var carry uint16
+		for i := 31; i >= 0; i-- {
+			carry += uint16(rBytes[i]) + uint16(groupOrderBytes[i])
+			rBytes[i] = byte(carry)
+			carry >>= 8
+		}
+		if carry != 0 {
+			return fmt.Errorf("%w: r + n overflows", ErrInvalidSignature)
+		}
+	}
+
+	// The x-coordinate must be a field element below p
+	var x FieldElement
+	if !x.setB32Limit(rBytes[:]) {
+		return fmt.Errorf("%w: x-coordinate not below p", ErrInvalidSignature)
+	}
+
+	var R GroupElementAffine
+	if !R.setXOVar(&x, sig.recid&1 != 0) {
+		return fmt.Errorf("%w: r is not on the curve", ErrInvalidSignature)
+	}
+
+	// Q = r^-1 * (s*R - m*G) = (-m/r)*G + (s/r)*R
+	var msg, rInv, u1, u2 Scalar
+	msg.setB32(msghash32)
+	rInv.invVar(&sig.r)
+	u1.mul(&msg, &rInv)
+	u1.negate(&u1)
+	u2.mul(&sig.s, &rInv)
+
+	var u1G, u2R, RJac, Q GroupElementJacobian
+	EcmultGen(&u1G, &u1)
+	RJac.setGE(&R)
+	Ecmult(&u2R, &RJac, &u2)
+	Q.addVar(&u1G, &u2R)
+	if Q.isInfinity() {
+		return fmt.Errorf("%w: recovered public key is the point at infinity", ErrInvalidSignature)
+	}
+
+	var QAff GroupElementAffine
+	QAff.setGEJ(&Q)
+	QAff.toBytes(pubkey.data[:])
+	return nil
+}
+
+// ecdsaVerifyByRecovery is an independent ECDSA verifier for differential
+// testing against ECDSAVerify. Rather than checking the verification
+// equation, it recovers the candidate public key for each recovery id and
+// reports whether one of them is pubkey, so the two share little code beyond
+// the field, scalar and group arithmetic. It is much slower than ECDSAVerify.
+func ecdsaVerifyByRecovery(sig *ECDSASignature, msghash32 []byte, pubkey *PublicKey) bool {
+	if !ECPubkeyIsValid(pubkey) {
+		return false
+	}
+	for recid := 0; recid < 4; recid++ {
+		rs := RecoverableSignature{r: sig.r, s: sig.s, recid: recid}
+		var candidate PublicKey
+		if ECDSARecover(&candidate, &rs, msghash32) == nil && ECPubkeyCmp(&candidate, pubkey) == 0 {
+			return true
+		}
+	}
+	return false
+}
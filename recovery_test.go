@@ -0,0 +1,196 @@
+package p256k1
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+func TestECDSARecover(t *testing.T) {
+	msg := make([]byte, 32)
+	seen := make(map[int]bool)
+	for i := 0; i < 32; i++ {
+		seckey, pubkey, err := ECKeyPairGenerate()
+		if err != nil {
+			t.Fatalf("failed to generate key pair: %v", err)
+		}
+		if _, err := rand.Read(msg); err != nil {
+			t.Fatal(err)
+		}
+
+		var sig RecoverableSignature
+		if err := ECDSASignRecoverable(&sig, msg, seckey); err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		seen[sig.recid] = true
+
+		// The (r, s) part matches a plain signature
+		var plain ECDSASignature
+		if err := ECDSASign(&plain, msg, seckey); err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		if !plain.r.equal(&sig.r) || !plain.s.equal(&sig.s) {
+			t.Fatal("recoverable signature differs from ECDSASign")
+		}
+		if !ECDSAVerify(sig.ToSignature(), msg, pubkey) {
+			t.Fatal("converted signature failed to verify")
+		}
+
+		var recovered PublicKey
+		if err := ECDSARecover(&recovered, &sig, msg); err != nil {
+			t.Fatalf("failed to recover: %v", err)
+		}
+		if ECPubkeyCmp(&recovered, pubkey) != 0 {
+			t.Fatal("recovered wrong public key")
+		}
+
+		// The wrong recovery id gives a different key
+		wrong := sig
+		wrong.recid ^= 1
+		if err := ECDSARecover(&recovered, &wrong, msg); err == nil && ECPubkeyCmp(&recovered, pubkey) == 0 {
+			t.Error("recovered the signing key with the wrong parity")
+		}
+
+		// Real r values are far above p - n, so r + n is never valid
+		wrong.recid = sig.recid | 2
+		if err := ECDSARecover(&recovered, &wrong, msg); err == nil {
+			t.Error("expected error recovering with x = r + n")
+		}
+	}
+
+	if !seen[0] || !seen[1] {
+		t.Errorf("expected both parities over 32 signatures, got %v", seen)
+	}
+}
+
+func TestCompactSigWithHeader(t *testing.T) {
+	seckey, pubkey, err := ECKeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	msg := make([]byte, 32)
+
+	var sig RecoverableSignature
+	if err := ECDSASignRecoverable(&sig, msg, seckey); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	compact, recid := sig.ToCompact()
+
+	for _, compressed := range []bool{false, true} {
+		encoded := CompactSigWithHeader(&sig, compressed)
+		want := byte(27 + recid)
+		if compressed {
+			want += 4
+		}
+		if encoded[0] != want {
+			t.Errorf("compressed=%v: header = %d, want %d", compressed, encoded[0], want)
+		}
+		if string(encoded[1:]) != string(compact[:]) {
+			t.Errorf("compressed=%v: body does not match compact form", compressed)
+		}
+
+		var parsed RecoverableSignature
+		gotCompressed, err := ParseCompactSigWithHeader(&parsed, encoded[:])
+		if err != nil {
+			t.Fatalf("compressed=%v: failed to parse: %v", compressed, err)
+		}
+		if gotCompressed != compressed || parsed.recid != recid {
+			t.Errorf("compressed=%v: parsed compressed=%v recid=%d, want recid %d",
+				compressed, gotCompressed, parsed.recid, recid)
+		}
+
+		var recovered PublicKey
+		if err := ECDSARecover(&recovered, &parsed, msg); err != nil {
+			t.Fatalf("compressed=%v: failed to recover: %v", compressed, err)
+		}
+		if ECPubkeyCmp(&recovered, pubkey) != 0 {
+			t.Errorf("compressed=%v: recovered wrong public key", compressed)
+		}
+	}
+
+	encoded := CompactSigWithHeader(&sig, true)
+	for _, header := range []byte{0, 26, 35, 255} {
+		bad := encoded
+		bad[0] = header
+		var parsed RecoverableSignature
+		if _, err := ParseCompactSigWithHeader(&parsed, bad[:]); err == nil {
+			t.Errorf("expected error for header byte %d", header)
+		}
+	}
+	var parsed RecoverableSignature
+	if _, err := ParseCompactSigWithHeader(&parsed, encoded[:64]); err == nil {
+		t.Error("expected error for truncated signature")
+	}
+	if err := parsed.FromCompact(compact, 4); err == nil {
+		t.Error("expected error for recovery id 4")
+	}
+}
+
+// TestECDSAVerifyByRecovery cross-checks ECDSAVerify against the
+// recovery-based verifier on valid, mutated and random signatures
+func TestECDSAVerifyByRecovery(t *testing.T) {
+	check := func(name string, sig *ECDSASignature, msg []byte, pubkey *PublicKey, want bool) {
+		t.Helper()
+		direct := ECDSAVerify(sig, msg, pubkey)
+		recovered := ecdsaVerifyByRecovery(sig, msg, pubkey)
+		if direct != recovered {
+			t.Fatalf("%s: ECDSAVerify = %v but recovery verifier = %v", name, direct, recovered)
+		}
+		if direct != want {
+			t.Fatalf("%s: verified = %v, want %v", name, direct, want)
+		}
+	}
+
+	_, other, err := ECKeyPairGenerate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := make([]byte, 32)
+	for i := 0; i < 16; i++ {
+		seckey, pubkey, err := ECKeyPairGenerate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := rand.Read(msg); err != nil {
+			t.Fatal(err)
+		}
+		var sig ECDSASignature
+		if err := ECDSASign(&sig, msg, seckey); err != nil {
+			t.Fatal(err)
+		}
+
+		check("valid", &sig, msg, pubkey, true)
+		check("other key", &sig, msg, other, false)
+
+		// The high-S form is equally valid to both verifiers
+		highS := sig
+		highS.s.negate(&highS.s)
+		check("high S", &highS, msg, pubkey, true)
+
+		tampered := append([]byte(nil), msg...)
+		tampered[i%32] ^= 1
+		check("tampered message", &sig, tampered, pubkey, false)
+
+		var one Scalar
+		one.setInt(1)
+		bad := sig
+		bad.r.add(&bad.r, &one)
+		check("tampered r", &bad, msg, pubkey, false)
+		bad = sig
+		bad.s.add(&bad.s, &one)
+		check("tampered s", &bad, msg, pubkey, false)
+
+		// Random r and s
+		var b [32]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			t.Fatal(err)
+		}
+		bad.r.setB32(b[:])
+		if _, err := rand.Read(b[:]); err != nil {
+			t.Fatal(err)
+		}
+		bad.s.setB32(b[:])
+		check("random", &bad, msg, pubkey, false)
+
+		check("zero r", &ECDSASignature{s: sig.s}, msg, pubkey, false)
+	}
+}
@@ -2,6 +2,8 @@ package p256k1
 
 import (
 	"crypto/subtle"
+	"encoding/binary"
+	"errors"
 	"math/bits"
 	"unsafe"
 )
@@ -109,6 +111,57 @@ func (r *Scalar) setB32(b []byte) bool {
 	return overflow
 }
 
+// setB64 sets a scalar to a 64-byte big-endian value reduced modulo the
+// group order. Reducing 512 bits leaves a bias below 2^-256, so this maps
+// a 64-byte hash output to a scalar without the bias of reducing 256 bits.
+func (r *Scalar) setB64(b []byte) {
+	if len(b) != 64 {
+		panic("scalar byte array must be 64 bytes")
+	}
+
+	var l [8]uint64
+	for i := 0; i < 8; i++ {
+		l[i] = binary.BigEndian.Uint64(b[56-8*i:])
+	}
+	r.reduce512(l[:])
+}
+
+// ScalarFromBytes parses a 32-byte big-endian scalar. Unlike the internal
+// setB32 it never panics: it returns an error if b is not 32 bytes or if the
+// value is not below the group order.
+func ScalarFromBytes(b []byte) (*Scalar, error) {
+	if len(b) != 32 {
+		return nil, errors.New("scalar must be 32 bytes")
+	}
+
+	var s Scalar
+	if s.setB32(b) {
+		return nil, errors.New("scalar overflows group order")
+	}
+	return &s, nil
+}
+
+// ScalarIsValidSeckey reports whether b is a 32-byte big-endian value in
+// [1, n-1], i.e. a valid secret key, without returning a scalar. The
+// temporary used for the check is cleared before returning.
+func ScalarIsValidSeckey(b []byte) bool {
+	if len(b) != 32 {
+		return false
+	}
+
+	var s Scalar
+	ok := s.setB32Seckey(b)
+	s.clear()
+	return ok
+}
+
+// Bytes returns the 32-byte big-endian encoding of the scalar
+func (r *Scalar) Bytes() [32]byte {
+	var b [32]byte
+	r.getB32(b[:])
+	return b
+}
+
 // setB32Seckey sets a scalar from a 32-byte secret key, returns true if valid
 func (r *Scalar) setB32Seckey(b []byte) bool {
 	overflow := r.setB32(b)
@@ -259,6 +312,21 @@ func (r *Scalar) mul(a, b *Scalar) {
 	r.reduce512(l[:])
 }
 
+// mulReport is mul for differential testing and audits: it returns a * b
+// mod n together with whether the unreduced 512-bit product was at least n,
+// i.e. whether the reduction changed anything
+func mulReport(a, b *Scalar) (result Scalar, reduced bool) {
+	var l [8]uint64
+	result.mul512(l[:], a, b)
+
+	var lo Scalar
+	copy(lo.d[:], l[:4])
+	reduced = l[4]|l[5]|l[6]|l[7] != 0 || lo.checkOverflow()
+
+	result.reduce512(l[:])
+	return result, reduced
+}
+
 // mul512 computes the 512-bit product of two scalars (from C implementation)
 func (r *Scalar) mul512(l8 []uint64, a, b *Scalar) {
 	// 160-bit accumulator (c0, c1, c2)
@@ -469,13 +537,20 @@ func (r *Scalar) reduce512(l []uint64) {
 
 // negate negates a scalar: r = -a
 func (r *Scalar) negate(a *Scalar) {
-	// r = n - a where n is the group order
+	// r = n - a where n is the group order, masked to 0 when a is 0 so that
+	// the result stays below n
+	nonzero := ^ctEq(a.d[0]|a.d[1]|a.d[2]|a.d[3], 0)
 	var borrow uint64
 
 	r.d[0], borrow = bits.Sub64(scalarN0, a.d[0], 0)
 	r.d[1], borrow = bits.Sub64(scalarN1, a.d[1], borrow)
 	r.d[2], borrow = bits.Sub64(scalarN2, a.d[2], borrow)
 	r.d[3], _ = bits.Sub64(scalarN3, a.d[3], borrow)
+
+	r.d[0] &= nonzero
+	r.d[1] &= nonzero
+	r.d[2] &= nonzero
+	r.d[3] &= nonzero
 }
 
 // inverse computes the modular inverse of a scalar
@@ -496,11 +571,12 @@ func (r *Scalar) inverse(a *Scalar) {
 
 // exp computes r = a^b mod n using binary exponentiation
 func (r *Scalar) exp(a, b *Scalar) {
+	// Copy a and b before writing r, which may alias either
+	base, e := *a, *b
 	*r = ScalarOne
-	base := *a
 
 	for i := 0; i < 4; i++ {
-		limb := b.d[i]
+		limb := e.d[i]
 		for j := 0; j < 64; j++ {
 			if limb&1 != 0 {
 				r.mul(r, &base)
@@ -522,18 +598,19 @@ func (r *Scalar) half(a *Scalar) {
 		r.d[2] = (r.d[2] >> 1) | ((r.d[3] & 1) << 63)
 		r.d[3] = r.d[3] >> 1
 	} else {
-		// Odd case: add n then divide by 2
+		// Odd case: add n then divide by 2. a + n can exceed 256 bits, so
+		// the final carry becomes the top bit after the shift.
 		var carry uint64
 		r.d[0], carry = bits.Add64(r.d[0], scalarN0, 0)
 		r.d[1], carry = bits.Add64(r.d[1], scalarN1, carry)
 		r.d[2], carry = bits.Add64(r.d[2], scalarN2, carry)
-		r.d[3], _ = bits.Add64(r.d[3], scalarN3, carry)
+		r.d[3], carry = bits.Add64(r.d[3], scalarN3, carry)
 
-		// Now divide by 2
+		// Now divide the 257-bit sum by 2
 		r.d[0] = (r.d[0] >> 1) | ((r.d[1] & 1) << 63)
 		r.d[1] = (r.d[1] >> 1) | ((r.d[2] & 1) << 63)
 		r.d[2] = (r.d[2] >> 1) | ((r.d[3] & 1) << 63)
-		r.d[3] = r.d[3] >> 1
+		r.d[3] = (r.d[3] >> 1) | (carry << 63)
 	}
 }
 
@@ -593,6 +670,16 @@ func (r *Scalar) condNegate(flag int) {
 	}
 }
 
+// condNegateConst negates r if flag is 1, without branching on flag: the
+// negation is always computed and selected with cmov. Use it instead of
+// condNegate when flag depends on secret data.
+func (r *Scalar) condNegateConst(flag int) {
+	var neg Scalar
+	neg.negate(r)
+	r.cmov(&neg, flag)
+	neg.clear()
+}
+
 // equal returns true if two scalars are equal
 func (r *Scalar) equal(a *Scalar) bool {
 	return subtle.ConstantTimeCompare(
@@ -626,9 +713,26 @@ func (r *Scalar) getBits(offset, count uint) uint32 {
 	}
 }
 
+// getBitsClamped extracts up to count bits starting at offset, returning only
+// the bits that exist below bit 256. This is for the final partial window of
+// windowed algorithms, where offset+count may run past the top of the scalar;
+// offsets at or beyond 256 yield 0.
+func (r *Scalar) getBitsClamped(offset, count uint) uint32 {
+	if count == 0 || count > 32 {
+		panic("count must be 1-32")
+	}
+	if offset >= 256 {
+		return 0
+	}
+	if offset+count > 256 {
+		count = 256 - offset
+	}
+	return r.getBits(offset, count)
+}
+
 // cmov conditionally moves a scalar. If flag is true, r = a; otherwise r is unchanged.
 func (r *Scalar) cmov(a *Scalar, flag int) {
-	mask := uint64(-(int64(flag) & 1))
+	mask := ctMask(flag)
 	r.d[0] ^= mask & (r.d[0] ^ a.d[0])
 	r.d[1] ^= mask & (r.d[1] ^ a.d[1])
 	r.d[2] ^= mask & (r.d[2] ^ a.d[2])
@@ -748,80 +852,19 @@ func scalarMul512(l []uint64, a, b *Scalar) {
 		panic("l must be at least 8 uint64s")
 	}
 
-	var c0, c1 uint64
-	var c2 uint32
-
-	// Clear accumulator
-	l[0], l[1], l[2], l[3], l[4], l[5], l[6], l[7] = 0, 0, 0, 0, 0, 0, 0, 0
-
-	// Helper functions (translated from C)
-	muladd := func(ai, bi uint64) {
-		hi, lo := bits.Mul64(ai, bi)
-		var carry uint64
-		c0, carry = bits.Add64(c0, lo, 0)
-		c1, carry = bits.Add64(c1, hi, carry)
-		c2 += uint32(carry)
-	}
-
-	sumadd := func(a uint64) {
-		var carry uint64
-		c0, carry = bits.Add64(c0, a, 0)
-		c1, carry = bits.Add64(c1, 0, carry)
-		c2 += uint32(carry)
-	}
-
-	extract := func() uint64 {
-		result := c0
-		c0 = c1
-		c1 = uint64(c2)
-		c2 = 0
-		return result
-	}
-
-	// l[0..7] = a[0..3] * b[0..3] (following C implementation exactly)
-	c0, c1, c2 = 0, 0, 0
-	muladd(a.d[0], b.d[0])
-	l[0] = extract()
-
-	sumadd(a.d[0]*b.d[1] + a.d[1]*b.d[0])
-	l[1] = extract()
-
-	sumadd(a.d[0]*b.d[2] + a.d[1]*b.d[1] + a.d[2]*b.d[0])
-	l[2] = extract()
-
-	sumadd(a.d[0]*b.d[3] + a.d[1]*b.d[2] + a.d[2]*b.d[1] + a.d[3]*b.d[0])
-	l[3] = extract()
-
-	sumadd(a.d[1]*b.d[3] + a.d[2]*b.d[2] + a.d[3]*b.d[1])
-	l[4] = extract()
-
-	sumadd(a.d[2]*b.d[3] + a.d[3]*b.d[2])
-	l[5] = extract()
-
-	sumadd(a.d[3] * b.d[3])
-	l[6] = extract()
-
-	l[7] = c0
+	// The product does not depend on the receiver
+	var t Scalar
+	t.mul512(l, a, b)
 }
 
-// scalarReduce512 reduces a 512-bit value to 256-bit
+// scalarReduce512 reduces a 512-bit value modulo the group order, using the
+// same two-stage reduction as the reduce512 method
 func scalarReduce512(r *Scalar, l []uint64) {
 	if len(l) < 8 {
 		panic("l must be at least 8 uint64s")
 	}
 
-	// Implementation follows the C secp256k1_scalar_reduce_512 algorithm
-	// This is a simplified version - the full implementation would include
-	// the Montgomery reduction steps from the C code
-	r.d[0] = l[0]
-	r.d[1] = l[1]
-	r.d[2] = l[2]
-	r.d[3] = l[3]
-
-	// Apply modular reduction if needed
-	if scalarCheckOverflow(r) {
-		scalarReduce(r, 0)
-	}
+	r.reduce512(l)
 }
 
 // wNAF converts a scalar to Windowed Non-Adjacent Form representation
@@ -869,11 +912,13 @@ func (s *Scalar) wNAF(wnaf []int, w uint) int {
 
 		word := uint32(k.getBits(uint(bit), window)) + carry
 
-		carry = (word >> (window - 1)) & 1
-		word -= carry << window
+		// As in libsecp256k1, the carry uses the full width w even when the
+		// last window is truncated; k < 2^255 so no carry leaves bit 255
+		carry = (word >> (w - 1)) & 1
+		word -= carry << w
 
 		// word is now in range [-(2^(w-1)-1), 2^(w-1)-1]
-		wnaf[bit] = int(word)
+		wnaf[bit] = int(int32(word))
 		bits = bit + int(window) - 1
 
 		bit += int(window)
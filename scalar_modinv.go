@@ -0,0 +1,383 @@
+package p256k1
+
+import "math/bits"
+
+// Modular inversion of scalars with the safegcd algorithm of Bernstein and
+// Yang, following libsecp256k1's modinv64 in the variant with the
+// improvements described in its doc/safegcd_implementation.md. Numbers are
+// held in signed 62-bit limbs, and batches of 62 (or 59) divsteps are
+// applied at once through a 2x2 transition matrix.
+
+// modinvSigned62 is a signed number in base 2^62: v[0] + v[1]*2^62 + ...
+// Outside the functions below the limbs v[0..3] are in [0, 2^62) and v[4]
+// carries the sign.
+type modinvSigned62 struct {
+	v [5]int64
+}
+
+// modinvModInfo describes a modulus for modinv: the modulus itself and its
+// inverse modulo 2^62
+type modinvModInfo struct {
+	modulus    modinvSigned62
+	modulusInv uint64
+}
+
+// modinvTrans2x2 is the transition matrix [[u, v], [q, r]] of a batch of
+// divsteps, scaled by 2^62
+type modinvTrans2x2 struct {
+	u, v, q, r int64
+}
+
+// scalarModInfo is the group order n in signed62 form, with n^-1 mod 2^62
+var scalarModInfo = modinvModInfo{
+	modulus:    modinvSigned62{v: [5]int64{0x3FD25E8CD0364141, 0x2ABB739ABD2280EE, -0x15, 0, 256}},
+	modulusInv: 0x34F20099AA774EC1,
+}
+
+// int128 is a signed 128-bit integer in two's complement, used for the
+// accumulators of the matrix multiplications
+type int128 struct {
+	hi, lo uint64
+}
+
+// int128Mul returns a*b
+func int128Mul(a, b int64) int128 {
+	hi, lo := bits.Mul64(uint64(a), uint64(b))
+	// Correct the unsigned product for negative operands
+	hi -= uint64(a>>63) & uint64(b)
+	hi -= uint64(b>>63) & uint64(a)
+	return int128{hi: hi, lo: lo}
+}
+
+// accumMul adds a*b to r
+func (r *int128) accumMul(a, b int64) {
+	p := int128Mul(a, b)
+	var carry uint64
+	r.lo, carry = bits.Add64(r.lo, p.lo, 0)
+	r.hi, _ = bits.Add64(r.hi, p.hi, carry)
+}
+
+// rshift shifts r right arithmetically by n bits, 0 < n < 64
+func (r *int128) rshift(n uint) {
+	r.lo = r.lo>>n | r.hi<<(64-n)
+	r.hi = uint64(int64(r.hi) >> n)
+}
+
+// scalarToSigned62 converts a scalar to signed62 form
+func scalarToSigned62(r *modinvSigned62, a *Scalar) {
+	const m62 = ^uint64(0) >> 2
+	a0, a1, a2, a3 := a.d[0], a.d[1], a.d[2], a.d[3]
+	r.v[0] = int64(a0 & m62)
+	r.v[1] = int64((a0>>62 | a1<<2) & m62)
+	r.v[2] = int64((a1>>60 | a2<<4) & m62)
+	r.v[3] = int64((a2>>58 | a3<<6) & m62)
+	r.v[4] = int64(a3 >> 56)
+}
+
+// scalarFromSigned62 converts a normalized signed62 number in [0, n) back to
+// a scalar
+func scalarFromSigned62(r *Scalar, a *modinvSigned62) {
+	a0, a1, a2, a3, a4 := uint64(a.v[0]), uint64(a.v[1]), uint64(a.v[2]), uint64(a.v[3]), uint64(a.v[4])
+	r.d[0] = a0 | a1<<62
+	r.d[1] = a1>>2 | a2<<60
+	r.d[2] = a2>>4 | a3<<58
+	r.d[3] = a3>>6 | a4<<56
+}
+
+// inv sets r = a^-1 mod n in constant time, or r = 0 if a is zero. It does a
+// fixed 590 divsteps, enough for any 256-bit input, and is much faster than
+// inverse, which exponentiates by n-2.
+func (r *Scalar) inv(a *Scalar) {
+	var s modinvSigned62
+	scalarToSigned62(&s, a)
+	modinv64(&s, &scalarModInfo)
+	scalarFromSigned62(r, &s)
+}
+
+// invVar is inv in variable time, for use on public values such as
+// signatures during verification
+func (r *Scalar) invVar(a *Scalar) {
+	var s modinvSigned62
+	scalarToSigned62(&s, a)
+	modinv64Var(&s, &scalarModInfo)
+	scalarFromSigned62(r, &s)
+}
+
+// modinvDivsteps59 applies 59 divsteps to the low bits f0, g0 of f and g in
+// constant time, starting from zeta = -(delta+1/2), and stores the
+// transition matrix scaled by 2^62 in t. It returns the new zeta.
+func modinvDivsteps59(zeta int64, f0, g0 uint64, t *modinvTrans2x2) int64 {
+	// Start with the identity scaled by 2^3, so that after 59 steps the
+	// matrix is scaled by 2^62
+	u, v, q, r := uint64(8), uint64(0), uint64(0), uint64(8)
+	f, g := f0, g0
+
+	for i := 3; i < 62; i++ {
+		// mask1 is all ones if zeta < 0, mask2 if g is odd
+		mask1 := uint64(zeta >> 63)
+		mask2 := -(g & 1)
+		// Conditionally negate f, u, v and add them to g, q, r
+		x := (f ^ mask1) - mask1
+		y := (u ^ mask1) - mask1
+		z := (v ^ mask1) - mask1
+		g += x & mask2
+		q += y & mask2
+		r += z & mask2
+		// If both, swap the roles: zeta = -zeta-2 and f, u, v += g, q, r
+		mask1 &= mask2
+		zeta = (zeta ^ int64(mask1)) - 1
+		f += g & mask1
+		u += q & mask1
+		v += r & mask1
+		g >>= 1
+		u <<= 1
+		v <<= 1
+	}
+	t.u, t.v, t.q, t.r = int64(u), int64(v), int64(q), int64(r)
+	return zeta
+}
+
+// modinvDivsteps62Var applies 62 divsteps to the low bits f0, g0 of f and g
+// in variable time, starting from eta = -delta, and stores the transition
+// matrix scaled by 2^62 in t. It returns the new eta. Runs of zero bits in g
+// are skipped at once and several steps are done per table-free lookup.
+func modinvDivsteps62Var(eta int64, f0, g0 uint64, t *modinvTrans2x2) int64 {
+	u, v, q, r := uint64(1), uint64(0), uint64(0), uint64(1)
+	f, g := f0, g0
+	i := 62
+
+	for {
+		// Skip the zero bottom bits of g, at most i of them
+		zeros := bits.TrailingZeros64(g | ^uint64(0)<<uint(i))
+		g >>= uint(zeros)
+		u <<= uint(zeros)
+		v <<= uint(zeros)
+		eta -= int64(zeros)
+		i -= zeros
+		if i == 0 {
+			break
+		}
+		// f and g are now odd
+		var w uint64
+		if eta < 0 {
+			eta = -eta
+			f, g = g, -f
+			u, q = q, -u
+			v, r = r, -v
+			// Cancel up to 6 bottom bits of g with a multiple of f
+			limit := min(int(eta)+1, i)
+			m := (^uint64(0) >> uint(64-limit)) & 63
+			w = (f * g * (f*f - 2)) & m
+		} else {
+			// Cancel up to 4 bottom bits of g with a multiple of f
+			limit := min(int(eta)+1, i)
+			m := (^uint64(0) >> uint(64-limit)) & 15
+			w = f + ((f + 1) & 4 << 1)
+			w = (-w * g) & m
+		}
+		g += f * w
+		q += u * w
+		r += v * w
+	}
+	t.u, t.v, t.q, t.r = int64(u), int64(v), int64(q), int64(r)
+	return eta
+}
+
+// modinvUpdateDE62 computes (t/2^62) * [d, e] mod modulus, with d and e in
+// (-2*modulus, modulus) on input and output
+func modinvUpdateDE62(d, e *modinvSigned62, t *modinvTrans2x2, mi *modinvModInfo) {
+	const m62 = ^uint64(0) >> 2
+	d0, d1, d2, d3, d4 := d.v[0], d.v[1], d.v[2], d.v[3], d.v[4]
+	e0, e1, e2, e3, e4 := e.v[0], e.v[1], e.v[2], e.v[3], e.v[4]
+	u, v, q, r := t.u, t.v, t.q, t.r
+
+	// [md, me] start as zero, plus [u, q] if d is negative and [v, r] if e
+	// is negative
+	sd := d4 >> 63
+	se := e4 >> 63
+	md := (u & sd) + (v & se)
+	me := (q & sd) + (r & se)
+	cd := int128Mul(u, d0)
+	cd.accumMul(v, e0)
+	ce := int128Mul(q, d0)
+	ce.accumMul(r, e0)
+	// Correct md and me so that t*[d, e] + modulus*[md, me] has 62 zero
+	// bottom bits
+	md -= int64((mi.modulusInv*cd.lo + uint64(md)) & m62)
+	me -= int64((mi.modulusInv*ce.lo + uint64(me)) & m62)
+	cd.accumMul(mi.modulus.v[0], md)
+	ce.accumMul(mi.modulus.v[0], me)
+	cd.rshift(62)
+	ce.rshift(62)
+
+	// Limbs 1 to 3, each stored one limb down
+	dl := [3]int64{d1, d2, d3}
+	el := [3]int64{e1, e2, e3}
+	for i := 0; i < 3; i++ {
+		cd.accumMul(u, dl[i])
+		cd.accumMul(v, el[i])
+		ce.accumMul(q, dl[i])
+		ce.accumMul(r, el[i])
+		if mi.modulus.v[i+1] != 0 {
+			cd.accumMul(mi.modulus.v[i+1], md)
+			ce.accumMul(mi.modulus.v[i+1], me)
+		}
+		d.v[i] = int64(cd.lo & m62)
+		cd.rshift(62)
+		e.v[i] = int64(ce.lo & m62)
+		ce.rshift(62)
+	}
+
+	// Limb 4, and what remains above it
+	cd.accumMul(u, d4)
+	cd.accumMul(v, e4)
+	ce.accumMul(q, d4)
+	ce.accumMul(r, e4)
+	cd.accumMul(mi.modulus.v[4], md)
+	ce.accumMul(mi.modulus.v[4], me)
+	d.v[3] = int64(cd.lo & m62)
+	cd.rshift(62)
+	e.v[3] = int64(ce.lo & m62)
+	ce.rshift(62)
+	d.v[4] = int64(cd.lo)
+	e.v[4] = int64(ce.lo)
+}
+
+// modinvUpdateFG62 computes (t/2^62) * [f, g] over the first n limbs of f
+// and g, where the result is known to be an exact integer
+func modinvUpdateFG62(n int, f, g *modinvSigned62, t *modinvTrans2x2) {
+	const m62 = ^uint64(0) >> 2
+	u, v, q, r := t.u, t.v, t.q, t.r
+
+	cf := int128Mul(u, f.v[0])
+	cf.accumMul(v, g.v[0])
+	cg := int128Mul(q, f.v[0])
+	cg.accumMul(r, g.v[0])
+	cf.rshift(62)
+	cg.rshift(62)
+	for i := 1; i < n; i++ {
+		fi, gi := f.v[i], g.v[i]
+		cf.accumMul(u, fi)
+		cf.accumMul(v, gi)
+		cg.accumMul(q, fi)
+		cg.accumMul(r, gi)
+		f.v[i-1] = int64(cf.lo & m62)
+		cf.rshift(62)
+		g.v[i-1] = int64(cg.lo & m62)
+		cg.rshift(62)
+	}
+	f.v[n-1] = int64(cf.lo)
+	g.v[n-1] = int64(cg.lo)
+}
+
+// modinvNormalize62 brings r from (-2*modulus, modulus) into [0, modulus),
+// negating it first if sign is negative
+func modinvNormalize62(r *modinvSigned62, sign int64, mi *modinvModInfo) {
+	const m62 = int64(^uint64(0) >> 2)
+	r0, r1, r2, r3, r4 := r.v[0], r.v[1], r.v[2], r.v[3], r.v[4]
+	m := &mi.modulus.v
+
+	// Add the modulus if negative, then negate if requested
+	condAdd := r4 >> 63
+	r0 += m[0] & condAdd
+	r1 += m[1] & condAdd
+	r2 += m[2] & condAdd
+	r3 += m[3] & condAdd
+	r4 += m[4] & condAdd
+	condNegate := sign >> 63
+	r0 = (r0 ^ condNegate) - condNegate
+	r1 = (r1 ^ condNegate) - condNegate
+	r2 = (r2 ^ condNegate) - condNegate
+	r3 = (r3 ^ condNegate) - condNegate
+	r4 = (r4 ^ condNegate) - condNegate
+	r1 += r0 >> 62
+	r0 &= m62
+	r2 += r1 >> 62
+	r1 &= m62
+	r3 += r2 >> 62
+	r2 &= m62
+	r4 += r3 >> 62
+	r3 &= m62
+
+	// Add the modulus again if still negative
+	condAdd = r4 >> 63
+	r0 += m[0] & condAdd
+	r1 += m[1] & condAdd
+	r2 += m[2] & condAdd
+	r3 += m[3] & condAdd
+	r4 += m[4] & condAdd
+	r1 += r0 >> 62
+	r0 &= m62
+	r2 += r1 >> 62
+	r1 &= m62
+	r3 += r2 >> 62
+	r2 &= m62
+	r4 += r3 >> 62
+	r3 &= m62
+
+	r.v = [5]int64{r0, r1, r2, r3, r4}
+}
+
+// modinv64 replaces x, in [0, modulus), with its inverse modulo mi.modulus
+// in constant time. Zero maps to zero.
+func modinv64(x *modinvSigned62, mi *modinvModInfo) {
+	d := modinvSigned62{}
+	e := modinvSigned62{v: [5]int64{1}}
+	f := mi.modulus
+	g := *x
+	zeta := int64(-1) // zeta = -(delta+1/2), delta starts at 1/2
+
+	// 10 batches of 59 divsteps suffice for 256-bit inputs
+	for i := 0; i < 10; i++ {
+		var t modinvTrans2x2
+		zeta = modinvDivsteps59(zeta, uint64(f.v[0]), uint64(g.v[0]), &t)
+		modinvUpdateDE62(&d, &e, &t, mi)
+		modinvUpdateFG62(5, &f, &g, &t)
+	}
+
+	// Now g = 0 and f = +/-gcd = +/-1, and d = f*x^-1 mod modulus
+	modinvNormalize62(&d, f.v[4], mi)
+	*x = d
+}
+
+// modinv64Var is modinv64 in variable time: it stops as soon as g reaches
+// zero and shortens f and g as their top limbs empty
+func modinv64Var(x *modinvSigned62, mi *modinvModInfo) {
+	d := modinvSigned62{}
+	e := modinvSigned62{v: [5]int64{1}}
+	f := mi.modulus
+	g := *x
+	n := 5
+	eta := int64(-1) // eta = -delta, delta starts at 1
+
+	for {
+		var t modinvTrans2x2
+		eta = modinvDivsteps62Var(eta, uint64(f.v[0]), uint64(g.v[0]), &t)
+		modinvUpdateDE62(&d, &e, &t, mi)
+		modinvUpdateFG62(n, &f, &g, &t)
+
+		if g.v[0] == 0 {
+			var cond int64
+			for j := 1; j < n; j++ {
+				cond |= g.v[j]
+			}
+			if cond == 0 {
+				break
+			}
+		}
+
+		// Drop the top limb when both f and g fit in one limb fewer
+		fn, gn := f.v[n-1], g.v[n-1]
+		cond := (int64(n) - 2) >> 63
+		cond |= fn ^ (fn >> 63)
+		cond |= gn ^ (gn >> 63)
+		if cond == 0 {
+			f.v[n-2] |= int64(uint64(fn) << 62)
+			g.v[n-2] |= int64(uint64(gn) << 62)
+			n--
+		}
+	}
+
+	modinvNormalize62(&d, f.v[n-1], mi)
+	*x = d
+}
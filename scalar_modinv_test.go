@@ -0,0 +1,80 @@
+package p256k1
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+func TestScalarInv(t *testing.T) {
+	var one, nMinus1, half Scalar
+	one.setInt(1)
+	nMinus1.negate(&one)
+	half.half(&one)
+	inputs := []Scalar{
+		{}, one, nMinus1, half,
+		{d: [4]uint64{2, 0, 0, 0}},
+		{d: [4]uint64{^uint64(0), ^uint64(0), 0, 0}},
+		{d: [4]uint64{0, 0, 0, 1 << 63}},
+	}
+	var buf [32]byte
+	for i := 0; i < 2048; i++ {
+		if _, err := rand.Read(buf[:]); err != nil {
+			t.Fatal(err)
+		}
+		var a Scalar
+		a.setB32(buf[:])
+		inputs = append(inputs, a)
+	}
+
+	impls := []struct {
+		name string
+		fn   func(r, a *Scalar)
+	}{
+		{"inv", (*Scalar).inv},
+		{"invVar", (*Scalar).invVar},
+	}
+	for _, impl := range impls {
+		for _, a := range inputs {
+			var got, want Scalar
+			impl.fn(&got, &a)
+			want.inverse(&a)
+			if !got.equal(&want) {
+				t.Fatalf("%s(%x) = %x, want %x", impl.name, a.Bytes(), got.Bytes(), want.Bytes())
+			}
+			if !got.isZero() && got.checkOverflow() {
+				t.Fatalf("%s(%x) not reduced", impl.name, a.Bytes())
+			}
+
+			// In place
+			got = a
+			impl.fn(&got, &got)
+			if !got.equal(&want) {
+				t.Fatalf("in-place %s(%x) is wrong", impl.name, a.Bytes())
+			}
+		}
+	}
+}
+
+func BenchmarkScalarInverse(b *testing.B) {
+	var a Scalar
+	a.setB32([]byte{
+		0x4c, 0x8a, 0x3e, 0x91, 0x27, 0xd5, 0x60, 0x1b, 0xf3, 0x09, 0x6e, 0xa2, 0x58, 0xc4, 0x1d, 0x7f,
+		0x35, 0xe8, 0x92, 0x0b, 0x6a, 0xcf, 0x14, 0x73, 0xbd, 0x21, 0x5e, 0x86, 0xf0, 0x39, 0xa7, 0x42,
+	})
+	impls := []struct {
+		name string
+		fn   func(r, a *Scalar)
+	}{
+		{"Fermat", (*Scalar).inverse},
+		{"SafegcdConst", (*Scalar).inv},
+		{"SafegcdVar", (*Scalar).invVar},
+	}
+	for _, impl := range impls {
+		b.Run(impl.name, func(b *testing.B) {
+			var r Scalar
+			for i := 0; i < b.N; i++ {
+				impl.fn(&r, &a)
+			}
+		})
+	}
+}
@@ -1,7 +1,13 @@
 package p256k1
 
 import (
+	"bytes"
 	"crypto/rand"
+	"encoding/hex"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math/big"
 	"testing"
 )
 
@@ -94,6 +100,44 @@ func TestScalarSetB32Seckey(t *testing.T) {
 	}
 }
 
+func TestScalarFromBytes(t *testing.T) {
+	for _, n := range []int{0, 1, 31, 33, 64} {
+		if _, err := ScalarFromBytes(make([]byte, n)); err == nil {
+			t.Errorf("expected error for %d-byte input", n)
+		}
+	}
+
+	// The group order itself overflows, n-1 does not
+	order := []byte{
+		0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE,
+		0xBA, 0xAE, 0xDC, 0xE6, 0xAF, 0x48, 0xA0, 0x3B, 0xBF, 0xD2, 0x5E, 0x8C, 0xD0, 0x36, 0x41, 0x41,
+	}
+	if _, err := ScalarFromBytes(order); err == nil {
+		t.Error("expected error for scalar equal to the group order")
+	}
+	order[31]--
+	s, err := ScalarFromBytes(order)
+	if err != nil {
+		t.Fatalf("n-1 should parse: %v", err)
+	}
+	if b := s.Bytes(); string(b[:]) != string(order) {
+		t.Errorf("round trip = %x, want %x", b, order)
+	}
+
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		t.Fatal(err)
+	}
+	b[0] &= 0x7f
+	s, err = ScalarFromBytes(b[:])
+	if err != nil {
+		t.Fatalf("failed to parse random scalar: %v", err)
+	}
+	if s.Bytes() != b {
+		t.Errorf("round trip = %x, want %x", s.Bytes(), b)
+	}
+}
+
 func TestScalarArithmetic(t *testing.T) {
 	// Test addition
 	var a, b, c Scalar
@@ -139,6 +183,95 @@ func TestScalarInverse(t *testing.T) {
 		if !product.isOne() {
 			t.Errorf("a * a^(-1) should equal 1 for a = %d", i)
 		}
+
+		// In place
+		inv = a
+		inv.inverse(&inv)
+		product.mul(&a, &inv)
+		if !product.isOne() {
+			t.Errorf("in-place inverse is wrong for a = %d", i)
+		}
+	}
+}
+
+func TestScalarExpAliasing(t *testing.T) {
+	var buf [64]byte
+	for i := 0; i < 16; i++ {
+		if _, err := rand.Read(buf[:]); err != nil {
+			t.Fatal(err)
+		}
+		var a, b Scalar
+		a.setB32(buf[:32])
+		b.setB32(buf[32:])
+
+		// r aliasing a or b must give the same result as a separate r
+		var r, ra, rb Scalar
+		r.exp(&a, &b)
+		ra = a
+		ra.exp(&ra, &b)
+		rb = b
+		rb.exp(&a, &rb)
+		if !ra.equal(&r) {
+			t.Errorf("r = a: %x^%x differs from a separate r", a.Bytes(), b.Bytes())
+		}
+		if !rb.equal(&r) {
+			t.Errorf("r = b: %x^%x differs from a separate r", a.Bytes(), b.Bytes())
+		}
+	}
+}
+
+func TestScalarWNAF(t *testing.T) {
+	var one, nMinus1, top Scalar
+	one.setInt(1)
+	nMinus1.negate(&one)
+	top.setB32([]byte{
+		0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1,
+	})
+	scalars := []Scalar{one, nMinus1, top}
+	for i := 0; i < 32; i++ {
+		var b [32]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			t.Fatal(err)
+		}
+		var s Scalar
+		s.setB32(b[:])
+		scalars = append(scalars, s)
+	}
+
+	for _, w := range []uint{2, 4, 5, 8} {
+		for i := range scalars {
+			var wnaf [257]int
+			n := scalars[i].wNAF(wnaf[:], w)
+
+			// Digits are odd and bounded, and the encoding sums back to
+			// the scalar, negated if its top bit was set
+			var acc, d Scalar
+			for j := 256; j >= 0; j-- {
+				acc.add(&acc, &acc)
+				digit := wnaf[j]
+				if digit == 0 {
+					continue
+				}
+				if j >= n || digit%2 == 0 || digit >= 1<<(w-1) || digit <= -(1<<(w-1)) {
+					t.Fatalf("w=%d scalar %d: bad digit %d at %d (len %d)", w, i, digit, j, n)
+				}
+				if digit > 0 {
+					d.setInt(uint(digit))
+					acc.add(&acc, &d)
+				} else {
+					d.setInt(uint(-digit))
+					acc.sub(&acc, &d)
+				}
+			}
+			want := scalars[i]
+			if want.getBits(255, 1) == 1 {
+				want.negate(&want)
+			}
+			if !acc.equal(&want) {
+				t.Errorf("w=%d scalar %d: wNAF does not sum to the scalar", w, i)
+			}
+		}
 	}
 }
 
@@ -161,6 +294,55 @@ func TestScalarHalf(t *testing.T) {
 	if !doubled.equal(&a) {
 		t.Error("2 * (7/2) should equal 7")
 	}
+
+	// half(0) == 0
+	a.setInt(0)
+	half.half(&a)
+	if !half.isZero() {
+		t.Error("0/2 should equal 0")
+	}
+
+	// half(1) == (n+1)/2
+	a.setInt(1)
+	half.half(&a)
+	expected := Scalar{d: [4]uint64{
+		0xDFE92F46681B20A1,
+		0x5D576E7357A4501D,
+		0xFFFFFFFFFFFFFFFF,
+		0x7FFFFFFFFFFFFFFF,
+	}}
+	if !half.equal(&expected) {
+		t.Error("1/2 should equal (n+1)/2")
+	}
+
+	// 2 * half(x) == x for edge and random values, including odd values
+	// where x + n overflows 256 bits
+	values := make([]Scalar, 0, 36)
+	for _, v := range []uint{0, 1, 2, 3} {
+		a.setInt(v)
+		values = append(values, a)
+	}
+	a.setInt(1)
+	a.negate(&a) // n-1
+	values = append(values, a)
+	a.setInt(2)
+	a.negate(&a) // n-2, odd
+	values = append(values, a)
+	for i := 0; i < 30; i++ {
+		var b [32]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			t.Fatal(err)
+		}
+		a.setB32(b[:])
+		values = append(values, a)
+	}
+	for i := range values {
+		half.half(&values[i])
+		doubled.add(&half, &half)
+		if !doubled.equal(&values[i]) {
+			t.Errorf("2 * half(x) != x for value %d", i)
+		}
+	}
 }
 
 func TestScalarProperties(t *testing.T) {
@@ -198,6 +380,146 @@ func TestScalarConditionalNegate(t *testing.T) {
 	}
 }
 
+func TestScalarIsValidSeckey(t *testing.T) {
+	n, _ := hex.DecodeString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141")
+	nMinus1, _ := hex.DecodeString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364140")
+	one := make([]byte, 32)
+	one[31] = 1
+
+	tests := []struct {
+		name  string
+		input []byte
+		want  bool
+	}{
+		{"zero", make([]byte, 32), false},
+		{"one", one, true},
+		{"n-1", nMinus1, true},
+		{"n", n, false},
+		{"short", one[:31], false},
+	}
+	for _, tc := range tests {
+		if got := ScalarIsValidSeckey(tc.input); got != tc.want {
+			t.Errorf("%s: ScalarIsValidSeckey = %v, want %v", tc.name, got, tc.want)
+		}
+		if got := ECSeckeyVerify(tc.input); got != tc.want {
+			t.Errorf("%s: ECSeckeyVerify = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestScalarCondNegateConst(t *testing.T) {
+	var zero, one, nMinus1, a Scalar
+	one.setInt(1)
+	nMinus1.negate(&one)
+	pattern, _ := hex.DecodeString("4c8a3e9127d5601bf3096ea258c41d7f35e8920b6acf1473bd215e86f039a742")
+	a.setB32(pattern)
+	for _, s := range []Scalar{zero, one, nMinus1, a} {
+		for _, flag := range []int{0, 1} {
+			got, want := s, s
+			got.condNegateConst(flag)
+			want.condNegate(flag)
+			if !got.equal(&want) {
+				t.Errorf("condNegateConst(%d) of %x = %x, want %x", flag, s.d, got.d, want.d)
+			}
+		}
+	}
+}
+
+// TestScalarCondNegateConstBranchFree checks the source of condNegateConst
+// and everything it calls for branches, so a data-dependent branch cannot be
+// added to the constant-time path unnoticed
+func TestScalarCondNegateConstBranchFree(t *testing.T) {
+	fset := token.NewFileSet()
+	funcs := map[string]bool{"condNegateConst": false, "negate": false, "cmov": false, "ctEq": false, "ctMask": false}
+	for _, file := range []string{"scalar.go", "field.go"} {
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			if _, want := funcs[fn.Name.Name]; !want {
+				continue
+			}
+			// Only the Scalar methods, not the field element ones
+			if fn.Recv != nil && file != "scalar.go" {
+				continue
+			}
+			funcs[fn.Name.Name] = true
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.IfStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt, *ast.ForStmt, *ast.RangeStmt:
+					t.Errorf("%s: branch at %s", fn.Name.Name, fset.Position(n.Pos()))
+				case *ast.BinaryExpr:
+					if n.Op == token.LAND || n.Op == token.LOR {
+						t.Errorf("%s: short-circuit operator at %s", fn.Name.Name, fset.Position(n.Pos()))
+					}
+				}
+				return true
+			})
+		}
+	}
+	for name, found := range funcs {
+		if !found {
+			t.Errorf("function %s not found", name)
+		}
+	}
+}
+
+func TestScalarNegateBoundaries(t *testing.T) {
+	nMinus1, _ := hex.DecodeString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364140")
+	var zero, one, top Scalar
+	one.setInt(1)
+	top.setB32(nMinus1)
+
+	tests := []struct {
+		name string
+		a    Scalar
+		want Scalar
+	}{
+		{"zero", zero, zero},
+		{"one", one, top},
+		{"n-1", top, one},
+	}
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		t.Fatal(err)
+	}
+	var random, randomNeg, sum Scalar
+	random.setB32(b[:])
+	randomNeg.negate(&random)
+	tests = append(tests, struct {
+		name string
+		a    Scalar
+		want Scalar
+	}{"random", random, randomNeg})
+
+	for _, tc := range tests {
+		var got Scalar
+		got.negate(&tc.a)
+		if !got.equal(&tc.want) {
+			t.Errorf("%s: negate = %x, want %x", tc.name, got.d, tc.want.d)
+		}
+
+		// In place, and via the slice-based helper
+		inPlace := tc.a
+		inPlace.negate(&inPlace)
+		limbs := tc.a.d
+		scalarNegate(limbs[:])
+		if !inPlace.equal(&tc.want) || limbs != tc.want.d {
+			t.Errorf("%s: in-place negation disagrees with negate", tc.name)
+		}
+
+		sum.add(&tc.a, &got)
+		if !sum.isZero() {
+			t.Errorf("%s: a + (-a) != 0", tc.name)
+		}
+	}
+}
+
 func TestScalarGetBits(t *testing.T) {
 	var a Scalar
 	a.setInt(0x12345678)
@@ -214,6 +536,52 @@ func TestScalarGetBits(t *testing.T) {
 	}
 }
 
+func TestScalarGetBitsClamped(t *testing.T) {
+	var a Scalar
+	a.d[3] = 0xb800000000000000
+
+	// Only bits 252..255 exist, so a 5-bit read at 252 returns those 4 bits
+	if bits := a.getBitsClamped(252, 5); bits != 0xb {
+		t.Errorf("Expected 0xb, got 0x%x", bits)
+	}
+	if bits := a.getBitsClamped(252, 4); bits != a.getBits(252, 4) {
+		t.Errorf("clamped read should match getBits when in range, got 0x%x", bits)
+	}
+	if bits := a.getBitsClamped(256, 5); bits != 0 {
+		t.Errorf("Expected 0 past the top of the scalar, got 0x%x", bits)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("getBits(252, 5) should panic")
+			}
+		}()
+		a.getBits(252, 5)
+	}()
+
+	// Fixed windows read with getBitsClamped must reassemble the scalar for
+	// every group size EcmultConst supports, including the partial top window
+	for w := uint(4); w <= 8; w++ {
+		var b [32]byte
+		for i := range b {
+			b[i] = byte(i*37) ^ byte(w)
+		}
+		var k Scalar
+		k.setB32(b[:])
+		var acc, digit, shift Scalar
+		shift.setInt(1 << w)
+		for offset := int(255/w) * int(w); offset >= 0; offset -= int(w) {
+			acc.mul(&acc, &shift)
+			digit.setInt(uint(k.getBitsClamped(uint(offset), w)))
+			acc.add(&acc, &digit)
+		}
+		if !acc.equal(&k) {
+			t.Errorf("group size %d: windows did not reassemble the scalar", w)
+		}
+	}
+}
+
 func TestScalarConditionalMove(t *testing.T) {
 	var a, b, original Scalar
 	a.setInt(5)
@@ -297,3 +665,165 @@ func TestScalarEdgeCases(t *testing.T) {
 		t.Error("(n-1) + 1 should equal 0 in scalar arithmetic")
 	}
 }
+
+func TestScalarMulReport(t *testing.T) {
+	order, _ := new(big.Int).SetString(testGroupOrderHex, 16)
+	toBig := func(s *Scalar) *big.Int {
+		b := s.Bytes()
+		return new(big.Int).SetBytes(b[:])
+	}
+	fromHex := func(h string) Scalar {
+		b, _ := hex.DecodeString(h)
+		var s Scalar
+		s.setB32(b)
+		return s
+	}
+
+	var one, two, nMinus1, half, halfUp, big128 Scalar
+	one.setInt(1)
+	two.setInt(2)
+	nMinus1.negate(&one)
+	// (n-1)/2 and (n+1)/2, so that 2*(n-1)/2 = n-1 stays below n and
+	// 2*(n+1)/2 = n+1 does not
+	half = fromHex("7fffffffffffffffffffffffffffffff5d576e7357a4501ddfe92f46681b20a0")
+	halfUp = fromHex("7fffffffffffffffffffffffffffffff5d576e7357a4501ddfe92f46681b20a1")
+	big128 = fromHex("0000000000000000000000000000000100000000000000000000000000000000")
+
+	cases := []struct {
+		name    string
+		a, b    *Scalar
+		reduced bool
+	}{
+		{"1*1", &one, &one, false},
+		{"1*(n-1)", &one, &nMinus1, false},
+		{"2*(n-1)/2", &two, &half, false},
+		{"2*(n+1)/2", &two, &halfUp, true},
+		{"2^128*2^128", &big128, &big128, true},
+		{"(n-1)*(n-1)", &nMinus1, &nMinus1, true},
+		{"0*(n-1)", &Scalar{}, &nMinus1, false},
+	}
+	for _, c := range cases {
+		got, reduced := mulReport(c.a, c.b)
+		var want Scalar
+		want.mul(c.a, c.b)
+		if !got.equal(&want) {
+			t.Errorf("%s: result differs from mul", c.name)
+		}
+		if reduced != c.reduced {
+			t.Errorf("%s: reduced = %v, want %v", c.name, reduced, c.reduced)
+		}
+	}
+
+	// Random operands of varying size: reduced must match a big.Int check
+	// of the raw product
+	for i := 0; i < 512; i++ {
+		var buf [32]byte
+		if _, err := rand.Read(buf[:]); err != nil {
+			t.Fatal(err)
+		}
+		var a, b Scalar
+		a.setB32(buf[:])
+		if _, err := rand.Read(buf[:]); err != nil {
+			t.Fatal(err)
+		}
+		// Shrink b to a random number of bytes
+		shift := i % 33
+		for j := 0; j < shift; j++ {
+			buf[j] = 0
+		}
+		b.setB32(buf[:])
+
+		got, reduced := mulReport(&a, &b)
+		prod := new(big.Int).Mul(toBig(&a), toBig(&b))
+		if want := prod.Cmp(order) >= 0; reduced != want {
+			t.Fatalf("a=%x b=%x: reduced = %v, want %v", a.Bytes(), b.Bytes(), reduced, want)
+		}
+		if toBig(&got).Cmp(prod.Mod(prod, order)) != 0 {
+			t.Fatalf("a=%x b=%x: wrong product", a.Bytes(), b.Bytes())
+		}
+	}
+
+	var small Scalar
+	small.setInt(0xffffffff)
+	if _, reduced := mulReport(&small, &small); reduced {
+		t.Error("product of 32-bit operands reported as reduced")
+	}
+}
+
+// TestScalarMulDirect checks the direct-function scalarMul against the
+// Scalar.mul method, including products whose high half is non-zero
+func TestScalarSetB64(t *testing.T) {
+	order, _ := new(big.Int).SetString(testGroupOrderHex, 16)
+
+	inputs := [][]byte{make([]byte, 64), bytes.Repeat([]byte{0xff}, 64)}
+	// n, n^2 and n^2 - 1 as 64-byte values
+	for _, v := range []*big.Int{order, new(big.Int).Mul(order, order), new(big.Int).Sub(new(big.Int).Mul(order, order), big.NewInt(1))} {
+		inputs = append(inputs, v.FillBytes(make([]byte, 64)))
+	}
+	for i := 0; i < 4096; i++ {
+		b := make([]byte, 64)
+		if _, err := rand.Read(b); err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, b)
+	}
+
+	for _, b := range inputs {
+		var s Scalar
+		s.setB64(b)
+		want := new(big.Int).Mod(new(big.Int).SetBytes(b), order).FillBytes(make([]byte, 32))
+		if got := s.Bytes(); hex.EncodeToString(got[:]) != hex.EncodeToString(want) {
+			t.Fatalf("setB64(%x) = %x, want %x", b, got, want)
+		}
+	}
+}
+
+func TestScalarMulDirect(t *testing.T) {
+	var one, nMinus1 Scalar
+	one.setInt(1)
+	nMinus1.negate(&one)
+	edges := []Scalar{{}, one, nMinus1, {d: [4]uint64{^uint64(0), ^uint64(0), 0, 0}}}
+
+	pairs := [][2]Scalar{}
+	for _, a := range edges {
+		for _, b := range edges {
+			pairs = append(pairs, [2]Scalar{a, b})
+		}
+	}
+	var buf [64]byte
+	for i := 0; i < 4096; i++ {
+		if _, err := rand.Read(buf[:]); err != nil {
+			t.Fatal(err)
+		}
+		var a, b Scalar
+		a.setB32(buf[:32])
+		b.setB32(buf[32:])
+		pairs = append(pairs, [2]Scalar{a, b})
+	}
+
+	for _, p := range pairs {
+		var got, want Scalar
+		scalarMul(&got, &p[0], &p[1])
+		want.mul(&p[0], &p[1])
+		if !got.equal(&want) {
+			t.Fatalf("scalarMul(%x, %x) = %x, want %x", p[0].Bytes(), p[1].Bytes(), got.Bytes(), want.Bytes())
+		}
+	}
+}
+
+func TestScalarSplitLambda(t *testing.T) {
+	var buf [32]byte
+	for i := 0; i < 256; i++ {
+		if _, err := rand.Read(buf[:]); err != nil {
+			t.Fatal(err)
+		}
+		var k, r1, r2, sum Scalar
+		k.setB32(buf[:])
+		r1.splitLambda(&r2, &k)
+		sum.mul(&r2, &secp256k1Lambda)
+		sum.add(&sum, &r1)
+		if !sum.equal(&k) {
+			t.Fatalf("r1 + lambda*r2 != k for %x", buf)
+		}
+	}
+}
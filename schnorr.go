@@ -1,7 +1,11 @@
 package p256k1
 
 import (
+	"crypto/rand"
+	"encoding/binary"
 	"errors"
+	"fmt"
+	"io"
 	"sync"
 	"unsafe"
 )
@@ -55,10 +59,13 @@ func NonceFunctionBIP340(nonce32 []byte, msg []byte, key32 []byte, xonlyPk32 []b
 	if len(xonlyPk32) != 32 {
 		return errors.New("xonlyPk32 must be 32 bytes")
 	}
+	if auxRand32 != nil && len(auxRand32) != 32 {
+		return errors.New("auxRand32 must be 32 bytes")
+	}
 
 	// Mask key with aux random data
 	var maskedKey [32]byte
-	if auxRand32 != nil && len(auxRand32) == 32 {
+	if auxRand32 != nil {
 		// TaggedHash("BIP0340/aux", aux_rand32)
 		auxHash := TaggedHash(bip340AuxTag, auxRand32)
 		for i := 0; i < 32; i++ {
@@ -91,86 +98,279 @@ type SchnorrSignature [64]byte
 
 // SchnorrSign creates a Schnorr signature following BIP-340
 func SchnorrSign(sig64 []byte, msg32 []byte, keypair *KeyPair, auxRand32 []byte) error {
+	return schnorrSign(nil, sig64, msg32, keypair, auxRand32)
+}
+
+// SchnorrSignWithContext is SchnorrSign using the signing context's generator
+// table and blinding. The signature is identical to SchnorrSign's. If the
+// context was created with ContextSignVerify the signature is verified before
+// returning, and ErrSignatureSelfCheck is returned if it does not verify.
+func SchnorrSignWithContext(ctx *Context, sig64 []byte, msg32 []byte, keypair *KeyPair, auxRand32 []byte) error {
+	if !ctx.canSign() {
+		return fmt.Errorf("%w: context cannot be used for signing", ErrContextNotBuilt)
+	}
+	if err := ctxSchnorrSign(ctx, sig64, msg32, keypair, auxRand32); err != nil {
+		return err
+	}
+	if !ctx.signVerify() {
+		return nil
+	}
+
+	xonly, err := keypair.XOnlyPubkey()
+	if err != nil {
+		return err
+	}
+	if !SchnorrVerify(sig64, msg32, xonly) {
+		memclear(unsafe.Pointer(&sig64[0]), 64)
+		return ErrSignatureSelfCheck
+	}
+	return nil
+}
+
+// SchnorrSecnonceSize is the size of the secret nonce written by
+// SchnorrPrecommitNonce
+const SchnorrSecnonceSize = 96
+
+// SchnorrPrecommitNonce starts a two-phase BIP-340 signature, letting a
+// signer commit to the nonce point R = k*G before the message to sign is
+// known. k is derived as in SchnorrSign from msg, which is whatever is
+// known at commit time (e.g. a session identifier), with 32 bytes from
+// crypto/rand as the auxiliary randomness, and negated if needed so that R
+// has even Y. R is written to pubnonce and the secret nonce, bound to kp, to
+// secnonce, which must be SchnorrSecnonceSize bytes. Finish with
+// SchnorrSignPrecommitted.
+//
+// Signing two different messages with one nonce reveals the secret key, and
+// a precommitment may be repeated with the same msg (after a retry or a
+// crash), so the nonce is never derived from caller input alone: as in
+// BIP-327 nonce generation, fresh randomness is always drawn. aux, if not
+// nil, is 32 extra bytes mixed into it. SchnorrSignPrecommitted clears
+// secnonce so it cannot be used twice. If ctx can sign, its blinded
+// generator multiplication is used. It returns false on invalid input or if
+// no randomness is available, leaving secnonce cleared.
+func SchnorrPrecommitNonce(ctx *Context, secnonce []byte, pubnonce *PublicKey, msg []byte, kp *KeyPair, aux []byte) bool {
+	if len(secnonce) != SchnorrSecnonceSize {
+		return false
+	}
+	memclear(unsafe.Pointer(&secnonce[0]), SchnorrSecnonceSize)
+	if pubnonce == nil || kp == nil || (aux != nil && len(aux) != 32) {
+		return false
+	}
+	if !ctx.canSign() {
+		ctx = nil
+	}
+
+	var rand32 [32]byte
+	if _, err := rand.Read(rand32[:]); err != nil {
+		return false
+	}
+	for i := range aux {
+		rand32[i] ^= aux[i]
+	}
+	defer memclear(unsafe.Pointer(&rand32[0]), 32)
+
+	var sk Scalar
+	var pkX [32]byte
+	if schnorrLoadKey(ctx, &sk, &pkX, kp) != nil {
+		return false
+	}
+	var skBytes [32]byte
+	sk.getB32(skBytes[:])
+	sk.clear()
+
+	var k Scalar
+	var r32 [32]byte
+	err := schnorrNonce(ctx, &k, &r32, msg, &skBytes, &pkX, rand32[:])
+	memclear(unsafe.Pointer(&skBytes[0]), 32)
+	if err != nil {
+		return false
+	}
+
+	// The even-Y point with x-coordinate r32 is R
+	var x FieldElement
+	var r GroupElementAffine
+	x.setB32(r32[:])
+	if !r.setXOVar(&x, false) {
+		k.clear()
+		return false
+	}
+	pubkeySave(pubnonce, &r)
+
+	// secnonce = k || pk x-coordinate || R x-coordinate
+	k.getB32(secnonce[:32])
+	copy(secnonce[32:64], pkX[:])
+	copy(secnonce[64:], r32[:])
+	k.clear()
+	return true
+}
+
+// SchnorrSignPrecommitted completes a two-phase signature begun with
+// SchnorrPrecommitNonce, signing msg32 with kp using the committed nonce.
+// kp must be the keypair the nonce was made for. secnonce is cleared before
+// the signature is computed, so a second call with it fails instead of
+// reusing the nonce.
+func SchnorrSignPrecommitted(sig64 []byte, msg32 []byte, kp *KeyPair, secnonce []byte) error {
 	if len(sig64) != 64 {
 		return errors.New("signature must be 64 bytes")
 	}
 	if len(msg32) != 32 {
 		return errors.New("message must be 32 bytes")
 	}
-	if keypair == nil {
+	if kp == nil {
 		return errors.New("keypair cannot be nil")
 	}
+	if len(secnonce) != SchnorrSecnonceSize {
+		return errors.New("secret nonce has the wrong size")
+	}
+
+	var k Scalar
+	valid := k.setB32Seckey(secnonce[:32])
+	var pkX, r32 [32]byte
+	copy(r32[:], secnonce[64:])
+	nonceX := [32]byte(secnonce[32:64])
+	memclear(unsafe.Pointer(&secnonce[0]), SchnorrSecnonceSize)
+	if !valid {
+		return errors.New("secret nonce is invalid or was already used")
+	}
 
-	// Load secret key
 	var sk Scalar
-	if !sk.setB32Seckey(keypair.seckey[:]) {
-		return errors.New("invalid secret key")
+	if err := schnorrLoadKey(nil, &sk, &pkX, kp); err != nil {
+		k.clear()
+		return err
+	}
+	if pkX != nonceX {
+		sk.clear()
+		k.clear()
+		return errors.New("secret nonce was made for a different key")
 	}
 
-	// Load public key
-	var pk GroupElementAffine
-	pk.fromBytes(keypair.pubkey.data[:])
-	if pk.isInfinity() {
-		return errors.New("invalid public key")
+	schnorrChallengeResponse(sig64, &r32, &pkX, msg32, &sk, &k)
+	sk.clear()
+	k.clear()
+	return nil
+}
+
+// schnorrSign creates a BIP-340 signature. A nil ctx uses the global
+// generator table.
+func schnorrSign(ctx *Context, sig64 []byte, msg32 []byte, keypair *KeyPair, auxRand32 []byte) error {
+	if len(sig64) != 64 {
+		return errors.New("signature must be 64 bytes")
+	}
+	if len(msg32) != 32 {
+		return errors.New("message must be 32 bytes")
+	}
+	if keypair == nil {
+		return errors.New("keypair cannot be nil")
+	}
+	if auxRand32 != nil && len(auxRand32) != 32 {
+		return errors.New("aux randomness must be 32 bytes")
 	}
 
-	// Negate secret key if Y coordinate is odd (BIP-340 requires even Y)
-	pk.y.normalize()
+	var sk Scalar
+	var pkX [32]byte
+	if err := schnorrLoadKey(ctx, &sk, &pkX, keypair); err != nil {
+		return err
+	}
 	var skBytes [32]byte
 	sk.getB32(skBytes[:])
 
-	if pk.y.isOdd() {
-		sk.negate(&sk)
-		sk.getB32(skBytes[:]) // Update skBytes with negated key
-		// Update pk to have even Y
-		pk.negate(&pk)
+	var k Scalar
+	var r32 [32]byte
+	err := schnorrNonce(ctx, &k, &r32, msg32, &skBytes, &pkX, auxRand32)
+	memclear(unsafe.Pointer(&skBytes[0]), 32)
+	if err != nil {
+		sk.clear()
+		return err
 	}
 
-	// Get x-only public key (X coordinate)
-	var pkX [32]byte
-	pk.x.normalize()
-	pk.x.getB32(pkX[:])
+	schnorrChallengeResponse(sig64, &r32, &pkX, msg32, &sk, &k)
 
-	// Generate nonce (use the possibly-negated secret key)
+	// Clear sensitive data
+	sk.clear()
+	k.clear()
+	memclear(unsafe.Pointer(&pkX[0]), 32)
+
+	return nil
+}
+
+// schnorrNonce derives the BIP-340 nonce k for msg from the (already
+// parity-adjusted) secret key and x-only public key, negating it if needed so
+// that R = k*G has even Y, and writes the x-coordinate of R to r32. A nil ctx
+// uses the global generator table.
+func schnorrNonce(ctx *Context, k *Scalar, r32 *[32]byte, msg []byte, skBytes, pkX *[32]byte, auxRand32 []byte) error {
 	var nonce32 [32]byte
-	if err := NonceFunctionBIP340(nonce32[:], msg32, skBytes[:], pkX[:], auxRand32); err != nil {
+	if err := NonceFunctionBIP340(nonce32[:], msg, skBytes[:], pkX[:], auxRand32); err != nil {
 		return err
 	}
 
 	// Parse nonce scalar
-	var k Scalar
-	if !k.setB32Seckey(nonce32[:]) {
+	valid := k.setB32Seckey(nonce32[:])
+	memclear(unsafe.Pointer(&nonce32[0]), 32)
+	ctx.declassify(unsafe.Pointer(&valid), unsafe.Sizeof(valid))
+	if !valid {
 		return errors.New("nonce generation failed")
 	}
 
-	if k.isZero() {
-		return errors.New("nonce is zero")
-	}
-
 	// Compute R = k * G
 	var rj GroupElementJacobian
-	EcmultGen(&rj, &k)
+	if ctx != nil {
+		ctx.ecmultGen(&rj, k)
+	} else {
+		EcmultGen(&rj, k)
+	}
 
 	// Convert to affine
 	var r GroupElementAffine
 	r.setGEJ(&rj)
 	r.y.normalize()
+	ctx.declassify(unsafe.Pointer(&r), unsafe.Sizeof(r))
 
-	// If R.y is odd, negate k
+	// If R.y is odd, negate k; -R has the same x-coordinate
 	if r.y.isOdd() {
-		k.negate(&k)
-		// Recompute R with negated k
-		EcmultGen(&rj, &k)
-		r.setGEJ(&rj)
+		k.negate(k)
 	}
 
 	// Extract r = X(R)
 	r.x.normalize()
-	var r32 [32]byte
 	r.x.getB32(r32[:])
+
+	rj.clear()
+	r.clear()
+	return nil
+}
+
+// schnorrLoadKey loads the secret key of keypair into sk, negated if needed
+// so that it belongs to the even-Y public key as BIP-340 requires, and writes
+// that public key's x-coordinate to pkX
+func schnorrLoadKey(ctx *Context, sk *Scalar, pkX *[32]byte, keypair *KeyPair) error {
+	valid := sk.setB32Seckey(keypair.seckey[:])
+	ctx.declassify(unsafe.Pointer(&valid), unsafe.Sizeof(valid))
+	if !valid {
+		return ErrInvalidSeckey
+	}
+
+	var pk GroupElementAffine
+	pk.fromBytes(keypair.pubkey.data[:])
+	if pk.isInfinity() {
+		sk.clear()
+		return ErrInvalidPubkey
+	}
+
+	// The negation of the secret key is branch-free; the parity itself is
+	// public. Negating pk would not change its x-coordinate.
+	pk.y.normalize()
+	sk.condNegateConst(boolToInt(pk.y.isOdd()))
+	pk.x.normalize()
+	pk.x.getB32(pkX[:])
+	return nil
+}
+
+// schnorrChallengeResponse completes a BIP-340 signature with nonce point
+// x-coordinate r32: it writes r32 and s = k + e*sk to sig64, where e is the
+// challenge TaggedHash("BIP0340/challenge", r || pk || msg)
+func schnorrChallengeResponse(sig64 []byte, r32, pkX *[32]byte, msg32 []byte, sk, k *Scalar) {
 	copy(sig64[:32], r32[:])
 
-	// Compute challenge e = TaggedHash("BIP0340/challenge", r || pk || msg)
 	var challengeInput []byte
 	challengeInput = append(challengeInput, r32[:]...)
 	challengeInput = append(challengeInput, pkX[:]...)
@@ -182,26 +382,12 @@ func SchnorrSign(sig64 []byte, msg32 []byte, keypair *KeyPair, auxRand32 []byte)
 
 	// Compute s = k + e * sk
 	var s Scalar
-	s.mul(&e, &sk)
-	s.add(&s, &k)
-
-	// Serialize s
-	var s32 [32]byte
-	s.getB32(s32[:])
-	copy(sig64[32:], s32[:])
+	s.mul(&e, sk)
+	s.add(&s, k)
+	s.getB32(sig64[32:])
 
-	// Clear sensitive data
-	sk.clear()
-	k.clear()
 	e.clear()
 	s.clear()
-	memclear(unsafe.Pointer(&nonce32[0]), 32)
-	memclear(unsafe.Pointer(&pkX[0]), 32)
-	memclear(unsafe.Pointer(&skBytes[0]), 32)
-	rj.clear()
-	r.clear()
-
-	return nil
 }
 
 // SchnorrVerifyOld is the deprecated original implementation of SchnorrVerify.
@@ -223,25 +409,21 @@ func SchnorrVerifyOld(sig64 []byte, msg32 []byte, xonlyPubkey *XOnlyPubkey) bool
 	copy(r32[:], sig64[:32])
 	copy(s32[:], sig64[32:])
 
-	// Parse r as field element
+	// Parse r as field element; it must be below p
 	var rx FieldElement
-	if err := rx.setB32(r32[:]); err != nil {
+	if !rx.setB32Limit(r32[:]) {
 		return false
 	}
 
 	// Check if r corresponds to a valid point
 	var r GroupElementAffine
 	if !r.setXOVar(&rx, false) {
-		// Try with odd Y
-		if !r.setXOVar(&rx, true) {
-			return false
-		}
+		return false
 	}
 
-	// Parse s as scalar
+	// Parse s as scalar; BIP-340 requires s < n but allows s = 0
 	var s Scalar
-	s.setB32(s32[:])
-	if s.isZero() {
+	if s.setB32(s32[:]) {
 		return false
 	}
 
@@ -263,7 +445,9 @@ func SchnorrVerifyOld(sig64 []byte, msg32 []byte, xonlyPubkey *XOnlyPubkey) bool
 	// Compute e*P where P is the x-only pubkey
 	// We need to reconstruct P with even Y
 	var pk GroupElementAffine
-	pk.x.setB32(xonlyPubkey.data[:])
+	if !pk.x.setB32Limit(xonlyPubkey.data[:]) {
+		return false
+	}
 	// Always use even Y for x-only pubkey
 	if !pk.setXOVar(&pk.x, false) {
 		return false
@@ -292,13 +476,10 @@ func SchnorrVerifyOld(sig64 []byte, msg32 []byte, xonlyPubkey *XOnlyPubkey) bool
 		return false
 	}
 
-	// Check if R.y is even
+	// R must have even Y
 	RAff.y.normalize()
 	if RAff.y.isOdd() {
-		// Negate R
-		var negR GroupElementAffine
-		negR.negate(&RAff)
-		RAff = negR
+		return false
 	}
 
 	// Compare X(R) with r
@@ -340,3 +521,378 @@ func SchnorrVerify(sig64 []byte, msg32 []byte, xonlyPubkey *XOnlyPubkey) bool {
 	result := secp256k1_schnorrsig_verify(ctx, sig64, msg32, len(msg32), &secp_xonly)
 	return result != 0
 }
+
+// SchnorrVerifyStream verifies a BIP-340 signature over a message of any
+// length read from msg. The message is fed into the challenge hash as it is
+// read, so it never needs to be held in memory. Returns false if reading
+// msg fails.
+func SchnorrVerifyStream(sig64 []byte, msg io.Reader, xonlyPubkey *XOnlyPubkey) bool {
+	if len(sig64) != 64 {
+		return false
+	}
+	if msg == nil || xonlyPubkey == nil {
+		return false
+	}
+
+	ctx := getSchnorrVerifyContext()
+
+	var secp_xonly secp256k1_xonly_pubkey
+	copy(secp_xonly.data[:], xonlyPubkey.data[:])
+
+	result := secp256k1_schnorrsig_verify_stream(ctx, sig64, nil, msg, &secp_xonly)
+	return result != 0
+}
+
+// XOnlyPubkeyLift lifts an x-only public key to its even-Y curve point, for
+// use with VerifyWithLoadedKey.
+func XOnlyPubkeyLift(xonlyPubkey *XOnlyPubkey) (*GroupElementAffine, error) {
+	if xonlyPubkey == nil {
+		return nil, errors.New("xonly pubkey cannot be nil")
+	}
+	p := new(GroupElementAffine)
+	if !schnorrBatchLoadPubkey(p, xonlyPubkey) {
+		return nil, ErrInvalidPubkey
+	}
+	p.x.normalize()
+	p.y.normalize()
+	return p, nil
+}
+
+// VerifyWithLoadedKey verifies a BIP-340 signature against a public key that
+// has already been lifted to a curve point (see XOnlyPubkeyLift). When many
+// signatures are checked under the same key this skips the square root that
+// SchnorrVerify spends re-lifting the key on every call. pk must be the
+// even-Y lift of the x-only key; any other point is rejected.
+func VerifyWithLoadedKey(sig64 []byte, msg32 []byte, pk *GroupElementAffine) bool {
+	if len(sig64) != 64 || len(msg32) != 32 || pk == nil {
+		return false
+	}
+	if pk.isInfinity() {
+		return false
+	}
+
+	p := *pk
+	p.x.normalize()
+	p.y.normalize()
+	if p.y.isOdd() || !p.isValid() {
+		return false
+	}
+
+	var ge secp256k1_ge
+	ge.x.n = p.x.n
+	ge.y.n = p.y.n
+
+	ctx := getSchnorrVerifyContext()
+	result := secp256k1_schnorrsig_verify_loaded(ctx, sig64, msg32, nil, &ge)
+	return result != 0
+}
+
+// SchnorrExtractR returns the x-coordinate r of the public nonce point R
+// encoded in sig64, and whether r is a valid field element (below p). It
+// returns nil and false if sig64 is not 64 bytes.
+func SchnorrExtractR(sig64 []byte) (*FieldElement, bool) {
+	if len(sig64) != 64 {
+		return nil, false
+	}
+
+	var rx FieldElement
+	if !rx.setB32Limit(sig64[:32]) {
+		rx.setB32(sig64[:32])
+		rx.normalize()
+		return &rx, false
+	}
+	return &rx, true
+}
+
+// SchnorrVerifyReturnR verifies sig64 and also returns the nonce point it
+// implies, R' = s*G - e*P with e the BIP-340 challenge, so callers can
+// inspect it (e.g. check it against a commitment in an adaptor-signature
+// protocol). It reports whether R' matches the R encoded in sig64 (same
+// x-coordinate and even Y), which is exactly the check SchnorrVerify
+// performs. It returns nil and false if the inputs are malformed or R' is
+// the point at infinity.
+func SchnorrVerifyReturnR(sig64 []byte, msg32 []byte, xonlyPubkey *XOnlyPubkey) (*GroupElementAffine, bool) {
+	if len(sig64) != 64 || len(msg32) != 32 || xonlyPubkey == nil {
+		return nil, false
+	}
+
+	var s Scalar
+	if s.setB32(sig64[32:]) {
+		return nil, false
+	}
+
+	var p GroupElementAffine
+	if !schnorrBatchLoadPubkey(&p, xonlyPubkey) {
+		return nil, false
+	}
+
+	var challengeInput [96]byte
+	copy(challengeInput[:32], sig64[:32])
+	copy(challengeInput[32:64], xonlyPubkey.data[:])
+	copy(challengeInput[64:], msg32)
+	challengeHash := TaggedHash(bip340ChallengeTag, challengeInput[:])
+	var e Scalar
+	e.setB32(challengeHash[:])
+
+	return schnorrRecomputeRWithChallenge(sig64, &s, &e, &p)
+}
+
+// SchnorrRecomputeR is SchnorrVerifyReturnR under its earlier name.
+//
+// Deprecated: Use SchnorrVerifyReturnR instead.
+func SchnorrRecomputeR(sig64 []byte, msg32 []byte, xonlyPubkey *XOnlyPubkey) (*GroupElementAffine, bool) {
+	return SchnorrVerifyReturnR(sig64, msg32, xonlyPubkey)
+}
+
+// SchnorrVerifyWithChallenge verifies sig64 like SchnorrVerify, but uses the
+// caller-supplied challenge e instead of computing the BIP-340 challenge
+// hash. This is for protocols that derive the challenge differently; with
+// the standard challenge it is equivalent to SchnorrVerify.
+func SchnorrVerifyWithChallenge(sig64 []byte, e *Scalar, xonlyPubkey *XOnlyPubkey) bool {
+	if len(sig64) != 64 || e == nil || xonlyPubkey == nil {
+		return false
+	}
+
+	var s Scalar
+	if s.setB32(sig64[32:]) {
+		return false
+	}
+
+	var p GroupElementAffine
+	if !schnorrBatchLoadPubkey(&p, xonlyPubkey) {
+		return false
+	}
+
+	_, ok := schnorrRecomputeRWithChallenge(sig64, &s, e, &p)
+	return ok
+}
+
+// schnorrRecomputeRWithChallenge computes R' = s*G - e*P and reports whether
+// it has even Y and the x-coordinate encoded in sig64
+func schnorrRecomputeRWithChallenge(sig64 []byte, s, e *Scalar, p *GroupElementAffine) (*GroupElementAffine, bool) {
+	var sG, pj, eP, rj GroupElementJacobian
+	EcmultGen(&sG, s)
+	pj.setGE(p)
+	Ecmult(&eP, &pj, e)
+	rj.subVar(&sG, &eP)
+	if rj.isInfinity() {
+		return nil, false
+	}
+
+	r := new(GroupElementAffine)
+	r.setGEJ(&rj)
+	r.x.normalize()
+	r.y.normalize()
+
+	rx, ok := SchnorrExtractR(sig64)
+	if !ok || r.y.isOdd() {
+		return r, false
+	}
+	return r, r.x.equal(rx)
+}
+
+// BIP-340 batch verification randomizer tag
+var bip340BatchTag = []byte("BIP0340/batch")
+
+// schnorrBatchItem holds the parsed components of one signature in a batch
+type schnorrBatchItem struct {
+	r GroupElementAffine
+	s Scalar
+	e Scalar
+}
+
+// schnorrBatchParse parses sig64 into its nonce point and s value and
+// computes the challenge for msg and pk32. BIP-340 allows messages of any
+// length. Returns false if the signature encoding is invalid.
+func schnorrBatchParse(item *schnorrBatchItem, sig64 []byte, msg []byte, pk32 []byte) bool {
+	if len(sig64) != 64 {
+		return false
+	}
+
+	// r must be a field element below p that is the X of a curve point
+	var rx FieldElement
+	if !rx.setB32Limit(sig64[:32]) || !item.r.setXOVar(&rx, false) {
+		return false
+	}
+
+	// s must be below the group order
+	if item.s.setB32(sig64[32:]) {
+		return false
+	}
+
+	// e = TaggedHash("BIP0340/challenge", r || pk || msg)
+	challengeInput := make([]byte, 64, 64+len(msg))
+	copy(challengeInput[:32], sig64[:32])
+	copy(challengeInput[32:64], pk32)
+	challengeInput = append(challengeInput, msg...)
+	challengeHash := TaggedHash(bip340ChallengeTag, challengeInput)
+	item.e.setB32(challengeHash[:])
+
+	return true
+}
+
+// schnorrBatchRandomizers derives the per-signature weights a_i from a hash of
+// the whole batch. The first weight is always 1, the rest are pseudorandom
+// so that a forger cannot choose signatures that cancel out in the sum.
+func schnorrBatchRandomizers(a []Scalar, sigs [][]byte, msgs [][]byte, pubkeys [][]byte) {
+	var seedInput []byte
+	for i := range sigs {
+		// Messages vary in length, so prefix each with its length to keep
+		// the encoding unambiguous
+		seedInput = append(seedInput, sigs[i]...)
+		seedInput = binary.BigEndian.AppendUint64(seedInput, uint64(len(msgs[i])))
+		seedInput = append(seedInput, msgs[i]...)
+		seedInput = append(seedInput, pubkeys[i]...)
+	}
+	seed := TaggedHash(bip340BatchTag, seedInput)
+
+	a[0].setInt(1)
+	var buf [36]byte
+	copy(buf[:32], seed[:])
+	for i := 1; i < len(a); i++ {
+		buf[32] = byte(i)
+		buf[33] = byte(i >> 8)
+		buf[34] = byte(i >> 16)
+		buf[35] = byte(i >> 24)
+		h := TaggedHash(bip340BatchTag, buf[:])
+		a[i].setB32(h[:])
+	}
+}
+
+// schnorrBatchLoadPubkey lifts an x-only public key to the point with even Y
+func schnorrBatchLoadPubkey(p *GroupElementAffine, xonlyPubkey *XOnlyPubkey) bool {
+	var px FieldElement
+	if !px.setB32Limit(xonlyPubkey.data[:]) {
+		return false
+	}
+	return p.setXOVar(&px, false)
+}
+
+// schnorrBatchCheck returns true if sG equals the sum of the given terms
+func schnorrBatchCheck(sSum *Scalar, sum *GroupElementJacobian) bool {
+	var sG, negSum, check GroupElementJacobian
+	EcmultGen(&sG, sSum)
+	negSum.negate(sum)
+	check.addVar(&sG, &negSum)
+	return check.isInfinity()
+}
+
+// SchnorrVerifyBatch verifies a batch of BIP-340 signatures at once. It
+// returns true only if every signature is valid for its message and public
+// key; messages may differ in length. The check uses a random linear
+// combination
+//
+//	(sum a_i*s_i)*G == sum a_i*R_i + sum (a_i*e_i)*P_i
+//
+// which costs one generator multiplication and one multi-scalar
+// multiplication over all R_i and P_i for the whole batch. An empty batch is
+// considered valid.
+func SchnorrVerifyBatch(sigs [][]byte, msgs [][]byte, pubkeys []*XOnlyPubkey) bool {
+	n := len(sigs)
+	if len(msgs) != n || len(pubkeys) != n {
+		return false
+	}
+	if n == 0 {
+		return true
+	}
+
+	// Reject keys that are not on the curve with the cheap Jacobi symbol
+	// before any signature parsing, square roots or multiplications
+	for i := 0; i < n; i++ {
+		if pubkeys[i] == nil {
+			return false
+		}
+		var px FieldElement
+		if !px.setB32Limit(pubkeys[i].data[:]) || !geXOnCurveVar(&px) {
+			return false
+		}
+	}
+
+	items := make([]schnorrBatchItem, n)
+	pkBytes := make([][]byte, n)
+	for i := 0; i < n; i++ {
+		pkBytes[i] = pubkeys[i].data[:]
+		if !schnorrBatchParse(&items[i], sigs[i], msgs[i], pkBytes[i]) {
+			return false
+		}
+	}
+
+	a := make([]Scalar, n)
+	schnorrBatchRandomizers(a, sigs, msgs, pkBytes)
+
+	// Terms a_i*R_i in the first half, (a_i*e_i)*P_i in the second
+	var sSum Scalar
+	scalars := make([]Scalar, 2*n)
+	points := make([]GroupElementAffine, 2*n)
+	for i := 0; i < n; i++ {
+		if !schnorrBatchLoadPubkey(&points[n+i], pubkeys[i]) {
+			return false
+		}
+
+		var t Scalar
+		t.mul(&a[i], &items[i].s)
+		sSum.add(&sSum, &t)
+
+		scalars[i] = a[i]
+		points[i] = items[i].r
+		scalars[n+i].mul(&a[i], &items[i].e)
+	}
+
+	var sum GroupElementJacobian
+	EcmultMulti(&sum, scalars, points)
+	return schnorrBatchCheck(&sSum, &sum)
+}
+
+// SchnorrVerifyBatchSameKey verifies a batch of BIP-340 signatures that were
+// all made by the same public key. The key is loaded once and factored out
+// of the random linear combination
+//
+//	(sum a_i*s_i)*G == sum a_i*R_i + (sum a_i*e_i)*P
+//
+// so only a single multiplication by P is needed regardless of batch size.
+func SchnorrVerifyBatchSameKey(sigs [][]byte, msgs [][]byte, xonlyPubkey *XOnlyPubkey) bool {
+	n := len(sigs)
+	if len(msgs) != n || xonlyPubkey == nil {
+		return false
+	}
+	if n == 0 {
+		return true
+	}
+
+	var p GroupElementAffine
+	if !schnorrBatchLoadPubkey(&p, xonlyPubkey) {
+		return false
+	}
+
+	items := make([]schnorrBatchItem, n)
+	pkBytes := make([][]byte, n)
+	for i := 0; i < n; i++ {
+		pkBytes[i] = xonlyPubkey.data[:]
+		if !schnorrBatchParse(&items[i], sigs[i], msgs[i], pkBytes[i]) {
+			return false
+		}
+	}
+
+	a := make([]Scalar, n)
+	schnorrBatchRandomizers(a, sigs, msgs, pkBytes)
+
+	// Terms a_i*R_i, then the single (sum a_i*e_i)*P
+	var sSum Scalar
+	scalars := make([]Scalar, n+1)
+	points := make([]GroupElementAffine, n+1)
+	for i := 0; i < n; i++ {
+		var t Scalar
+		t.mul(&a[i], &items[i].s)
+		sSum.add(&sSum, &t)
+		t.mul(&a[i], &items[i].e)
+		scalars[n].add(&scalars[n], &t)
+
+		scalars[i] = a[i]
+		points[i] = items[i].r
+	}
+	points[n] = p
+
+	var sum GroupElementJacobian
+	EcmultMulti(&sum, scalars, points)
+	return schnorrBatchCheck(&sSum, &sum)
+}
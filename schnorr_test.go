@@ -1,6 +1,14 @@
 package p256k1
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 )
 
@@ -115,6 +123,68 @@ func TestSchnorrVerifyInvalid(t *testing.T) {
 	}
 }
 
+func TestSchnorrSignatureRange(t *testing.T) {
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	xonly, err := kp.XOnlyPubkey()
+	if err != nil {
+		t.Fatalf("failed to get x-only pubkey: %v", err)
+	}
+	msg := make([]byte, 32)
+	var sig [64]byte
+	if err := SchnorrSign(sig[:], msg, kp, nil); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	verifiers := []struct {
+		name   string
+		verify func(sig64, msg32 []byte, xonly *XOnlyPubkey) bool
+	}{
+		{"SchnorrVerify", SchnorrVerify},
+		{"SchnorrVerifyOld", SchnorrVerifyOld},
+	}
+	for _, v := range verifiers {
+		if !v.verify(sig[:], msg, xonly) {
+			t.Fatalf("%s: valid signature failed to verify", v.name)
+		}
+
+		// s must be below n. A valid signature whose s+n fits in 32 bytes
+		// would need s < 2^256 - n, about 2^-128 likely and not
+		// constructible without breaking the hash, so set s to n + i
+		// directly: were s reduced these would be the residues 0, 1, 2.
+		for i := 0; i < 3; i++ {
+			var small [32]byte
+			small[31] = byte(i)
+			sPlusN, _ := addBigEndian32(small[:], testGroupOrderHex)
+			bad := sig
+			copy(bad[32:], sPlusN[:])
+			if v.verify(bad[:], msg, xonly) {
+				t.Errorf("%s: signature with s = n+%d verified", v.name, i)
+			}
+		}
+
+		// r must be below p
+		bad := sig
+		p, _ := hex.DecodeString(testFieldPrimeHex)
+		copy(bad[:32], p)
+		if v.verify(bad[:], msg, xonly) {
+			t.Errorf("%s: signature with r=p verified", v.name)
+		}
+
+		// Flipping R to odd Y (s -> n-s with the same r) must not verify
+		var s, negS Scalar
+		s.setB32(sig[32:])
+		negS.negate(&s)
+		bad = sig
+		negS.getB32(bad[32:])
+		if v.verify(bad[:], msg, xonly) {
+			t.Errorf("%s: signature with negated s verified", v.name)
+		}
+	}
+}
+
 func TestNonceFunctionBIP340(t *testing.T) {
 	key32 := make([]byte, 32)
 	xonlyPk32 := make([]byte, 32)
@@ -276,3 +346,845 @@ func BenchmarkSchnorrVerify(b *testing.B) {
 		}
 	}
 }
+
+// makeSchnorrBatch signs n distinct messages with kp
+func makeSchnorrBatch(tb testing.TB, kp *KeyPair, n int) (sigs [][]byte, msgs [][]byte) {
+	for i := 0; i < n; i++ {
+		msg := make([]byte, 32)
+		msg[0] = byte(i)
+		msg[1] = byte(i >> 8)
+		sig := make([]byte, 64)
+		if err := SchnorrSign(sig, msg, kp, nil); err != nil {
+			tb.Fatalf("failed to sign: %v", err)
+		}
+		sigs = append(sigs, sig)
+		msgs = append(msgs, msg)
+	}
+	return sigs, msgs
+}
+
+func TestSchnorrVerifyBatchSameKey(t *testing.T) {
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	defer kp.Clear()
+
+	xonly, err := kp.XOnlyPubkey()
+	if err != nil {
+		t.Fatalf("failed to get x-only pubkey: %v", err)
+	}
+
+	sigs, msgs := makeSchnorrBatch(t, kp, 8)
+	pubkeys := make([]*XOnlyPubkey, len(sigs))
+	for i := range pubkeys {
+		pubkeys[i] = xonly
+	}
+
+	if !SchnorrVerifyBatchSameKey(sigs, msgs, xonly) {
+		t.Error("same-key batch verification failed")
+	}
+	if !SchnorrVerifyBatch(sigs, msgs, pubkeys) {
+		t.Error("general batch verification failed")
+	}
+	if !SchnorrVerifyBatchSameKey(nil, nil, xonly) {
+		t.Error("empty batch should verify")
+	}
+
+	// A single bad signature must fail the whole batch
+	sigs[3][63] ^= 1
+	if SchnorrVerifyBatchSameKey(sigs, msgs, xonly) {
+		t.Error("same-key batch should fail with corrupted signature")
+	}
+	if SchnorrVerifyBatch(sigs, msgs, pubkeys) {
+		t.Error("general batch should fail with corrupted signature")
+	}
+	sigs[3][63] ^= 1
+
+	// A key whose x is not on the curve (5^3 + 7 is not a square) is
+	// rejected by the residuosity pre-filter
+	var offCurve XOnlyPubkey
+	offCurve.data[31] = 5
+	saved := pubkeys[2]
+	pubkeys[2] = &offCurve
+	if SchnorrVerifyBatch(sigs, msgs, pubkeys) {
+		t.Error("general batch should fail with an off-curve pubkey")
+	}
+	pubkeys[2] = saved
+
+	// Swapped messages must fail
+	msgs[0], msgs[1] = msgs[1], msgs[0]
+	if SchnorrVerifyBatchSameKey(sigs, msgs, xonly) {
+		t.Error("same-key batch should fail with swapped messages")
+	}
+	msgs[0], msgs[1] = msgs[1], msgs[0]
+
+	// A different key must fail
+	kp2, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	defer kp2.Clear()
+	xonly2, err := kp2.XOnlyPubkey()
+	if err != nil {
+		t.Fatalf("failed to get x-only pubkey: %v", err)
+	}
+	if SchnorrVerifyBatchSameKey(sigs, msgs, xonly2) {
+		t.Error("same-key batch should fail with wrong pubkey")
+	}
+
+	// Mismatched lengths must fail
+	if SchnorrVerifyBatchSameKey(sigs, msgs[:4], xonly) {
+		t.Error("same-key batch should fail with mismatched lengths")
+	}
+}
+
+func benchmarkSchnorrBatch(b *testing.B, sameKey bool) {
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		b.Fatalf("failed to generate keypair: %v", err)
+	}
+	defer kp.Clear()
+
+	xonly, err := kp.XOnlyPubkey()
+	if err != nil {
+		b.Fatalf("failed to get x-only pubkey: %v", err)
+	}
+
+	sigs, msgs := makeSchnorrBatch(b, kp, 64)
+	pubkeys := make([]*XOnlyPubkey, len(sigs))
+	for i := range pubkeys {
+		pubkeys[i] = xonly
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if sameKey {
+			SchnorrVerifyBatchSameKey(sigs, msgs, xonly)
+		} else {
+			SchnorrVerifyBatch(sigs, msgs, pubkeys)
+		}
+	}
+}
+
+func BenchmarkSchnorrVerifyBatch(b *testing.B) {
+	benchmarkSchnorrBatch(b, false)
+}
+
+func BenchmarkSchnorrVerifyBatchSameKey(b *testing.B) {
+	benchmarkSchnorrBatch(b, true)
+}
+
+func TestSchnorrVerifyBatchMixed(t *testing.T) {
+	// The valid BIP-340 vectors have different keys and message lengths
+	var sigs, msgs [][]byte
+	var pubkeys []*XOnlyPubkey
+	var invalid []int
+	for i, v := range bip340Vectors {
+		pub, _ := hex.DecodeString(v.pubkey)
+		xonly, err := XOnlyPubkeyParse(pub)
+		if err != nil {
+			continue
+		}
+		if !v.valid {
+			invalid = append(invalid, i)
+			continue
+		}
+		sig, _ := hex.DecodeString(v.sig)
+		msg, _ := hex.DecodeString(v.msg)
+		sigs = append(sigs, sig)
+		msgs = append(msgs, msg)
+		pubkeys = append(pubkeys, xonly)
+	}
+	if !SchnorrVerifyBatch(sigs, msgs, pubkeys) {
+		t.Fatal("batch of valid BIP-340 vectors failed")
+	}
+
+	// Any one invalid vector fails the whole batch
+	for _, i := range invalid {
+		v := bip340Vectors[i]
+		pub, _ := hex.DecodeString(v.pubkey)
+		xonly, _ := XOnlyPubkeyParse(pub)
+		sig, _ := hex.DecodeString(v.sig)
+		msg, _ := hex.DecodeString(v.msg)
+		if SchnorrVerifyBatch(append(sigs[:len(sigs):len(sigs)], sig), append(msgs[:len(msgs):len(msgs)], msg),
+			append(pubkeys[:len(pubkeys):len(pubkeys)], xonly)) {
+			t.Errorf("batch with invalid vector %d (%s) verified", i, v.comment)
+		}
+	}
+
+	// Moving bytes between adjacent messages keeps the concatenation but
+	// must still fail
+	msgs2 := append([][]byte(nil), msgs...)
+	for i := 0; i+1 < len(msgs2); i++ {
+		if len(msgs2[i]) > 0 {
+			msgs2[i], msgs2[i+1] = msgs2[i][:len(msgs2[i])-1], append([]byte{msgs2[i][len(msgs2[i])-1]}, msgs2[i+1]...)
+			break
+		}
+	}
+	if SchnorrVerifyBatch(sigs, msgs2, pubkeys) {
+		t.Error("batch with shifted message boundary verified")
+	}
+
+	// Many random keys
+	sigs, msgs, pubkeys = nil, nil, nil
+	for i := 0; i < 24; i++ {
+		kp, err := KeyPairGenerate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		xonly, err := kp.XOnlyPubkey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, m := makeSchnorrBatch(t, kp, 1+i%3)
+		for range s {
+			pubkeys = append(pubkeys, xonly)
+		}
+		sigs = append(sigs, s...)
+		msgs = append(msgs, m...)
+	}
+	if !SchnorrVerifyBatch(sigs, msgs, pubkeys) {
+		t.Fatal("batch of random keys failed")
+	}
+	pubkeys[5], pubkeys[6] = pubkeys[6], pubkeys[5]
+	if pubkeys[5] != pubkeys[6] && SchnorrVerifyBatch(sigs, msgs, pubkeys) {
+		t.Error("batch with swapped keys verified")
+	}
+}
+
+func BenchmarkSchnorrVerifyBatchVsSequential(b *testing.B) {
+	for _, n := range []int{16, 64, 256} {
+		sigs := make([][]byte, n)
+		msgs := make([][]byte, n)
+		pubkeys := make([]*XOnlyPubkey, n)
+		for i := 0; i < n; i++ {
+			kp, err := KeyPairGenerate()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if pubkeys[i], err = kp.XOnlyPubkey(); err != nil {
+				b.Fatal(err)
+			}
+			s, m := makeSchnorrBatch(b, kp, 1)
+			sigs[i], msgs[i] = s[0], m[0]
+		}
+
+		b.Run(fmt.Sprintf("Batch-%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if !SchnorrVerifyBatch(sigs, msgs, pubkeys) {
+					b.Fatal("batch failed")
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("Sequential-%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for j := 0; j < n; j++ {
+					if !SchnorrVerify(sigs[j], msgs[j], pubkeys[j]) {
+						b.Fatal("verify failed")
+					}
+				}
+			}
+		})
+	}
+}
+
+// schnorrSignLong signs a message of arbitrary length as BIP-340 allows, for
+// tests that need messages longer than SchnorrSign accepts
+func schnorrSignLong(t *testing.T, kp *KeyPair, msg []byte) []byte {
+	var sk Scalar
+	if !sk.setB32Seckey(kp.seckey[:]) {
+		t.Fatal("invalid secret key")
+	}
+	var pk GroupElementAffine
+	pk.fromBytes(kp.pubkey.data[:])
+	pk.y.normalize()
+	if pk.y.isOdd() {
+		sk.negate(&sk)
+	}
+	xonly, err := kp.XOnlyPubkey()
+	if err != nil {
+		t.Fatalf("failed to get x-only pubkey: %v", err)
+	}
+
+	var k Scalar
+	var kb [32]byte
+	if _, err := rand.Read(kb[:]); err != nil {
+		t.Fatal(err)
+	}
+	k.setB32(kb[:])
+
+	var rj GroupElementJacobian
+	EcmultGen(&rj, &k)
+	var r GroupElementAffine
+	r.setGEJ(&rj)
+	r.y.normalize()
+	if r.y.isOdd() {
+		k.negate(&k)
+	}
+	r.x.normalize()
+
+	sig := make([]byte, 64)
+	r.x.getB32(sig[:32])
+
+	challengeInput := append(append(append([]byte{}, sig[:32]...), xonly.data[:]...), msg...)
+	challengeHash := TaggedHash(bip340ChallengeTag, challengeInput)
+	var e, s Scalar
+	e.setB32(challengeHash[:])
+	s.mul(&e, &sk)
+	s.add(&s, &k)
+	s.getB32(sig[32:])
+	return sig
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestSchnorrVerifyStream(t *testing.T) {
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	defer kp.Clear()
+
+	xonly, err := kp.XOnlyPubkey()
+	if err != nil {
+		t.Fatalf("failed to get x-only pubkey: %v", err)
+	}
+
+	// Large message compared against the buffered verifier
+	msg := make([]byte, 1<<20+17)
+	if _, err := rand.Read(msg); err != nil {
+		t.Fatal(err)
+	}
+	sig := schnorrSignLong(t, kp, msg)
+
+	var secp_xonly secp256k1_xonly_pubkey
+	copy(secp_xonly.data[:], xonly.data[:])
+	buffered := secp256k1_schnorrsig_verify(getSchnorrVerifyContext(), sig, msg, len(msg), &secp_xonly) != 0
+	streamed := SchnorrVerifyStream(sig, bytes.NewReader(msg), xonly)
+	if !buffered || !streamed {
+		t.Errorf("large message verification failed: buffered=%v, streamed=%v", buffered, streamed)
+	}
+
+	msg[len(msg)-1] ^= 1
+	if SchnorrVerifyStream(sig, bytes.NewReader(msg), xonly) {
+		t.Error("stream verification should fail with modified message")
+	}
+
+	// 32-byte messages agree with SchnorrVerify
+	msg32 := make([]byte, 32)
+	var sig32 [64]byte
+	if err := SchnorrSign(sig32[:], msg32, kp, nil); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if !SchnorrVerifyStream(sig32[:], bytes.NewReader(msg32), xonly) {
+		t.Error("stream verification failed for 32-byte message")
+	}
+
+	if SchnorrVerifyStream(sig32[:], failingReader{}, xonly) {
+		t.Error("stream verification should fail when the reader fails")
+	}
+}
+
+func TestSchnorrExtractR(t *testing.T) {
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	defer kp.Clear()
+
+	xonly, err := kp.XOnlyPubkey()
+	if err != nil {
+		t.Fatalf("failed to get x-only pubkey: %v", err)
+	}
+
+	msg := make([]byte, 32)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+	var sig [64]byte
+	if err := SchnorrSign(sig[:], msg, kp, nil); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	rx, ok := SchnorrExtractR(sig[:])
+	if !ok {
+		t.Fatal("r of a valid signature should be below p")
+	}
+	var rBytes [32]byte
+	rx.getB32(rBytes[:])
+	if !bytes.Equal(rBytes[:], sig[:32]) {
+		t.Error("extracted r does not match signature bytes")
+	}
+
+	// The recomputed nonce point must match R for a valid signature
+	r, ok := SchnorrVerifyReturnR(sig[:], msg, xonly)
+	if !ok || r == nil {
+		t.Fatal("recomputed R should match the signature")
+	}
+	if !r.isValid() || r.y.isOdd() || !r.x.equal(rx) {
+		t.Error("recomputed R should be a valid point with even Y and x = r")
+	}
+
+	// A different message implies a different R
+	wrongMsg := make([]byte, 32)
+	if r, ok := SchnorrVerifyReturnR(sig[:], wrongMsg, xonly); ok || r == nil {
+		t.Error("recomputed R should not match for a different message")
+	}
+
+	// r >= p is reported as invalid
+	var bad [64]byte
+	for i := 0; i < 32; i++ {
+		bad[i] = 0xff
+	}
+	if _, ok := SchnorrExtractR(bad[:]); ok {
+		t.Error("r >= p should be reported as invalid")
+	}
+	if _, ok := SchnorrExtractR(sig[:63]); ok {
+		t.Error("short signature should be rejected")
+	}
+}
+
+func TestVerifyWithLoadedKey(t *testing.T) {
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	defer kp.Clear()
+
+	xonly, err := kp.XOnlyPubkey()
+	if err != nil {
+		t.Fatalf("failed to get x-only pubkey: %v", err)
+	}
+	pk, err := XOnlyPubkeyLift(xonly)
+	if err != nil {
+		t.Fatalf("failed to lift pubkey: %v", err)
+	}
+
+	sigs, msgs := makeSchnorrBatch(t, kp, 8)
+	for i := range sigs {
+		if got, want := VerifyWithLoadedKey(sigs[i], msgs[i], pk), SchnorrVerify(sigs[i], msgs[i], xonly); got != want || !got {
+			t.Errorf("signature %d: loaded verify %v, full verify %v", i, got, want)
+		}
+
+		sigs[i][63] ^= 1
+		if got, want := VerifyWithLoadedKey(sigs[i], msgs[i], pk), SchnorrVerify(sigs[i], msgs[i], xonly); got != want || got {
+			t.Errorf("corrupted signature %d: loaded verify %v, full verify %v", i, got, want)
+		}
+		sigs[i][63] ^= 1
+	}
+
+	// The odd-Y lift of the same x is not the BIP-340 key
+	var odd GroupElementAffine
+	odd.negate(pk)
+	if VerifyWithLoadedKey(sigs[0], msgs[0], &odd) {
+		t.Error("odd-Y point should be rejected")
+	}
+
+	var inf GroupElementAffine
+	inf.setInfinity()
+	if VerifyWithLoadedKey(sigs[0], msgs[0], &inf) {
+		t.Error("point at infinity should be rejected")
+	}
+	if VerifyWithLoadedKey(sigs[0], msgs[0], nil) {
+		t.Error("nil point should be rejected")
+	}
+	if VerifyWithLoadedKey(sigs[0][:63], msgs[0], pk) {
+		t.Error("short signature should be rejected")
+	}
+}
+
+func TestVerifyWithLoadedKeyConcurrent(t *testing.T) {
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	defer kp.Clear()
+
+	xonly, err := kp.XOnlyPubkey()
+	if err != nil {
+		t.Fatalf("failed to get x-only pubkey: %v", err)
+	}
+	pk, err := XOnlyPubkeyLift(xonly)
+	if err != nil {
+		t.Fatalf("failed to lift pubkey: %v", err)
+	}
+
+	// Each goroutine hashes its own challenge; with shared hash state the
+	// writes interleave and valid signatures fail
+	sigs, msgs := makeSchnorrBatch(t, kp, 8)
+	var wg sync.WaitGroup
+	var failed atomic.Int32
+	for i := range sigs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if !VerifyWithLoadedKey(sigs[i], msgs[i], pk) || !SchnorrVerify(sigs[i], msgs[i], xonly) {
+					failed.Add(1)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if n := failed.Load(); n != 0 {
+		t.Errorf("%d concurrent verifications of valid signatures failed", n)
+	}
+}
+
+func BenchmarkVerifyWithLoadedKey(b *testing.B) {
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		b.Fatalf("failed to generate keypair: %v", err)
+	}
+	defer kp.Clear()
+
+	xonly, err := kp.XOnlyPubkey()
+	if err != nil {
+		b.Fatalf("failed to get x-only pubkey: %v", err)
+	}
+	pk, err := XOnlyPubkeyLift(xonly)
+	if err != nil {
+		b.Fatalf("failed to lift pubkey: %v", err)
+	}
+
+	sigs, msgs := makeSchnorrBatch(b, kp, 64)
+
+	b.Run("loaded", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := range sigs {
+				VerifyWithLoadedKey(sigs[j], msgs[j], pk)
+			}
+		}
+	})
+	b.Run("full", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := range sigs {
+				SchnorrVerify(sigs[j], msgs[j], xonly)
+			}
+		}
+	})
+}
+
+func TestSchnorrVerifyWithChallenge(t *testing.T) {
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	defer kp.Clear()
+
+	xonly, err := kp.XOnlyPubkey()
+	if err != nil {
+		t.Fatalf("failed to get x-only pubkey: %v", err)
+	}
+
+	sigs, msgs := makeSchnorrBatch(t, kp, 4)
+	for i := range sigs {
+		var challengeInput [96]byte
+		copy(challengeInput[:32], sigs[i][:32])
+		copy(challengeInput[32:64], xonly.data[:])
+		copy(challengeInput[64:], msgs[i])
+		h := TaggedHash(bip340ChallengeTag, challengeInput[:])
+		var e Scalar
+		e.setB32(h[:])
+
+		if got, want := SchnorrVerifyWithChallenge(sigs[i], &e, xonly), SchnorrVerify(sigs[i], msgs[i], xonly); got != want || !got {
+			t.Errorf("signature %d: with challenge %v, normal verify %v", i, got, want)
+		}
+
+		// Any other challenge fails
+		var one, other Scalar
+		one.setInt(1)
+		other.add(&e, &one)
+		if SchnorrVerifyWithChallenge(sigs[i], &other, xonly) {
+			t.Errorf("signature %d: verified with the wrong challenge", i)
+		}
+
+		sigs[i][63] ^= 1
+		if SchnorrVerifyWithChallenge(sigs[i], &e, xonly) {
+			t.Errorf("signature %d: corrupted signature verified", i)
+		}
+		sigs[i][63] ^= 1
+	}
+
+	var e Scalar
+	if SchnorrVerifyWithChallenge(sigs[0], nil, xonly) || SchnorrVerifyWithChallenge(sigs[0][:63], &e, xonly) {
+		t.Error("malformed input should be rejected")
+	}
+}
+
+func TestSchnorrVerifyReturnR(t *testing.T) {
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	defer kp.Clear()
+
+	xonly, err := kp.XOnlyPubkey()
+	if err != nil {
+		t.Fatalf("failed to get x-only pubkey: %v", err)
+	}
+
+	sigs, msgs := makeSchnorrBatch(t, kp, 4)
+	for i := range sigs {
+		r, ok := SchnorrVerifyReturnR(sigs[i], msgs[i], xonly)
+		if !ok || r == nil {
+			t.Fatalf("signature %d: valid signature rejected", i)
+		}
+		var rx [32]byte
+		r.x.getB32(rx[:])
+		if !bytes.Equal(rx[:], sigs[i][:32]) {
+			t.Errorf("signature %d: R.x = %x, want %x", i, rx, sigs[i][:32])
+		}
+		if ok != SchnorrVerify(sigs[i], msgs[i], xonly) {
+			t.Errorf("signature %d: disagrees with SchnorrVerify", i)
+		}
+
+		// A corrupted s still yields an R, but not one that matches
+		sigs[i][63] ^= 1
+		if r, ok := SchnorrVerifyReturnR(sigs[i], msgs[i], xonly); ok || r == nil {
+			t.Errorf("signature %d: corrupted signature should return a mismatching R", i)
+		}
+		sigs[i][63] ^= 1
+	}
+}
+
+func TestSchnorrRejectsUnreducedX(t *testing.T) {
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	defer kp.Clear()
+
+	xonly, err := kp.XOnlyPubkey()
+	if err != nil {
+		t.Fatalf("failed to get x-only pubkey: %v", err)
+	}
+
+	// p+1 reduces to x = 1, which lifts to a curve point, so only the range
+	// check on the encoding rejects it
+	pPlus1, _ := hex.DecodeString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc30")
+	var bad XOnlyPubkey
+	copy(bad.data[:], pPlus1)
+
+	sigs, msgs := makeSchnorrBatch(t, kp, 4)
+	pubkeys := make([]*XOnlyPubkey, len(sigs))
+	for i := range pubkeys {
+		pubkeys[i] = xonly
+	}
+	if !SchnorrVerifyBatch(sigs, msgs, pubkeys) {
+		t.Fatal("valid batch rejected")
+	}
+
+	var p GroupElementAffine
+	if schnorrBatchLoadPubkey(&p, &bad) {
+		t.Error("schnorrBatchLoadPubkey accepted x >= p")
+	}
+	var ge secp256k1_ge
+	var raw secp256k1_xonly_pubkey
+	copy(raw.data[:], pPlus1)
+	if secp256k1_xonly_pubkey_load(nil, &ge, &raw) {
+		t.Error("secp256k1_xonly_pubkey_load accepted x >= p")
+	}
+	if r, ok := SchnorrVerifyReturnR(sigs[0], msgs[0], &bad); ok || r != nil {
+		t.Error("SchnorrVerifyReturnR accepted a pubkey with x >= p")
+	}
+	if SchnorrVerify(sigs[0], msgs[0], &bad) || SchnorrVerifyOld(sigs[0], msgs[0], &bad) {
+		t.Error("SchnorrVerify accepted a pubkey with x >= p")
+	}
+	pubkeys[1] = &bad
+	if SchnorrVerifyBatch(sigs, msgs, pubkeys) {
+		t.Error("SchnorrVerifyBatch accepted a pubkey with x >= p")
+	}
+	pubkeys[1] = xonly
+
+	// The same for R encoded as p+1
+	sig := append([]byte(nil), sigs[2]...)
+	copy(sig[:32], pPlus1)
+	var item schnorrBatchItem
+	if schnorrBatchParse(&item, sig, msgs[2], xonly.data[:]) {
+		t.Error("schnorrBatchParse accepted R.x >= p")
+	}
+	if _, ok := SchnorrVerifyReturnR(sig, msgs[2], xonly); ok {
+		t.Error("SchnorrVerifyReturnR accepted R.x >= p")
+	}
+	if SchnorrVerifyOld(sig, msgs[2], xonly) {
+		t.Error("SchnorrVerifyOld accepted R.x >= p")
+	}
+	sigs[2] = sig
+	if SchnorrVerifyBatch(sigs, msgs, pubkeys) {
+		t.Error("SchnorrVerifyBatch accepted R.x >= p")
+	}
+}
+
+func TestSchnorrPrecommitNonce(t *testing.T) {
+	kp, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	xonly, err := kp.XOnlyPubkey()
+	if err != nil {
+		t.Fatalf("failed to get x-only pubkey: %v", err)
+	}
+	session := []byte("payment channel 42, round 7")
+	aux := make([]byte, 32)
+	if _, err := rand.Read(aux); err != nil {
+		t.Fatal(err)
+	}
+
+	signCtx := ContextCreate(ContextSign)
+	for _, ctx := range []*Context{nil, signCtx} {
+		secnonce := make([]byte, SchnorrSecnonceSize)
+		var pubnonce PublicKey
+		if !SchnorrPrecommitNonce(ctx, secnonce, &pubnonce, session, kp, aux) {
+			t.Fatal("failed to precommit nonce")
+		}
+
+		// The message is only chosen after committing to R
+		msg := make([]byte, 32)
+		if _, err := rand.Read(msg); err != nil {
+			t.Fatal(err)
+		}
+		var sig [64]byte
+		if err := SchnorrSignPrecommitted(sig[:], msg, kp, secnonce); err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		if !SchnorrVerify(sig[:], msg, xonly) {
+			t.Fatal("precommitted signature does not verify")
+		}
+
+		var compressed [33]byte
+		ECPubkeySerialize(compressed[:], &pubnonce, ECCompressed)
+		if compressed[0] != 0x02 || !bytes.Equal(compressed[1:], sig[:32]) {
+			t.Error("signature does not use the committed nonce point")
+		}
+
+		if !bytes.Equal(secnonce, make([]byte, SchnorrSecnonceSize)) {
+			t.Error("secret nonce not cleared after signing")
+		}
+		if SchnorrSignPrecommitted(sig[:], msg, kp, secnonce) == nil {
+			t.Error("signing twice with one secret nonce succeeded")
+		}
+	}
+
+	// Repeating a precommitment with the same inputs, e.g. after a crash,
+	// must give a fresh nonce
+	nonceFor := func(ctx *Context, msg, aux []byte) PublicKey {
+		secnonce := make([]byte, SchnorrSecnonceSize)
+		var pubnonce PublicKey
+		if !SchnorrPrecommitNonce(ctx, secnonce, &pubnonce, msg, kp, aux) {
+			t.Fatal("failed to precommit nonce")
+		}
+		return pubnonce
+	}
+	if nonceFor(nil, session, aux) == nonceFor(nil, session, aux) ||
+		nonceFor(signCtx, session, nil) == nonceFor(signCtx, session, nil) {
+		t.Error("repeated precommitment reused a nonce")
+	}
+
+	// The secret nonce is bound to the keypair it was made for
+	other, err := KeyPairGenerate()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	secnonce := make([]byte, SchnorrSecnonceSize)
+	var pubnonce PublicKey
+	if !SchnorrPrecommitNonce(nil, secnonce, &pubnonce, session, kp, aux) {
+		t.Fatal("failed to precommit nonce")
+	}
+	var sig [64]byte
+	if SchnorrSignPrecommitted(sig[:], make([]byte, 32), other, secnonce) == nil {
+		t.Error("signing with another key's secret nonce succeeded")
+	}
+
+	if SchnorrPrecommitNonce(nil, make([]byte, 64), &pubnonce, session, kp, aux) ||
+		SchnorrPrecommitNonce(nil, secnonce, nil, session, kp, aux) ||
+		SchnorrPrecommitNonce(nil, secnonce, &pubnonce, session, kp, aux[:31]) {
+		t.Error("invalid arguments accepted")
+	}
+	if !bytes.Equal(secnonce, make([]byte, SchnorrSecnonceSize)) {
+		t.Error("secret nonce not cleared on failure")
+	}
+}
+
+// bip340Vectors are the test vectors from BIP-340 (test-vectors.csv)
+var bip340Vectors = []struct {
+	seckey, pubkey, aux, msg, sig string
+	valid                         bool
+	comment                       string
+}{
+	{"0000000000000000000000000000000000000000000000000000000000000003", "F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9", "0000000000000000000000000000000000000000000000000000000000000000", "0000000000000000000000000000000000000000000000000000000000000000", "E907831F80848D1069A5371B402410364BDF1C5F8307B0084C55F1CE2DCA821525F66A4A85EA8B71E482A74F382D2CE5EBEEE8FDB2172F477DF4900D310536C0", true, ""},
+	{"B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF", "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659", "0000000000000000000000000000000000000000000000000000000000000001", "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", "6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE33418906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A", true, ""},
+	{"C90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74020BBEA63B14E5C9", "DD308AFEC5777E13121FA72B9CC1B7CC0139715309B086C960E18FD969774EB8", "C87AA53824B4D7AE2EB035A2B5BBBCCC080E76CDC6D1692C4B0B62D798E6D906", "7E2D58D8B3BCDF1ABADEC7829054F90DDA9805AAB56C77333024B9D0A508B75C", "5831AAEED7B44BB74E5EAB94BA9D4294C49BCF2A60728D8B4C200F50DD313C1BAB745879A5AD954A72C45A91C3A51D3C7ADEA98D82F8481E0E1E03674A6F3FB7", true, ""},
+	{"0B432B2677937381AEF05BB02A66ECD012773062CF3FA2549E44F58ED2401710", "25D1DFF95105F5253C4022F628A996AD3A0D95FBF21D468A1B33F8C160D8F517", "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF", "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF", "7EB0509757E246F19449885651611CB965ECC1A187DD51B64FDA1EDC9637D5EC97582B9CB13DB3933705B32BA982AF5AF25FD78881EBB32771FC5922EFC66EA3", true, "test fails if msg is reduced modulo p or n"},
+	{"", "D69C3509BB99E412E68B0FE8544E72837DFA30746D8BE2AA65975F29D22DC7B9", "", "4DF3C3F68FCC83B27E9D42C90431A72499F17875C81A599B566C9889B9696703", "00000000000000000000003B78CE563F89A0ED9414F5AA28AD0D96D6795F9C6376AFB1548AF603B3EB45C9F8207DEE1060CB71C04E80F593060B07D28308D7F4", true, ""},
+	{"", "EEFDEA4CDB677750A420FEE807EACF21EB9898AE79B9768766E4FAA04A2D4A34", "", "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", "6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E17776969E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B", false, "public key not on the curve"},
+	{"", "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659", "", "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", "FFF97BD5755EEEA420453A14355235D382F6472F8568A18B2F057A14602975563CC27944640AC607CD107AE10923D9EF7A73C643E166BE5EBEAFA34B1AC553E2", false, "has_even_y(R) is false"},
+	{"", "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659", "", "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", "1FA62E331EDBC21C394792D2AB1100A7B432B013DF3F6FF4F99FCB33E0E1515F28890B3EDB6E7189B630448B515CE4F8622A954CFE545735AAEA5134FCCDB2BD", false, "negated message"},
+	{"", "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659", "", "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", "6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E177769961764B3AA9B2FFCB6EF947B6887A226E8D7C93E00C5ED0C1834FF0D0C2E6DA6", false, "negated s value"},
+	{"", "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659", "", "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", "0000000000000000000000000000000000000000000000000000000000000000123DDA8328AF9C23A94C1FEECFD123BA4FB73476F0D594DCB65C6425BD186051", false, "sG - eP is infinite, x(inf) as 0"},
+	{"", "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659", "", "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", "00000000000000000000000000000000000000000000000000000000000000017615FBAF5AE28864013C099742DEADB4DBA87F11AC6754F93780D5A1837CF197", false, "sG - eP is infinite, x(inf) as 1"},
+	{"", "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659", "", "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", "4A298DACAE57395A15D0795DDBFD1DCB564DA82B0F269BC70A74F8220429BA1D69E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B", false, "sig[0:32] is not an X coordinate on the curve"},
+	{"", "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659", "", "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F69E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B", false, "sig[0:32] is equal to field size"},
+	{"", "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659", "", "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", "6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E177769FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", false, "sig[32:64] is equal to curve order"},
+	{"", "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC30", "", "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89", "6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E17776969E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B", false, "public key is not a valid X coordinate because it exceeds the field size"},
+	{"0340034003400340034003400340034003400340034003400340034003400340", "778CAA53B4393AC467774D09497A87224BF9FAB6F6E68B23086497324D6FD117", "0000000000000000000000000000000000000000000000000000000000000000", "", "71535DB165ECD9FBBC046E5FFAEA61186BB6AD436732FCCC25291A55895464CF6069CE26BF03466228F19A3A62DB8A649F2D560FAC652827D1AF0574E427AB63", true, "message of size 0"},
+	{"0340034003400340034003400340034003400340034003400340034003400340", "778CAA53B4393AC467774D09497A87224BF9FAB6F6E68B23086497324D6FD117", "0000000000000000000000000000000000000000000000000000000000000000", "11", "08A20A0AFEF64124649232E0693C583AB1B9934AE63B4C3511F3AE1134C6A303EA3173BFEA6683BD101FA5AA5DBC1996FE7CACFC5A577D33EC14564CEC2BACBF", true, "message of size 1"},
+	{"0340034003400340034003400340034003400340034003400340034003400340", "778CAA53B4393AC467774D09497A87224BF9FAB6F6E68B23086497324D6FD117", "0000000000000000000000000000000000000000000000000000000000000000", "0102030405060708090A0B0C0D0E0F1011", "5130F39A4059B43BC7CAC09A19ECE52B5D8699D1A71E3C52DA9AFDB6B50AC370C4A482B77BF960F8681540E25B6771ECE1E5A37FD80E5A51897C5566A97EA5A5", true, "message of size 17"},
+	{"0340034003400340034003400340034003400340034003400340034003400340", "778CAA53B4393AC467774D09497A87224BF9FAB6F6E68B23086497324D6FD117", "0000000000000000000000000000000000000000000000000000000000000000", strings.Repeat("99", 100), "403B12B0D8555A344175EA7EC746566303321E5DBFA8BE6F091635163ECA79A8585ED3E3170807E7C03B720FC54C7B23897FCBA0E9D0B4A06894CFD249F22367", true, "message of size 100"},
+}
+
+func TestSchnorrBIP340Vectors(t *testing.T) {
+	for i, v := range bip340Vectors {
+		pub, _ := hex.DecodeString(v.pubkey)
+		msg, _ := hex.DecodeString(v.msg)
+		sig, _ := hex.DecodeString(v.sig)
+
+		// Signing reproduces the signature exactly; only 32-byte messages
+		// can be signed
+		if v.seckey != "" && len(msg) == 32 {
+			seckey, _ := hex.DecodeString(v.seckey)
+			aux, _ := hex.DecodeString(v.aux)
+			kp, err := KeyPairCreate(seckey)
+			if err != nil {
+				t.Fatalf("vector %d: %v", i, err)
+			}
+			xonly, err := kp.XOnlyPubkey()
+			if err != nil {
+				t.Fatalf("vector %d: %v", i, err)
+			}
+			if x := xonly.Serialize(); !bytes.Equal(x[:], pub) {
+				t.Errorf("vector %d: pubkey %X, want %s", i, x, v.pubkey)
+			}
+			var got [64]byte
+			if err := SchnorrSign(got[:], msg, kp, aux); err != nil {
+				t.Fatalf("vector %d: sign: %v", i, err)
+			}
+			if !bytes.Equal(got[:], sig) {
+				t.Errorf("vector %d: signature %X, want %s", i, got, v.sig)
+			}
+		}
+
+		xonly, err := XOnlyPubkeyParse(pub)
+		if err != nil {
+			if v.valid {
+				t.Errorf("vector %d: pubkey rejected: %v", i, err)
+			}
+			continue
+		}
+		if i == 5 || i == 14 {
+			t.Errorf("vector %d (%s): pubkey accepted", i, v.comment)
+		}
+		ok := SchnorrVerifyStream(sig, bytes.NewReader(msg), xonly)
+		if len(msg) == 32 && SchnorrVerify(sig, msg, xonly) != ok {
+			t.Errorf("vector %d: SchnorrVerify and SchnorrVerifyStream disagree", i)
+		}
+		if ok != v.valid {
+			t.Errorf("vector %d (%s): verify = %v, want %v", i, v.comment, ok, v.valid)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package p256k1
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"unsafe"
+)
+
+// Share is one share of a secret split with SplitSecret: the value of the
+// sharing polynomial at a non-zero point X
+type Share struct {
+	X uint32
+	Y [32]byte
+}
+
+// SplitSecret splits secret into the given number of shares using Shamir's
+// scheme over the scalar field, so that any threshold of them reconstruct it
+// with CombineShares and fewer reveal nothing about it. Shares are evaluated
+// at X = 1, 2, ..., shares. The secret must be below the group order.
+func SplitSecret(secret [32]byte, threshold, shares int) ([]Share, error) {
+	coeffs, err := shamirPolynomial(secret, threshold, shares, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	out := shamirShares(coeffs, shares)
+	for i := range coeffs {
+		coeffs[i].clear()
+	}
+	return out, nil
+}
+
+// shamirPolynomial returns the coefficients of a random polynomial of degree
+// threshold-1 whose constant term is secret. The other coefficients are read
+// from rng and are non-zero, so the degree is exact.
+func shamirPolynomial(secret [32]byte, threshold, shares int, rng io.Reader) ([]Scalar, error) {
+	if threshold < 1 || threshold > shares {
+		return nil, fmt.Errorf("threshold %d must be between 1 and the number of shares %d", threshold, shares)
+	}
+	if uint64(shares) > math.MaxUint32 {
+		return nil, errors.New("too many shares")
+	}
+
+	coeffs := make([]Scalar, threshold)
+	if coeffs[0].setB32(secret[:]) {
+		return nil, errors.New("secret must be below the group order")
+	}
+	var b [32]byte
+	for i := 1; i < threshold; i++ {
+		if err := seckeyGenerate(b[:], rng); err != nil {
+			for j := range coeffs {
+				coeffs[j].clear()
+			}
+			return nil, err
+		}
+		coeffs[i].setB32(b[:])
+	}
+	memclear(unsafe.Pointer(&b[0]), 32)
+	return coeffs, nil
+}
+
+// shamirShares evaluates the polynomial at X = 1, ..., n
+func shamirShares(coeffs []Scalar, n int) []Share {
+	out := make([]Share, n)
+	for i := range out {
+		out[i].X = uint32(i + 1)
+		var x, y Scalar
+		x.setInt(uint(out[i].X))
+		shamirEval(&y, coeffs, &x)
+		y.getB32(out[i].Y[:])
+		y.clear()
+	}
+	return out
+}
+
+// shamirEval sets r to the polynomial with the given coefficients evaluated
+// at x, using Horner's rule
+func shamirEval(r *Scalar, coeffs []Scalar, x *Scalar) {
+	*r = coeffs[len(coeffs)-1]
+	for i := len(coeffs) - 2; i >= 0; i-- {
+		r.mul(r, x)
+		r.add(r, &coeffs[i])
+	}
+}
+
+// CombineShares reconstructs a secret from shares made by SplitSecret, by
+// Lagrange interpolation at X = 0. At least threshold shares must be given;
+// with fewer the result is an unrelated value, not an error, since the
+// shares themselves do not record the threshold.
+func CombineShares(shares []Share) ([32]byte, error) {
+	var out [32]byte
+	if len(shares) == 0 {
+		return out, errors.New("no shares")
+	}
+
+	xs := make([]Scalar, len(shares))
+	ys := make([]Scalar, len(shares))
+	defer func() {
+		for i := range ys {
+			ys[i].clear()
+		}
+	}()
+	for i := range shares {
+		if shares[i].X == 0 {
+			return out, fmt.Errorf("%w: share has X = 0", ErrParse)
+		}
+		for j := 0; j < i; j++ {
+			if shares[j].X == shares[i].X {
+				return out, fmt.Errorf("%w: duplicate share X = %d", ErrParse, shares[i].X)
+			}
+		}
+		xs[i].setInt(uint(shares[i].X))
+		if ys[i].setB32(shares[i].Y[:]) {
+			return out, fmt.Errorf("%w: share value not below the group order", ErrParse)
+		}
+	}
+
+	var zero Scalar
+	secret := ScalarLagrangeInterpolate(xs, ys, zero)
+	secret.getB32(out[:])
+	secret.clear()
+	return out, nil
+}
+
+// ScalarLagrangeInterpolate evaluates at atX the polynomial of lowest degree
+// through the points (xs[i], ys[i]), i.e. it returns
+//
+//	sum_i ys[i] * prod_{j != i} (atX - xs[j]) / (xs[i] - xs[j])
+//
+// CombineShares is the case atX = 0; schemes such as FROST also need other
+// points. It panics if xs and ys differ in length or xs has duplicates.
+func ScalarLagrangeInterpolate(xs, ys []Scalar, atX Scalar) Scalar {
+	if len(xs) != len(ys) {
+		panic("xs and ys differ in length")
+	}
+
+	var r, term, num, den, d Scalar
+	for i := range xs {
+		num.setInt(1)
+		den.setInt(1)
+		for j := range xs {
+			if j == i {
+				continue
+			}
+			d.sub(&atX, &xs[j])
+			num.mul(&num, &d)
+			d.sub(&xs[i], &xs[j])
+			if d.isZero() {
+				panic("duplicate interpolation point")
+			}
+			den.mul(&den, &d)
+		}
+		den.inv(&den)
+		term.mul(&ys[i], &num)
+		term.mul(&term, &den)
+		r.add(&r, &term)
+	}
+	term.clear()
+	return r
+}
+
+// SplitSecretVerifiable is SplitSecret with Feldman commitments: it also
+// returns coeff*G for each coefficient of the sharing polynomial, lowest
+// degree first, so holders can check their shares with VerifyShare. The
+// first commitment is the public key of secret, which must therefore be a
+// valid secret key.
+func SplitSecretVerifiable(secret [32]byte, threshold, shares int) ([]Share, []PublicKey, error) {
+	coeffs, err := shamirPolynomial(secret, threshold, shares, rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		for i := range coeffs {
+			coeffs[i].clear()
+		}
+	}()
+
+	commitments := make([]PublicKey, threshold)
+	var b [32]byte
+	for i := range coeffs {
+		coeffs[i].getB32(b[:])
+		if err := ECPubkeyCreate(&commitments[i], b[:]); err != nil {
+			memclear(unsafe.Pointer(&b[0]), 32)
+			return nil, nil, err
+		}
+	}
+	memclear(unsafe.Pointer(&b[0]), 32)
+	return shamirShares(coeffs, shares), commitments, nil
+}
+
+// VerifyShare checks a share against Feldman commitments from
+// SplitSecretVerifiable: share.Y*G must equal the sum of
+// commitments[j] * share.X^j
+func VerifyShare(share Share, commitments []PublicKey) bool {
+	if share.X == 0 || len(commitments) == 0 {
+		return false
+	}
+	var y Scalar
+	if y.setB32(share.Y[:]) {
+		return false
+	}
+
+	var x, xj Scalar
+	x.setInt(uint(share.X))
+	xj.setInt(1)
+	var sum, term GroupElementJacobian
+	sum.setInfinity()
+	for i := range commitments {
+		if !ECPubkeyIsValid(&commitments[i]) {
+			return false
+		}
+		var c GroupElementAffine
+		pubkeyLoad(&c, &commitments[i])
+		ecmultWindowedVar(&term, &c, &xj)
+		sum.addVar(&sum, &term)
+		xj.mul(&xj, &x)
+	}
+
+	var want GroupElementJacobian
+	EcmultGen(&want, &y)
+	var got, wantAff GroupElementAffine
+	got.setGEJ(&sum)
+	wantAff.setGEJ(&want)
+	return got.equal(&wantAff)
+}
@@ -0,0 +1,239 @@
+package p256k1
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// shamirSubsets calls f with every subset of shares of size k
+func shamirSubsets(shares []Share, k int, f func([]Share)) {
+	var pick func(start int, chosen []Share)
+	pick = func(start int, chosen []Share) {
+		if len(chosen) == k {
+			f(chosen)
+			return
+		}
+		for i := start; i < len(shares); i++ {
+			pick(i+1, append(chosen, shares[i]))
+		}
+	}
+	pick(0, make([]Share, 0, k))
+}
+
+func TestSplitCombineSecret(t *testing.T) {
+	var secret [32]byte
+	if _, err := rand.Read(secret[:]); err != nil {
+		t.Fatal(err)
+	}
+	secret[0] &= 0x7f
+
+	for _, tc := range []struct{ threshold, shares int }{{1, 1}, {1, 3}, {2, 3}, {3, 5}, {5, 5}} {
+		shares, err := SplitSecret(secret, tc.threshold, tc.shares)
+		if err != nil {
+			t.Fatalf("%d-of-%d: SplitSecret failed: %v", tc.threshold, tc.shares, err)
+		}
+		if len(shares) != tc.shares {
+			t.Fatalf("%d-of-%d: got %d shares", tc.threshold, tc.shares, len(shares))
+		}
+
+		// Any threshold or more shares reconstruct the secret
+		for k := tc.threshold; k <= tc.shares; k++ {
+			shamirSubsets(shares, k, func(subset []Share) {
+				got, err := CombineShares(subset)
+				if err != nil {
+					t.Fatalf("%d-of-%d: CombineShares failed: %v", tc.threshold, tc.shares, err)
+				}
+				if got != secret {
+					t.Errorf("%d-of-%d: %d shares gave the wrong secret", tc.threshold, tc.shares, k)
+				}
+			})
+		}
+
+		// Fewer do not
+		if tc.threshold > 1 {
+			shamirSubsets(shares, tc.threshold-1, func(subset []Share) {
+				got, err := CombineShares(subset)
+				if err != nil {
+					t.Fatalf("%d-of-%d: CombineShares failed: %v", tc.threshold, tc.shares, err)
+				}
+				if got == secret {
+					t.Errorf("%d-of-%d: %d shares reconstructed the secret", tc.threshold, tc.shares, len(subset))
+				}
+			})
+		}
+	}
+}
+
+func TestSplitSecretErrors(t *testing.T) {
+	var secret [32]byte
+	for _, tc := range []struct{ threshold, shares int }{{0, 3}, {4, 3}, {-1, 1}, {1, 0}} {
+		if _, err := SplitSecret(secret, tc.threshold, tc.shares); err == nil {
+			t.Errorf("%d-of-%d: expected an error", tc.threshold, tc.shares)
+		}
+	}
+
+	var n [32]byte
+	order, _ := hex.DecodeString(testGroupOrderHex)
+	copy(n[:], order)
+	if _, err := SplitSecret(n, 2, 3); err == nil {
+		t.Error("expected an error for a secret equal to the group order")
+	}
+
+	shares, err := SplitSecret(secret, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CombineShares(nil); err == nil {
+		t.Error("expected an error for no shares")
+	}
+	if _, err := CombineShares([]Share{shares[0], shares[0]}); !errors.Is(err, ErrParse) {
+		t.Errorf("duplicate shares: got %v, want ErrParse", err)
+	}
+	if _, err := CombineShares([]Share{{X: 0, Y: shares[0].Y}, shares[1]}); !errors.Is(err, ErrParse) {
+		t.Errorf("share at X = 0: got %v, want ErrParse", err)
+	}
+	bad := shares[1]
+	copy(bad.Y[:], n[:])
+	if _, err := CombineShares([]Share{shares[0], bad}); !errors.Is(err, ErrParse) {
+		t.Errorf("share value equal to the group order: got %v, want ErrParse", err)
+	}
+}
+
+func TestVerifiableSecretSharing(t *testing.T) {
+	seckey, err := ECSeckeyGenerate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var secret [32]byte
+	copy(secret[:], seckey)
+
+	shares, commitments, err := SplitSecretVerifiable(secret, 3, 5)
+	if err != nil {
+		t.Fatalf("SplitSecretVerifiable failed: %v", err)
+	}
+	if len(commitments) != 3 {
+		t.Fatalf("got %d commitments, want 3", len(commitments))
+	}
+
+	// The constant-term commitment is the secret's public key
+	var pubkey PublicKey
+	if err := ECPubkeyCreate(&pubkey, seckey); err != nil {
+		t.Fatal(err)
+	}
+	if ECPubkeyCmp(&pubkey, &commitments[0]) != 0 {
+		t.Error("first commitment is not the secret's public key")
+	}
+
+	for _, share := range shares {
+		if !VerifyShare(share, commitments) {
+			t.Errorf("valid share at X = %d failed to verify", share.X)
+		}
+	}
+	got, err := CombineShares(shares[1:4])
+	if err != nil || got != secret {
+		t.Errorf("verifiable shares did not reconstruct the secret: %v", err)
+	}
+
+	// Tampering with the value, the point or the commitments is detected
+	bad := shares[0]
+	bad.Y[31] ^= 1
+	if VerifyShare(bad, commitments) {
+		t.Error("share with a modified value verified")
+	}
+	bad = shares[0]
+	bad.X = 6
+	if VerifyShare(bad, commitments) {
+		t.Error("share with a modified X verified")
+	}
+	if VerifyShare(shares[0], commitments[:2]) {
+		t.Error("share verified against truncated commitments")
+	}
+	// At X = 1 every power of X is 1, so use another share
+	swapped := []PublicKey{commitments[1], commitments[0], commitments[2]}
+	if VerifyShare(shares[1], swapped) {
+		t.Error("share verified against reordered commitments")
+	}
+	if VerifyShare(shares[0], []PublicKey{{}, commitments[1], commitments[2]}) {
+		t.Error("share verified against an invalid commitment")
+	}
+
+	var zero [32]byte
+	if _, _, err := SplitSecretVerifiable(zero, 2, 3); err == nil {
+		t.Error("expected an error for a zero secret")
+	}
+}
+
+func TestScalarLagrangeInterpolate(t *testing.T) {
+	scalar := func(v uint) Scalar {
+		var s Scalar
+		s.setInt(v)
+		return s
+	}
+
+	// f(x) = 3 + 2x + 5x^2 through x = 1, 2, 3
+	xs := []Scalar{scalar(1), scalar(2), scalar(3)}
+	ys := []Scalar{scalar(10), scalar(27), scalar(54)}
+	for _, c := range []struct{ x, want uint }{{0, 3}, {1, 10}, {3, 54}, {4, 91}, {10, 523}} {
+		want := scalar(c.want)
+		if got := ScalarLagrangeInterpolate(xs, ys, scalar(c.x)); !got.equal(&want) {
+			t.Errorf("f(%d) = %x, want %d", c.x, got.Bytes(), c.want)
+		}
+	}
+
+	// At x = -1, f = 3 - 2 + 5 = 6
+	var negOne Scalar
+	one := scalar(1)
+	negOne.negate(&one)
+	if got, want := ScalarLagrangeInterpolate(xs, ys, negOne), scalar(6); !got.equal(&want) {
+		t.Errorf("f(-1) = %x, want 6", got.Bytes())
+	}
+
+	// Random polynomials of degree t-1 through t random points
+	randScalar := func() Scalar {
+		var b [32]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			t.Fatal(err)
+		}
+		var s Scalar
+		s.setB32(b[:])
+		return s
+	}
+	for deg := 0; deg < 6; deg++ {
+		coeffs := make([]Scalar, deg+1)
+		for i := range coeffs {
+			coeffs[i] = randScalar()
+		}
+		xs := make([]Scalar, deg+1)
+		ys := make([]Scalar, deg+1)
+		for i := range xs {
+			xs[i] = randScalar()
+			shamirEval(&ys[i], coeffs, &xs[i])
+		}
+		at := randScalar()
+		var want Scalar
+		shamirEval(&want, coeffs, &at)
+		if got := ScalarLagrangeInterpolate(xs, ys, at); !got.equal(&want) {
+			t.Errorf("degree %d: interpolation does not match the polynomial", deg)
+		}
+	}
+
+	if got := ScalarLagrangeInterpolate(nil, nil, scalar(5)); !got.isZero() {
+		t.Error("interpolating no points should give zero")
+	}
+
+	for name, f := range map[string]func(){
+		"length mismatch": func() { ScalarLagrangeInterpolate(xs, ys[:2], one) },
+		"duplicate x":     func() { ScalarLagrangeInterpolate([]Scalar{one, one}, ys[:2], one) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
@@ -0,0 +1,119 @@
+package p256k1
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"unsafe"
+)
+
+// Groundwork for additive threshold ECDSA. Each party holds additive shares
+// x_i of the key and k_i of the nonce, so that x = sum x_i and k = sum k_i,
+// and publishes X_i = x_i*G and R_i = k_i*G. The functions here cover only
+// the public point and scalar combination; computing s = k^-1(m + r*x)
+// without any party learning k or x needs a multiplicative-to-additive
+// protocol (e.g. Lindell's) that is not implemented here.
+
+// ECDSAPresignShare is one party's share of a presignature nonce: the secret
+// k_i and the public nonce point R_i = k_i*G sent to the other parties
+type ECDSAPresignShare struct {
+	k     Scalar
+	Point PublicKey
+}
+
+// ECDSAPresignShareGenerate creates a presignature nonce share from
+// crypto/rand. The share holds a secret and must be cleared with Clear once
+// it is no longer needed; a nonce share must never be used for two
+// signatures.
+func ECDSAPresignShareGenerate() (*ECDSAPresignShare, error) {
+	return ecdsaPresignShareGenerate(rand.Reader)
+}
+
+// ecdsaPresignShareGenerate creates a nonce share from rng
+func ecdsaPresignShareGenerate(rng io.Reader) (*ECDSAPresignShare, error) {
+	var b [32]byte
+	if err := seckeyGenerate(b[:], rng); err != nil {
+		return nil, err
+	}
+	share := &ECDSAPresignShare{}
+	share.k.setB32(b[:])
+	if err := ECPubkeyCreate(&share.Point, b[:]); err != nil {
+		memclear(unsafe.Pointer(&b[0]), 32)
+		share.Clear()
+		return nil, err
+	}
+	memclear(unsafe.Pointer(&b[0]), 32)
+	return share, nil
+}
+
+// Clear clears the secret nonce share
+func (s *ECDSAPresignShare) Clear() {
+	s.k.clear()
+	s.Point.data = [64]byte{}
+}
+
+// ECDSAPresignature is the combined public part of a presignature: the nonce
+// point R = sum R_i and the signature value r = x(R) mod n
+type ECDSAPresignature struct {
+	Point PublicKey
+	r     Scalar
+}
+
+// R returns the signature value r = x(R) mod n, 32 bytes big-endian
+func (p *ECDSAPresignature) R() [32]byte {
+	return p.r.Bytes()
+}
+
+// ECDSACombinePresign combines the parties' public nonce points into a
+// presignature. It fails if any point is invalid or if the points sum to
+// infinity.
+func ECDSACombinePresign(points []PublicKey) (*ECDSAPresignature, error) {
+	var sum GroupElementAffine
+	if err := pubkeySum(&sum, points); err != nil {
+		return nil, err
+	}
+	pre := &ECDSAPresignature{}
+	pubkeySave(&pre.Point, &sum)
+	var xb [32]byte
+	sum.x.getB32(xb[:])
+	pre.r.setB32(xb[:])
+	return pre, nil
+}
+
+// ECDSACombinePubkeys combines the parties' public key shares X_i into the
+// joint public key X = sum X_i, which signatures from the shared key verify
+// under. It fails if any key is invalid or if the keys sum to infinity.
+func ECDSACombinePubkeys(pubkeys []PublicKey) (PublicKey, error) {
+	var out PublicKey
+	var sum GroupElementAffine
+	if err := pubkeySum(&sum, pubkeys); err != nil {
+		return out, err
+	}
+	pubkeySave(&out, &sum)
+	return out, nil
+}
+
+// pubkeySum sets r to the sum of the given public keys, normalized
+func pubkeySum(r *GroupElementAffine, pubkeys []PublicKey) error {
+	if len(pubkeys) == 0 {
+		return errors.New("no points to combine")
+	}
+	var sum GroupElementJacobian
+	sum.setInfinity()
+	for i := range pubkeys {
+		if !ECPubkeyIsValid(&pubkeys[i]) {
+			return fmt.Errorf("%w: point %d", ErrInvalidPubkey, i)
+		}
+		var p GroupElementAffine
+		pubkeyLoad(&p, &pubkeys[i])
+		sum.addGE(&sum, &p)
+	}
+	if sum.isInfinity() {
+		return fmt.Errorf("%w: points sum to infinity", ErrInvalidPubkey)
+	}
+	r.setGEJ(&sum)
+	r.x.normalize()
+	r.y.normalize()
+	return nil
+}
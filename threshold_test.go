@@ -0,0 +1,112 @@
+package p256k1
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestECDSACombinePresign(t *testing.T) {
+	for _, parties := range []int{1, 2, 3} {
+		shares := make([]*ECDSAPresignShare, parties)
+		points := make([]PublicKey, parties)
+		var k Scalar
+		for i := range shares {
+			share, err := ECDSAPresignShareGenerate()
+			if err != nil {
+				t.Fatalf("ECDSAPresignShareGenerate failed: %v", err)
+			}
+			shares[i] = share
+			points[i] = share.Point
+			k.add(&k, &share.k)
+		}
+
+		pre, err := ECDSACombinePresign(points)
+		if err != nil {
+			t.Fatalf("%d parties: ECDSACombinePresign failed: %v", parties, err)
+		}
+
+		// R is the sum of the nonce points, i.e. (sum k_i)*G
+		kb := k.Bytes()
+		var want PublicKey
+		if err := ECPubkeyCreate(&want, kb[:]); err != nil {
+			t.Fatal(err)
+		}
+		if ECPubkeyCmp(&pre.Point, &want) != 0 {
+			t.Errorf("%d parties: combined R is not the sum of the nonce points", parties)
+		}
+
+		// A signature made with the joint key and joint nonce has this r
+		// and verifies under the combined public key
+		keys := make([]PublicKey, parties)
+		var x Scalar
+		for i := range keys {
+			seckey, err := ECSeckeyGenerate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ECPubkeyCreate(&keys[i], seckey); err != nil {
+				t.Fatal(err)
+			}
+			var xi Scalar
+			xi.setB32(seckey)
+			x.add(&x, &xi)
+		}
+		joint, err := ECDSACombinePubkeys(keys)
+		if err != nil {
+			t.Fatalf("%d parties: ECDSACombinePubkeys failed: %v", parties, err)
+		}
+		msg := make([]byte, 32)
+		if _, err := rand.Read(msg); err != nil {
+			t.Fatal(err)
+		}
+		xb := x.Bytes()
+		var sig ECDSASignature
+		if err := ECDSASignWithNonce(&sig, msg, xb[:], kb[:]); err != nil {
+			t.Fatalf("ECDSASignWithNonce failed: %v", err)
+		}
+		if sig.r.Bytes() != pre.R() {
+			t.Errorf("%d parties: signature r does not match the presignature", parties)
+		}
+		if !ECDSAVerify(&sig, msg, &joint) {
+			t.Errorf("%d parties: signature does not verify under the combined key", parties)
+		}
+
+		for _, share := range shares {
+			share.Clear()
+			if !share.k.isZero() {
+				t.Error("Clear left the nonce share set")
+			}
+		}
+	}
+}
+
+func TestECDSACombinePresignErrors(t *testing.T) {
+	share, err := ECDSAPresignShareGenerate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer share.Clear()
+
+	if _, err := ECDSACombinePresign(nil); err == nil {
+		t.Error("expected an error for no points")
+	}
+	if _, err := ECDSACombinePresign([]PublicKey{share.Point, {}}); !errors.Is(err, ErrInvalidPubkey) {
+		t.Errorf("invalid point: got %v, want ErrInvalidPubkey", err)
+	}
+
+	// R_i + (-R_i) is infinity
+	var neg Scalar
+	neg.negate(&share.k)
+	nb := neg.Bytes()
+	var negPoint PublicKey
+	if err := ECPubkeyCreate(&negPoint, nb[:]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ECDSACombinePresign([]PublicKey{share.Point, negPoint}); !errors.Is(err, ErrInvalidPubkey) {
+		t.Errorf("points summing to infinity: got %v, want ErrInvalidPubkey", err)
+	}
+	if _, err := ECDSACombinePubkeys([]PublicKey{share.Point, negPoint}); !errors.Is(err, ErrInvalidPubkey) {
+		t.Errorf("keys summing to infinity: got %v, want ErrInvalidPubkey", err)
+	}
+}
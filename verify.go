@@ -3,6 +3,7 @@ package p256k1
 import (
 	"crypto/sha256"
 	"hash"
+	"io"
 	"sync"
 	"unsafe"
 )
@@ -352,8 +353,18 @@ func secp256k1_fe_is_zero(a *secp256k1_fe) bool {
 	return (a.n[0] | a.n[1] | a.n[2] | a.n[3] | a.n[4]) == 0
 }
 
-// secp256k1_fe_is_odd checks if field element is odd
+// secp256k1_fe_is_odd checks if field element is odd. The limbs carry no
+// normalization flag, so a copy is normalized first: a non-canonical
+// representation such as p itself has an odd low limb but the value 0.
 func secp256k1_fe_is_odd(a *secp256k1_fe) bool {
+	t := *a
+	secp256k1_fe_normalize_var(&t)
+	return secp256k1_fe_is_odd_normalized(&t)
+}
+
+// secp256k1_fe_is_odd_normalized checks if a normalized field element is
+// odd. The result is meaningless for a non-normalized input.
+func secp256k1_fe_is_odd_normalized(a *secp256k1_fe) bool {
 	return a.n[0]&1 == 1
 }
 
@@ -380,13 +391,43 @@ func secp256k1_fe_normalizes_to_zero(r *secp256k1_fe) bool {
 	return fe.normalizesToZeroVar()
 }
 
-// secp256k1_fe_negate negates field element
+// secp256k1_fe_load converts a secp256k1_fe into a FieldElement. The C-style
+// type carries no magnitude, so the limbs are weakly normalized to give the
+// result a known magnitude of 1 before any operation relies on it.
+func secp256k1_fe_load(r *FieldElement, a *secp256k1_fe) {
+	r.n = a.n
+	r.normalized = false
+	r.normalizeWeak()
+}
+
+// secp256k1_fe_store copies a FieldElement into a secp256k1_fe, weakly
+// normalizing it first so the result has magnitude 1
+func secp256k1_fe_store(r *secp256k1_fe, a *FieldElement) {
+	t := *a
+	t.normalizeWeak()
+	r.n = t.n
+}
+
+// secp256k1_gej_load converts a secp256k1_gej into a GroupElementJacobian with
+// magnitude-1 coordinates
+func secp256k1_gej_load(r *GroupElementJacobian, a *secp256k1_gej) {
+	secp256k1_fe_load(&r.x, &a.x)
+	secp256k1_fe_load(&r.y, &a.y)
+	secp256k1_fe_load(&r.z, &a.z)
+	r.infinity = a.infinity != 0
+}
+
+// secp256k1_fe_negate negates field element. Since secp256k1_fe does not
+// track magnitude, the caller-supplied bound m cannot be checked; the input
+// is weakly normalized first so the negation is correct for any magnitude.
+// m must be at least 1 to mirror the C precondition.
 func secp256k1_fe_negate(r *secp256k1_fe, a *secp256k1_fe, m int) {
-	var fe FieldElement
-	fe.n = a.n
-	var fea FieldElement
-	fea.n = a.n
-	fe.negate(&fea, m)
+	if m < 1 {
+		panic("secp256k1_fe_negate: magnitude must be at least 1")
+	}
+	var fea, fe FieldElement
+	secp256k1_fe_load(&fea, a)
+	fe.negate(&fea, 1)
 	r.n = fe.n
 }
 
@@ -400,6 +441,38 @@ func secp256k1_fe_add(r *secp256k1_fe, a *secp256k1_fe) {
 	r.n = fe.n
 }
 
+// secp256k1_fe_mul_max_magnitude is the largest magnitude an input to
+// secp256k1_fe_mul or secp256k1_fe_sqr may have
+const secp256k1_fe_mul_max_magnitude = 8
+
+// secp256k1_fe_max_magnitude is the largest magnitude the 5x52 limbs can
+// hold without overflowing
+const secp256k1_fe_max_magnitude = 32
+
+// secp256k1_fe_add_checked adds a to r like secp256k1_fe_add, where rmag and
+// amag are the magnitudes the caller is tracking for r and a, since
+// secp256k1_fe does not carry them. It returns the magnitude of the sum and
+// panics if that would exceed what the limbs can hold.
+func secp256k1_fe_add_checked(r *secp256k1_fe, a *secp256k1_fe, rmag, amag int) int {
+	if rmag < 0 || amag < 0 {
+		panic("secp256k1_fe_add_checked: negative magnitude")
+	}
+	m := rmag + amag
+	if m > secp256k1_fe_max_magnitude {
+		panic("secp256k1_fe_add_checked: magnitude overflow")
+	}
+	secp256k1_fe_add(r, a)
+	return m
+}
+
+// secp256k1_fe_verify_mul_magnitude panics if a field element of magnitude m
+// could not safely be passed to secp256k1_fe_mul or secp256k1_fe_sqr
+func secp256k1_fe_verify_mul_magnitude(m int) {
+	if m > secp256k1_fe_mul_max_magnitude {
+		panic("field element magnitude too large for mul/sqr")
+	}
+}
+
 // secp256k1_fe_add_int adds int to field element
 func secp256k1_fe_add_int(r *secp256k1_fe, a int) {
 	var fe FieldElement
@@ -408,34 +481,24 @@ func secp256k1_fe_add_int(r *secp256k1_fe, a int) {
 	r.n = fe.n
 }
 
-// secp256k1_fe_set_b32_mod sets field element from bytes mod
+// secp256k1_fe_set_b32_mod sets r to the 32-byte big-endian value a reduced
+// mod p. The result is fully normalized, so callers do not need to normalize
+// it again before comparing or serializing.
 func secp256k1_fe_set_b32_mod(r *secp256k1_fe, a []byte) {
 	var fe FieldElement
 	fe.setB32(a)
+	fieldNormalize(&fe)
 	r.n = fe.n
 }
 
-// secp256k1_fe_set_b32_limit sets field element from bytes limit
+// secp256k1_fe_set_b32_limit sets r to the 32-byte big-endian value a and
+// returns false if the value is not below p. On success the result is
+// already normalized since no reduction is needed.
 func secp256k1_fe_set_b32_limit(r *secp256k1_fe, a []byte) bool {
 	var fe FieldElement
-	if err := fe.setB32(a); err != nil {
-		return false
-	}
-
-	// Check if normalized value is within limit
-	fe.normalize()
+	ok := fe.setB32Limit(a)
 	r.n = fe.n
-
-	// Check if r >= p (field modulus)
-	// p = 0xFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F
-	// Check: r.n[4] == 0x0FFFFFFFFFFFF && r.n[3] == 0xFFFFFFFFFFFFF &&
-	//        r.n[2] == 0xFFFFFFFFFFFFF && r.n[1] == 0xFFFFFFFFFFFFF &&
-	//        r.n[0] >= 0xFFFFEFFFFFC2F
-	limit := (r.n[4] == 0x0FFFFFFFFFFFF) &&
-		((r.n[3] & r.n[2] & r.n[1]) == 0xFFFFFFFFFFFFF) &&
-		(r.n[0] >= 0xFFFFEFFFFFC2F)
-
-	return !limit
+	return ok
 }
 
 // secp256k1_fe_get_b32 gets field element to bytes
@@ -447,20 +510,21 @@ func secp256k1_fe_get_b32(r []byte, a *secp256k1_fe) {
 
 // secp256k1_fe_equal checks if two field elements are equal
 func secp256k1_fe_equal(a *secp256k1_fe, b *secp256k1_fe) bool {
-	var fea, feb FieldElement
-	fea.n = a.n
-	feb.n = b.n
-	// Normalize both to ensure consistent state since secp256k1_fe doesn't carry
-	// magnitude information. This ensures that the limbs correspond to a valid
-	// field element representation before we compute the comparison.
-	fea.normalize()
-	feb.normalize()
-	
-	// Now compute the difference and check if it's zero: (a - b) ≡ 0 (mod p)
-	var na FieldElement
-	na.negate(&fea, 1)
-	na.add(&feb)
-	return na.normalizesToZeroVar()
+	// Normalize both since secp256k1_fe doesn't carry magnitude information;
+	// once both are in canonical form the limbs can be compared directly
+	na, nb := *a, *b
+	secp256k1_fe_normalize_var(&na)
+	secp256k1_fe_normalize_var(&nb)
+	return secp256k1_fe_equal_normalized(&na, &nb)
+}
+
+// secp256k1_fe_equal_normalized checks if two field elements are equal by
+// direct limb comparison. Both a and b must already be normalized (e.g. by
+// secp256k1_fe_normalize_var or secp256k1_fe_set_b32_limit); otherwise equal
+// values with different representations compare unequal.
+func secp256k1_fe_equal_normalized(a *secp256k1_fe, b *secp256k1_fe) bool {
+	return a.n[0] == b.n[0] && a.n[1] == b.n[1] && a.n[2] == b.n[2] &&
+		a.n[3] == b.n[3] && a.n[4] == b.n[4]
 }
 
 // secp256k1_fe_sqrt computes square root
@@ -576,10 +640,7 @@ func secp256k1_gej_clear(r *secp256k1_gej) {
 // secp256k1_ge_set_gej sets affine from Jacobian
 func secp256k1_ge_set_gej(r *secp256k1_ge, a *secp256k1_gej) {
 	var gej GroupElementJacobian
-	gej.x.n = a.x.n
-	gej.y.n = a.y.n
-	gej.z.n = a.z.n
-	gej.infinity = a.infinity != 0
+	secp256k1_gej_load(&gej, a)
 
 	var ge GroupElementAffine
 	ge.setGEJ(&gej)
@@ -597,10 +658,7 @@ func secp256k1_ge_set_gej_var(r *secp256k1_ge, a *secp256k1_gej) {
 	}
 
 	var gej GroupElementJacobian
-	gej.x.n = a.x.n
-	gej.y.n = a.y.n
-	gej.z.n = a.z.n
-	gej.infinity = false
+	secp256k1_gej_load(&gej, a)
 
 	var ge GroupElementAffine
 	ge.setGEJ(&gej)
@@ -613,36 +671,31 @@ func secp256k1_ge_set_gej_var(r *secp256k1_ge, a *secp256k1_gej) {
 // secp256k1_gej_double_var doubles Jacobian point
 func secp256k1_gej_double_var(r *secp256k1_gej, a *secp256k1_gej, rzr *secp256k1_fe) {
 	var geja, gejr GroupElementJacobian
-	geja.x.n = a.x.n
-	geja.y.n = a.y.n
-	geja.z.n = a.z.n
-	geja.infinity = a.infinity != 0
+	secp256k1_gej_load(&geja, a)
 
 	gejr.double(&geja)
 
-	r.x.n = gejr.x.n
-	r.y.n = gejr.y.n
-	r.z.n = gejr.z.n
+	// The doubled Y has magnitude 3; reduce so the stored limbs stay within
+	// magnitude-1 bounds for callers that use them directly
+	secp256k1_fe_store(&r.x, &gejr.x)
+	secp256k1_fe_store(&r.y, &gejr.y)
+	secp256k1_fe_store(&r.z, &gejr.z)
 	r.infinity = boolToInt(gejr.infinity)
 
 	if rzr != nil {
-		// rzr = 2*a->y (from double logic)
+		// double computes Z3 = Y1*Z1, so the z-ratio is a->y
 		rzr.n = a.y.n
-		secp256k1_fe_add(rzr, &a.y)
 	}
 }
 
 // secp256k1_gej_add_ge_var adds affine point to Jacobian point
 func secp256k1_gej_add_ge_var(r *secp256k1_gej, a *secp256k1_gej, b *secp256k1_ge, rzr *secp256k1_fe) {
 	var geja GroupElementJacobian
-	geja.x.n = a.x.n
-	geja.y.n = a.y.n
-	geja.z.n = a.z.n
-	geja.infinity = a.infinity != 0
+	secp256k1_gej_load(&geja, a)
 
 	var geb GroupElementAffine
-	geb.x.n = b.x.n
-	geb.y.n = b.y.n
+	secp256k1_fe_load(&geb.x, &b.x)
+	secp256k1_fe_load(&geb.y, &b.y)
 	geb.infinity = b.infinity != 0
 
 	var fezr *FieldElement
@@ -655,6 +708,15 @@ func secp256k1_gej_add_ge_var(r *secp256k1_gej, a *secp256k1_gej, b *secp256k1_g
 	var gejr GroupElementJacobian
 	gejr.addGEWithZR(&geja, &geb, fezr)
 
+	// The result is stored without its magnitude and callers feed it
+	// straight into mul/sqr, so it must already be within bounds
+	secp256k1_fe_verify_mul_magnitude(gejr.x.magnitude)
+	secp256k1_fe_verify_mul_magnitude(gejr.y.magnitude)
+	secp256k1_fe_verify_mul_magnitude(gejr.z.magnitude)
+	if fezr != nil {
+		secp256k1_fe_verify_mul_magnitude(fezr.magnitude)
+	}
+
 	r.x.n = gejr.x.n
 	r.y.n = gejr.y.n
 	r.z.n = gejr.z.n
@@ -688,6 +750,29 @@ func getChallengeHashContext() hash.Hash {
 	return challengeHashContext
 }
 
+// SHA256 state after absorbing SHA256(tag) || SHA256(tag) for the BIP-340
+// challenge tag. It is only ever cloned, never written after setup.
+var (
+	challengeMidstate     hash.Hash
+	challengeMidstateOnce sync.Once
+)
+
+// newChallengeHash returns a private copy of the challenge tag midstate, so
+// concurrent verifications never share hash state
+func newChallengeHash() hash.Hash {
+	challengeMidstateOnce.Do(func() {
+		tagHash := getTaggedHashPrefix(bip340ChallengeTag)
+		challengeMidstate = sha256.New()
+		challengeMidstate.Write(tagHash[:])
+		challengeMidstate.Write(tagHash[:])
+	})
+	h, err := challengeMidstate.(hash.Cloner).Clone()
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
 // ============================================================================
 // EC MULTIPLICATION OPERATIONS
 // ============================================================================
@@ -724,10 +809,7 @@ func secp256k1_ecmult(r *secp256k1_gej, a *secp256k1_gej, na *secp256k1_scalar,
 	// r = na * a + ng * G
 	// Convert input to Go types
 	var geja GroupElementJacobian
-	geja.x.n = a.x.n
-	geja.y.n = a.y.n
-	geja.z.n = a.z.n
-	geja.infinity = a.infinity != 0
+	secp256k1_gej_load(&geja, a)
 
 	var sna, sng Scalar
 	sna.d = na.d
@@ -796,9 +878,12 @@ type secp256k1_context struct {
 	declassify     int
 }
 
-// secp256k1_declassify declassifies data (no-op in non-VERIFY builds)
+// secp256k1_declassify declassifies data if ctx.declassify is set (no-op
+// unless built with the p256k1_ctime tag)
 func secp256k1_declassify(ctx *secp256k1_context, p unsafe.Pointer, len uintptr) {
-	// No-op
+	if ctx.declassify != 0 {
+		ctimeDeclassify(p, len)
+	}
 }
 
 // secp256k1_pubkey represents a public key
@@ -854,7 +939,7 @@ func secp256k1_pubkey_save(pubkey *secp256k1_pubkey, ge *secp256k1_ge) {
 func secp256k1_xonly_pubkey_load(ctx *secp256k1_context, ge *secp256k1_ge, pubkey *secp256k1_xonly_pubkey) bool {
 	// Reconstruct point from X coordinate (x-only pubkey only has X)
 	var x FieldElement
-	if err := x.setB32(pubkey.data[:]); err != nil {
+	if !x.setB32Limit(pubkey.data[:]) {
 		return false
 	}
 
@@ -920,28 +1005,32 @@ func secp256k1_schnorrsig_sha256_tagged(sha *secp256k1_sha256) {
 
 // secp256k1_schnorrsig_challenge computes challenge hash
 func secp256k1_schnorrsig_challenge(e *secp256k1_scalar, r32 []byte, msg []byte, msglen int, pubkey32 []byte) {
-	// Zero-allocation challenge computation
-	var challengeHash [32]byte
-	var tagHash [32]byte
-
-	// Use pre-allocated hash context for both hashes to avoid allocations
+	// Use pre-allocated hash context to avoid allocations
 	h := getChallengeHashContext()
+	secp256k1_schnorrsig_challenge_init(h, r32, pubkey32)
+	h.Write(msg[:msglen]) // msg
+	secp256k1_schnorrsig_challenge_finalize(e, h)
+}
 
+// secp256k1_schnorrsig_challenge_init resets h and writes the fixed challenge
+// prefix SHA256(tag) || SHA256(tag) || r32 || pubkey32. The message is then
+// written to h by the caller, either at once or incrementally.
+func secp256k1_schnorrsig_challenge_init(h hash.Hash, r32 []byte, pubkey32 []byte) {
 	// First hash: SHA256(tag) - use Sum256 directly to avoid hash context
-	tagHash = sha256.Sum256(bip340ChallengeTag)
+	tagHash := sha256.Sum256(bip340ChallengeTag)
 
-	// Second hash: SHA256(SHA256(tag) || SHA256(tag) || r32 || pubkey32 || msg)
 	h.Reset()
 	h.Write(tagHash[:])    // SHA256(tag)
 	h.Write(tagHash[:])    // SHA256(tag) again
 	h.Write(r32[:32])      // r32
 	h.Write(pubkey32[:32]) // pubkey32
-	h.Write(msg[:msglen])  // msg
+}
 
-	// Sum into a temporary buffer, then copy
-	var temp [32]byte
-	h.Sum(temp[:0])
-	copy(challengeHash[:], temp[:])
+// secp256k1_schnorrsig_challenge_finalize sets e to the challenge hash in h
+// reduced mod n
+func secp256k1_schnorrsig_challenge_finalize(e *secp256k1_scalar, h hash.Hash) {
+	var challengeHash [32]byte
+	h.Sum(challengeHash[:0])
 
 	// Convert hash to scalar directly - avoid intermediate Scalar by setting directly
 	e.d[0] = uint64(challengeHash[31]) | uint64(challengeHash[30])<<8 | uint64(challengeHash[29])<<16 | uint64(challengeHash[28])<<24 |
@@ -1115,29 +1204,16 @@ func feGetB32(b []byte, a []uint64) {
 	fieldGetB32(b, &tempFE)
 }
 
-// scalarNegate negates scalar
+// scalarNegate negates a scalar in place: r = n - r, or 0 if r is 0
 func scalarNegate(r []uint64) {
 	if len(r) < 4 {
 		return
 	}
 
-	// Compute -r mod n: if r == 0 then 0 else n - r
-	if r[0] != 0 || r[1] != 0 || r[2] != 0 || r[3] != 0 {
-		r[0] = (^r[0]) + 1
-		r[1] = ^r[1]
-		r[2] = ^r[2]
-		r[3] = ^r[3]
-
-		// Add n if we wrapped around
-		var tempS Scalar
-		copy(tempS.d[:], r)
-		if tempS.checkOverflow() {
-			r[0] += scalarNC0
-			r[1] += scalarNC1
-			r[2] += scalarNC2
-			r[3] += 0
-		}
-	}
+	var tempS Scalar
+	copy(tempS.d[:], r)
+	tempS.negate(&tempS)
+	copy(r, tempS.d[:])
 }
 
 // gejSetGe sets jacobian coordinates from affine
@@ -1236,14 +1312,20 @@ func ecmult(rjx, rjy, rjz []uint64, rjInf *int, ajx, ajy, ajz []uint64, ajInf in
 
 // secp256k1_schnorrsig_verify verifies a Schnorr signature
 func secp256k1_schnorrsig_verify(ctx *secp256k1_context, sig64 []byte, msg []byte, msglen int, pubkey *secp256k1_xonly_pubkey) int {
-	var s secp256k1_scalar
-	var e secp256k1_scalar
-	var rj secp256k1_gej
+	if msg == nil && msglen != 0 {
+		return 0
+	}
+
+	return secp256k1_schnorrsig_verify_stream(ctx, sig64, msg[:msglen], nil, pubkey)
+}
+
+// secp256k1_schnorrsig_verify_stream verifies a Schnorr signature whose
+// message is msg followed by everything read from stream, if it is not nil,
+// written into the challenge hash after the fixed r32 || pubkey32 prefix.
+// This lets the message be streamed rather than held in memory. Returns 0
+// if reading stream fails.
+func secp256k1_schnorrsig_verify_stream(ctx *secp256k1_context, sig64 []byte, msg []byte, stream io.Reader, pubkey *secp256k1_xonly_pubkey) int {
 	var pk secp256k1_ge
-	var pkj secp256k1_gej
-	var rx secp256k1_fe
-	var r secp256k1_ge
-	var overflow int
 
 	if ctx == nil {
 		return 0
@@ -1251,9 +1333,6 @@ func secp256k1_schnorrsig_verify(ctx *secp256k1_context, sig64 []byte, msg []byt
 	if sig64 == nil {
 		return 0
 	}
-	if msg == nil && msglen != 0 {
-		return 0
-	}
 	if pubkey == nil {
 		return 0
 	}
@@ -1263,16 +1342,40 @@ func secp256k1_schnorrsig_verify(ctx *secp256k1_context, sig64 []byte, msg []byt
 		return 0
 	}
 
-	if !secp256k1_fe_set_b32_limit(&rx, sig64[:32]) {
+	if !secp256k1_xonly_pubkey_load(ctx, &pk, pubkey) {
 		return 0
 	}
 
-	secp256k1_scalar_set_b32(&s, sig64[32:], &overflow)
-	if overflow != 0 {
+	return secp256k1_schnorrsig_verify_loaded(ctx, sig64, msg, stream, &pk)
+}
+
+// secp256k1_schnorrsig_verify_loaded verifies a Schnorr signature against a
+// public key that has already been lifted to its even-Y point, so callers
+// verifying many signatures under one key only pay for the lift once. The
+// challenge is hashed in a per-call copy of the tag midstate, so concurrent
+// calls are safe.
+func secp256k1_schnorrsig_verify_loaded(ctx *secp256k1_context, sig64 []byte, msg []byte, stream io.Reader, pk *secp256k1_ge) int {
+	var s secp256k1_scalar
+	var e secp256k1_scalar
+	var rj secp256k1_gej
+	var pkj secp256k1_gej
+	var rx secp256k1_fe
+	var r secp256k1_ge
+	var overflow int
+
+	if ctx == nil || pk == nil {
+		return 0
+	}
+	if len(sig64) < 64 {
 		return 0
 	}
 
-	if !secp256k1_xonly_pubkey_load(ctx, &pk, pubkey) {
+	if !secp256k1_fe_set_b32_limit(&rx, sig64[:32]) {
+		return 0
+	}
+
+	secp256k1_scalar_set_b32(&s, sig64[32:], &overflow)
+	if overflow != 0 {
 		return 0
 	}
 
@@ -1280,11 +1383,20 @@ func secp256k1_schnorrsig_verify(ctx *secp256k1_context, sig64 []byte, msg []byt
 	secp256k1_fe_normalize_var(&pk.x)
 	var pkXBytes [32]byte
 	secp256k1_fe_get_b32(pkXBytes[:], &pk.x)
-	secp256k1_schnorrsig_challenge(&e, sig64[:32], msg, msglen, pkXBytes[:])
+	h := newChallengeHash()
+	h.Write(sig64[:32])
+	h.Write(pkXBytes[:])
+	h.Write(msg)
+	if stream != nil {
+		if _, err := io.Copy(h, stream); err != nil {
+			return 0
+		}
+	}
+	secp256k1_schnorrsig_challenge_finalize(&e, h)
 
 	// Compute rj = s*G + (-e)*pkj
 	secp256k1_scalar_negate(&e, &e)
-	secp256k1_gej_set_ge(&pkj, &pk)
+	secp256k1_gej_set_ge(&pkj, pk)
 	secp256k1_ecmult(&rj, &pkj, &e, &s)
 
 	secp256k1_ge_set_gej_var(&r, &rj)
@@ -1294,17 +1406,16 @@ func secp256k1_schnorrsig_verify(ctx *secp256k1_context, sig64 []byte, msg []byt
 
 	// Optimize: normalize r.y only once and check if odd
 	secp256k1_fe_normalize_var(&r.y)
-	if secp256k1_fe_is_odd(&r.y) {
+	if secp256k1_fe_is_odd_normalized(&r.y) {
 		return 0
 	}
 
-	// Optimize: normalize r.x and rx only once before comparison
+	// Optimize: normalize r.x only once before comparison (rx is already
+	// normalized by secp256k1_fe_set_b32_limit)
 	secp256k1_fe_normalize_var(&r.x)
-	secp256k1_fe_normalize_var(&rx)
 
 	// Direct comparison of normalized field elements to avoid allocations
-	if rx.n[0] != r.x.n[0] || rx.n[1] != r.x.n[1] || rx.n[2] != r.x.n[2] ||
-	   rx.n[3] != r.x.n[3] || rx.n[4] != r.x.n[4] {
+	if !secp256k1_fe_equal_normalized(&rx, &r.x) {
 		return 0
 	}
 
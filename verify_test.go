@@ -1,6 +1,7 @@
 package p256k1
 
 import (
+	"encoding/hex"
 	"testing"
 )
 
@@ -368,3 +369,356 @@ func TestSecp256k1SchnorrsigVerifyComparison(t *testing.T) {
 		}
 	})
 }
+
+func TestSecp256k1FeSetB32(t *testing.T) {
+	fromHex := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatalf("failed to decode hex: %v", err)
+		}
+		return b
+	}
+
+	pMinus1 := fromHex("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e")
+	p := fromHex("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f")
+	pPlus1 := fromHex("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc30")
+	max := fromHex("ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+
+	t.Run("Limit", func(t *testing.T) {
+		var r secp256k1_fe
+		if !secp256k1_fe_set_b32_limit(&r, pMinus1) {
+			t.Error("p-1 should be accepted")
+		}
+		var out [32]byte
+		secp256k1_fe_get_b32(out[:], &r)
+		for i := range out {
+			if out[i] != pMinus1[i] {
+				t.Fatal("p-1 did not round-trip without normalization")
+			}
+		}
+
+		for _, b := range [][]byte{p, pPlus1, max} {
+			if secp256k1_fe_set_b32_limit(&r, b) {
+				t.Errorf("value %x >= p should be rejected", b)
+			}
+		}
+	})
+
+	t.Run("Mod", func(t *testing.T) {
+		var r secp256k1_fe
+		secp256k1_fe_set_b32_mod(&r, p)
+		if !secp256k1_fe_is_zero(&r) {
+			t.Error("p mod p should be zero without further normalization")
+		}
+
+		secp256k1_fe_set_b32_mod(&r, pPlus1)
+		var one secp256k1_fe
+		secp256k1_fe_set_int(&one, 1)
+		if r.n != one.n {
+			t.Error("(p+1) mod p should be one without further normalization")
+		}
+
+		secp256k1_fe_set_b32_mod(&r, pMinus1)
+		var lim secp256k1_fe
+		secp256k1_fe_set_b32_limit(&lim, pMinus1)
+		if r.n != lim.n {
+			t.Error("mod and limit variants should agree below p")
+		}
+	})
+}
+
+func TestSecp256k1FeEqual(t *testing.T) {
+	var a, b secp256k1_fe
+	secp256k1_fe_set_int(&a, 5)
+	secp256k1_fe_set_int(&b, 5)
+	if !secp256k1_fe_equal_normalized(&a, &b) || !secp256k1_fe_equal(&a, &b) {
+		t.Error("equal normalized values should compare equal")
+	}
+
+	secp256k1_fe_set_int(&b, 6)
+	if secp256k1_fe_equal_normalized(&a, &b) || secp256k1_fe_equal(&a, &b) {
+		t.Error("different values should compare unequal")
+	}
+
+	// p + 5 is the same field element as 5 but not normalized
+	p5, _ := hex.DecodeString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc34")
+	var fe FieldElement
+	fe.setB32(p5)
+	var c secp256k1_fe
+	c.n = fe.n
+	if !secp256k1_fe_equal(&a, &c) {
+		t.Error("secp256k1_fe_equal should normalize its inputs")
+	}
+	if secp256k1_fe_equal_normalized(&a, &c) {
+		t.Error("secp256k1_fe_equal_normalized should not normalize its inputs")
+	}
+	secp256k1_fe_normalize_var(&c)
+	if !secp256k1_fe_equal_normalized(&a, &c) {
+		t.Error("values should compare equal after normalization")
+	}
+}
+
+func TestSecp256k1FeIsOdd(t *testing.T) {
+	// The limbs of p: the low limb is odd but the value is 0
+	p := secp256k1_fe{n: [5]uint64{fieldModulusLimb0, fieldModulusLimb1, fieldModulusLimb2, fieldModulusLimb3, fieldModulusLimb4}}
+	if secp256k1_fe_is_odd(&p) {
+		t.Error("p is 0, which is even")
+	}
+	if !secp256k1_fe_is_odd_normalized(&p) {
+		t.Error("secp256k1_fe_is_odd_normalized should not normalize its input")
+	}
+	if p.n[0] != fieldModulusLimb0 {
+		t.Error("secp256k1_fe_is_odd modified its input")
+	}
+
+	for v := 0; v < 8; v++ {
+		var a secp256k1_fe
+		secp256k1_fe_set_int(&a, v)
+		odd := v&1 == 1
+		if secp256k1_fe_is_odd(&a) != odd || secp256k1_fe_is_odd_normalized(&a) != odd {
+			t.Errorf("parity of %d is wrong", v)
+		}
+		// p + v flips the parity of the low limb but not of the value
+		feAddModulus(&a, 1)
+		if secp256k1_fe_is_odd(&a) != odd {
+			t.Errorf("parity of p + %d is wrong", v)
+		}
+	}
+}
+
+// feAddModulus adds k copies of p to the limbs of a, leaving the value
+// unchanged but raising its magnitude.
+func feAddModulus(a *secp256k1_fe, k uint64) {
+	a.n[0] += k * fieldModulusLimb0
+	a.n[1] += k * fieldModulusLimb1
+	a.n[2] += k * fieldModulusLimb2
+	a.n[3] += k * fieldModulusLimb3
+	a.n[4] += k * fieldModulusLimb4
+}
+
+func TestSecp256k1FeNegateHighMagnitude(t *testing.T) {
+	pm1, _ := hex.DecodeString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e")
+	var a secp256k1_fe
+	secp256k1_fe_set_b32_mod(&a, pm1)
+	for i := 0; i < 7; i++ {
+		var c secp256k1_fe
+		secp256k1_fe_set_b32_mod(&c, pm1)
+		secp256k1_fe_add(&a, &c)
+	}
+
+	var neg secp256k1_fe
+	secp256k1_fe_negate(&neg, &a, 1)
+	secp256k1_fe_add(&neg, &a)
+	if !secp256k1_fe_normalizes_to_zero(&neg) {
+		t.Error("-a + a should be zero for a high-magnitude input")
+	}
+}
+
+func TestSecp256k1GejUnnormalizedInputs(t *testing.T) {
+	var g2 GroupElementJacobian
+	g2.setGE(&Generator)
+	g2.double(&g2)
+
+	var want GroupElementJacobian
+	want.addGE(&g2, &Generator)
+	var wantAff GroupElementAffine
+	wantAff.setGEJ(&want)
+
+	// Same point as g2 but with every coordinate at magnitude 5
+	var a secp256k1_gej
+	a.x.n, a.y.n, a.z.n = g2.x.n, g2.y.n, g2.z.n
+	feAddModulus(&a.x, 4)
+	feAddModulus(&a.y, 4)
+	feAddModulus(&a.z, 4)
+
+	var b secp256k1_ge
+	b.x.n, b.y.n = Generator.x.n, Generator.y.n
+
+	var sum secp256k1_gej
+	var zr secp256k1_fe
+	secp256k1_gej_add_ge_var(&sum, &a, &b, &zr)
+	var got secp256k1_ge
+	secp256k1_ge_set_gej_var(&got, &sum)
+	secp256k1_fe_normalize_var(&got.x)
+	secp256k1_fe_normalize_var(&got.y)
+	wantAff.x.normalize()
+	wantAff.y.normalize()
+	if got.x.n != wantAff.x.n || got.y.n != wantAff.y.n {
+		t.Error("gej_add_ge_var gave the wrong point for unnormalized input")
+	}
+
+	// The z-ratio from doubling must satisfy r.z = a.z * rzr
+	var dbl secp256k1_gej
+	secp256k1_gej_double_var(&dbl, &a, &zr)
+	var expZ secp256k1_fe
+	var az, fzr, prod FieldElement
+	secp256k1_fe_load(&az, &a.z)
+	secp256k1_fe_load(&fzr, &zr)
+	prod.mul(&az, &fzr)
+	expZ.n = prod.n
+	if !secp256k1_fe_equal(&expZ, &dbl.z) {
+		t.Error("gej_double_var returned the wrong z-ratio")
+	}
+}
+
+func TestSecp256k1GejDoubleRepeated(t *testing.T) {
+	var p secp256k1_gej
+	p.x.n, p.y.n, p.z.n = Generator.x.n, Generator.y.n, [5]uint64{1}
+
+	// Magnitude-1 limb bounds, as in the C VERIFY checks
+	const limbMax, topMax = 2 * (1<<52 - 1), 2 * (1<<48 - 1)
+	for i := 0; i < 20; i++ {
+		secp256k1_gej_double_var(&p, &p, nil)
+		for _, fe := range []*secp256k1_fe{&p.x, &p.y, &p.z} {
+			if fe.n[0] > limbMax || fe.n[1] > limbMax || fe.n[2] > limbMax ||
+				fe.n[3] > limbMax || fe.n[4] > topMax {
+				t.Fatalf("doubling %d left limbs out of bounds: %x", i+1, fe.n)
+			}
+		}
+	}
+
+	var got secp256k1_ge
+	secp256k1_ge_set_gej_var(&got, &p)
+	secp256k1_fe_normalize_var(&got.x)
+	secp256k1_fe_normalize_var(&got.y)
+
+	var k Scalar
+	k.setInt(1)
+	for i := 0; i < 20; i++ {
+		k.add(&k, &k)
+	}
+	var wantJ GroupElementJacobian
+	EcmultGen(&wantJ, &k)
+	var want GroupElementAffine
+	want.setGEJ(&wantJ)
+	want.x.normalize()
+	want.y.normalize()
+	if got.x.n != want.x.n || got.y.n != want.y.n {
+		t.Error("2^20 doublings of G differ from EcmultGen(2^20)")
+	}
+}
+
+// feLimbMagnitude returns the smallest magnitude consistent with the limbs
+// of a, i.e. the least m with every limb at most 2*m times its maximum
+func feLimbMagnitude(a *secp256k1_fe) int {
+	m := 0
+	for i, limb := range a.n {
+		max := uint64(0xFFFFFFFFFFFFF)
+		if i == 4 {
+			max = 0x0FFFFFFFFFFFF
+		}
+		if lm := int((limb + 2*max - 1) / (2 * max)); lm > m {
+			m = lm
+		}
+	}
+	return m
+}
+
+func TestSecp256k1FeAddChecked(t *testing.T) {
+	var a, one secp256k1_fe
+	secp256k1_fe_set_int(&a, 3)
+	secp256k1_fe_set_int(&one, 1)
+	m := 1
+	for i := 0; i < 5; i++ {
+		m = secp256k1_fe_add_checked(&a, &one, m, 1)
+	}
+	if m != 6 {
+		t.Fatalf("magnitude %d, want 6", m)
+	}
+	var want secp256k1_fe
+	secp256k1_fe_set_int(&want, 8)
+	if !secp256k1_fe_equal(&a, &want) {
+		t.Error("checked add gave the wrong sum")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on magnitude overflow")
+		}
+	}()
+	secp256k1_fe_add_checked(&a, &one, secp256k1_fe_max_magnitude, 1)
+}
+
+// TestSecp256k1GejAddGeVarMagnitude runs the Schnorr verification equation
+// R = s*G - e*P through the secp256k1_gej wrappers one double and add at a
+// time, checking that every stored coordinate stays within the magnitude
+// fe_mul and fe_sqr accept
+func TestSecp256k1GejAddGeVarMagnitude(t *testing.T) {
+	checkMag := func(what string, a *secp256k1_gej) {
+		t.Helper()
+		for _, fe := range []*secp256k1_fe{&a.x, &a.y, &a.z} {
+			if m := feLimbMagnitude(fe); m > secp256k1_fe_mul_max_magnitude {
+				t.Fatalf("%s: coordinate magnitude %d", what, m)
+			}
+		}
+	}
+
+	for iter := 0; iter < 8; iter++ {
+		kp, err := KeyPairGenerate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		xonly, err := kp.XOnlyPubkey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := make([]byte, 32)
+		msg[0] = byte(iter)
+		var sig [64]byte
+		if err := SchnorrSign(sig[:], msg, kp, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		var secpXonly secp256k1_xonly_pubkey
+		copy(secpXonly.data[:], xonly.data[:])
+		if secp256k1_schnorrsig_verify(&secp256k1_context{}, sig[:], msg, len(msg), &secpXonly) != 1 {
+			t.Fatal("signature did not verify")
+		}
+
+		var pk secp256k1_ge
+		if !secp256k1_xonly_pubkey_load(nil, &pk, &secpXonly) {
+			t.Fatal("failed to load pubkey")
+		}
+		var s, e secp256k1_scalar
+		var overflow int
+		secp256k1_scalar_set_b32(&s, sig[32:], &overflow)
+		secp256k1_schnorrsig_challenge(&e, sig[:32], msg, len(msg), xonly.data[:])
+		secp256k1_scalar_negate(&e, &e)
+		var eb [32]byte
+		secp256k1_scalar_get_b32(eb[:], &e)
+
+		// -e*P by double-and-add
+		var acc secp256k1_gej
+		secp256k1_gej_set_infinity(&acc)
+		for i := 0; i < 256; i++ {
+			secp256k1_gej_double_var(&acc, &acc, nil)
+			checkMag("double", &acc)
+			if eb[i/8]>>(7-i%8)&1 == 1 {
+				var zr secp256k1_fe
+				secp256k1_gej_add_ge_var(&acc, &acc, &pk, &zr)
+				checkMag("add", &acc)
+				if m := feLimbMagnitude(&zr); m > secp256k1_fe_mul_max_magnitude {
+					t.Fatalf("z-ratio magnitude %d", m)
+				}
+			}
+		}
+
+		// + s*G
+		var sgj secp256k1_gej
+		var sg secp256k1_ge
+		secp256k1_ecmult_gen(nil, &sgj, &s)
+		secp256k1_ge_set_gej_var(&sg, &sgj)
+		secp256k1_gej_add_ge_var(&acc, &acc, &sg, nil)
+		checkMag("add s*G", &acc)
+
+		var r secp256k1_ge
+		secp256k1_ge_set_gej_var(&r, &acc)
+		secp256k1_fe_normalize_var(&r.x)
+		secp256k1_fe_normalize_var(&r.y)
+		var rx [32]byte
+		secp256k1_fe_get_b32(rx[:], &r.x)
+		if secp256k1_ge_is_infinity(&r) || secp256k1_fe_is_odd(&r.y) || string(rx[:]) != string(sig[:32]) {
+			t.Fatal("step-by-step verification did not reproduce R")
+		}
+	}
+}
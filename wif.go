@@ -0,0 +1,195 @@
+package p256k1
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"unsafe"
+)
+
+// Network prefixes for Wallet Import Format secret keys. Regtest uses the
+// testnet prefix.
+const (
+	WIFMainnet = 0x80
+	WIFTestnet = 0xef
+)
+
+// base58Alphabet is the Bitcoin Base58 alphabet
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// SeckeyToWIF encodes a secret key in Wallet Import Format: Base58Check of
+// netPrefix || seckey, followed by 0x01 if the matching public key is to be
+// used in compressed form. It returns "" if seckey is not a valid secret
+// key.
+func SeckeyToWIF(seckey []byte, compressed bool, netPrefix byte) string {
+	if len(seckey) != 32 || !ECSeckeyVerify(seckey) {
+		return ""
+	}
+	var payload [34]byte
+	payload[0] = netPrefix
+	copy(payload[1:33], seckey)
+	n := 33
+	if compressed {
+		payload[33] = 0x01
+		n = 34
+	}
+	s := base58CheckEncode(payload[:n])
+	memclear(unsafe.Pointer(&payload[0]), uintptr(len(payload)))
+	return s
+}
+
+// SeckeyFromWIF decodes a secret key in Wallet Import Format, returning the
+// key and whether it is marked for use with a compressed public key. The
+// prefix must be WIFMainnet or WIFTestnet; use SeckeyFromWIFNetwork to
+// require a particular one.
+func SeckeyFromWIF(wif string) ([]byte, bool, error) {
+	payload, err := base58CheckDecode(wif)
+	if err != nil {
+		return nil, false, err
+	}
+	defer memclear(unsafe.Pointer(&payload[0]), uintptr(len(payload)))
+
+	if payload[0] != WIFMainnet && payload[0] != WIFTestnet {
+		return nil, false, fmt.Errorf("%w: WIF prefix %#x is not for a Bitcoin network", ErrParse, payload[0])
+	}
+	return seckeyFromWIFPayload(payload)
+}
+
+// SeckeyFromWIFNetwork is SeckeyFromWIF for a key with network prefix
+// netPrefix, such as WIFMainnet. A key for any other network is an error.
+func SeckeyFromWIFNetwork(wif string, netPrefix byte) ([]byte, bool, error) {
+	payload, err := base58CheckDecode(wif)
+	if err != nil {
+		return nil, false, err
+	}
+	defer memclear(unsafe.Pointer(&payload[0]), uintptr(len(payload)))
+
+	if payload[0] != netPrefix {
+		return nil, false, fmt.Errorf("%w: WIF prefix %#x, want %#x", ErrParse, payload[0], netPrefix)
+	}
+	return seckeyFromWIFPayload(payload)
+}
+
+// seckeyFromWIFPayload parses a decoded WIF payload after its prefix has
+// been checked
+func seckeyFromWIFPayload(payload []byte) ([]byte, bool, error) {
+	var compressed bool
+	switch {
+	case len(payload) == 33:
+	case len(payload) == 34 && payload[33] == 0x01:
+		compressed = true
+	default:
+		return nil, false, fmt.Errorf("%w: invalid WIF payload", ErrParse)
+	}
+
+	seckey := make([]byte, 32)
+	copy(seckey, payload[1:33])
+	if !ECSeckeyVerify(seckey) {
+		return nil, false, ErrInvalidSeckey
+	}
+	return seckey, compressed, nil
+}
+
+// base58CheckEncode appends the 4-byte double-SHA256 checksum to payload and
+// encodes the result in Base58
+func base58CheckEncode(payload []byte) string {
+	buf := make([]byte, len(payload), len(payload)+4)
+	copy(buf, payload)
+	sum := doubleSHA256(payload)
+	buf = append(buf, sum[:4]...)
+	s := base58Encode(buf)
+	memclear(unsafe.Pointer(&buf[0]), uintptr(len(buf)))
+	return s
+}
+
+// base58CheckDecode decodes a Base58 string and verifies and strips its
+// checksum
+func base58CheckDecode(s string) ([]byte, error) {
+	buf, err := base58Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) < 5 {
+		return nil, fmt.Errorf("%w: Base58Check string too short", ErrParse)
+	}
+	payload := buf[:len(buf)-4]
+	sum := doubleSHA256(payload)
+	if subtle.ConstantTimeCompare(sum[:4], buf[len(buf)-4:]) != 1 {
+		memclear(unsafe.Pointer(&buf[0]), uintptr(len(buf)))
+		return nil, fmt.Errorf("%w: Base58Check checksum mismatch", ErrParse)
+	}
+	return payload, nil
+}
+
+// base58Encode encodes b in Base58, with one leading '1' per leading zero
+// byte
+func base58Encode(b []byte) string {
+	zeros := 0
+	for zeros < len(b) && b[zeros] == 0 {
+		zeros++
+	}
+	// log(256)/log(58) < 1.37
+	digits := make([]byte, 0, len(b)*137/100+1)
+	for _, c := range b[zeros:] {
+		carry := int(c)
+		for i := range digits {
+			carry += int(digits[i]) << 8
+			digits[i] = byte(carry % 58)
+			carry /= 58
+		}
+		for carry > 0 {
+			digits = append(digits, byte(carry%58))
+			carry /= 58
+		}
+	}
+
+	out := make([]byte, zeros+len(digits))
+	for i := 0; i < zeros; i++ {
+		out[i] = '1'
+	}
+	for i, d := range digits {
+		out[len(out)-1-i] = base58Alphabet[d]
+	}
+	clear(digits)
+	return string(out)
+}
+
+// base58Decode decodes a Base58 string
+func base58Decode(s string) ([]byte, error) {
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty Base58 string", ErrParse)
+	}
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+	// Little-endian base-256 digits of the value
+	var bytes []byte
+	for i := zeros; i < len(s); i++ {
+		carry := -1
+		for j := 0; j < len(base58Alphabet); j++ {
+			if base58Alphabet[j] == s[i] {
+				carry = j
+				break
+			}
+		}
+		if carry < 0 {
+			return nil, fmt.Errorf("%w: invalid Base58 character %q", ErrParse, s[i])
+		}
+		for j := range bytes {
+			carry += int(bytes[j]) * 58
+			bytes[j] = byte(carry)
+			carry >>= 8
+		}
+		for carry > 0 {
+			bytes = append(bytes, byte(carry))
+			carry >>= 8
+		}
+	}
+
+	out := make([]byte, zeros+len(bytes))
+	for i, c := range bytes {
+		out[len(out)-1-i] = c
+	}
+	clear(bytes)
+	return out, nil
+}
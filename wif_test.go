@@ -0,0 +1,110 @@
+package p256k1
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestSeckeyWIF(t *testing.T) {
+	seckey, _ := hex.DecodeString("0c28fca386c7a227600b2fe50b7cae11ec86d3bf1fbe471be89827e19d72aa1d")
+	vectors := []struct {
+		wif        string
+		compressed bool
+		prefix     byte
+	}{
+		{"5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ", false, WIFMainnet},
+		{"KwdMAjGmerYanjeui5SHS7JkmpZvVipYvB2LJGU1ZxJwYvP98617", true, WIFMainnet},
+		{"91gGn1HgSap6CbU12F6z3pJri26xzp7Ay1VW6NHCoEayNXwRpu2", false, WIFTestnet},
+		{"cMzLdeGd5vEqxB8B6VFQoRopQ3sLAAvEzDAoQgvX54xwofSWj1fx", true, WIFTestnet},
+	}
+	for _, v := range vectors {
+		if got := SeckeyToWIF(seckey, v.compressed, v.prefix); got != v.wif {
+			t.Errorf("SeckeyToWIF(compressed=%v, %#x) = %s, want %s", v.compressed, v.prefix, got, v.wif)
+		}
+		got, compressed, err := SeckeyFromWIF(v.wif)
+		if err != nil {
+			t.Fatalf("SeckeyFromWIF(%s): %v", v.wif, err)
+		}
+		if !bytes.Equal(got, seckey) || compressed != v.compressed {
+			t.Errorf("SeckeyFromWIF(%s) = %x, %v", v.wif, got, compressed)
+		}
+
+		// Only the key's own network is accepted when one is required
+		for _, prefix := range []byte{WIFMainnet, WIFTestnet} {
+			got, _, err := SeckeyFromWIFNetwork(v.wif, prefix)
+			if prefix == v.prefix && (err != nil || !bytes.Equal(got, seckey)) {
+				t.Errorf("SeckeyFromWIFNetwork(%s, %#x) = %x, %v", v.wif, prefix, got, err)
+			}
+			if prefix != v.prefix && !errors.Is(err, ErrParse) {
+				t.Errorf("SeckeyFromWIFNetwork(%s, %#x): got %v, want ErrParse", v.wif, prefix, err)
+			}
+		}
+	}
+
+	// Round trip random keys
+	for i := 0; i < 32; i++ {
+		sk, _, err := ECKeyPairGenerate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		wif := SeckeyToWIF(sk, i&1 == 1, WIFMainnet)
+		got, compressed, err := SeckeyFromWIF(wif)
+		if err != nil || !bytes.Equal(got, sk) || compressed != (i&1 == 1) {
+			t.Fatalf("round trip of %x failed: %v", sk, err)
+		}
+	}
+
+	if SeckeyToWIF(make([]byte, 32), true, WIFMainnet) != "" || SeckeyToWIF(seckey[:31], true, WIFMainnet) != "" {
+		t.Error("SeckeyToWIF should reject invalid keys")
+	}
+	order, _ := hex.DecodeString(testGroupOrderHex)
+	if _, _, err := SeckeyFromWIF(base58CheckEncode(append([]byte{WIFMainnet}, order...))); !errors.Is(err, ErrInvalidSeckey) {
+		t.Errorf("key equal to the order: got %v, want ErrInvalidSeckey", err)
+	}
+
+	invalid := []string{
+		"",
+		"5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTK",               // bad checksum
+		"KwdMAjGmerYanjeui5SHS7JkmpZvVipYvB2LJGU1ZxJwYvP9861l",              // invalid character
+		base58CheckEncode(append([]byte{WIFMainnet}, seckey[:31]...)),       // short
+		base58CheckEncode(append(append([]byte{WIFMainnet}, seckey...), 2)), // bad compression flag
+		base58CheckEncode(append(append([]byte{0xb0}, seckey...), 1)),       // Litecoin prefix
+	}
+	for _, s := range invalid {
+		if _, _, err := SeckeyFromWIF(s); !errors.Is(err, ErrParse) {
+			t.Errorf("SeckeyFromWIF(%q): got %v, want ErrParse", s, err)
+		}
+	}
+}
+
+func TestBase58(t *testing.T) {
+	vectors := []struct{ hex, enc string }{
+		{"", ""},
+		{"61", "2g"},
+		{"626262", "a3gV"},
+		{"636363", "aPEr"},
+		{"73696d706c792061206c6f6e6720737472696e67", "2cFupjhnEsSn59qHXstmK2ffpLv2"},
+		{"00eb15231dfceb60925886b67d065299925915aeb172c06647", "1NS17iag9jJgTHD1VXjvLCEnZuQ3rJDE9L"},
+		{"516b6fcd0f", "ABnLTmg"},
+		{"bf4f89001e670274dd", "3SEo3LWLoPntC"},
+		{"572e4794", "3EFU7m"},
+		{"ecac89cad93923c02321", "EJDM8drfXA6uyA"},
+		{"10c8511e", "Rt5zm"},
+		{"00000000000000000000", "1111111111"},
+	}
+	for _, v := range vectors {
+		b, _ := hex.DecodeString(v.hex)
+		if got := base58Encode(b); got != v.enc {
+			t.Errorf("base58Encode(%s) = %s, want %s", v.hex, got, v.enc)
+		}
+		if v.enc == "" {
+			continue
+		}
+		got, err := base58Decode(v.enc)
+		if err != nil || !bytes.Equal(got, b) {
+			t.Errorf("base58Decode(%s) = %x, %v, want %s", v.enc, got, err, v.hex)
+		}
+	}
+}